@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/eviltomorrow/open-terminal/apps/open-server/conf"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/controller"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/cache"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/knowledge"
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/session"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/tool"
 	"github.com/eviltomorrow/open-terminal/lib/buildinfo"
 	"github.com/eviltomorrow/open-terminal/lib/envutil"
 	"github.com/eviltomorrow/open-terminal/lib/finalizer"
@@ -13,8 +21,13 @@ import (
 	"github.com/eviltomorrow/open-terminal/lib/fs"
 	"github.com/eviltomorrow/open-terminal/lib/grpc/server"
 	"github.com/eviltomorrow/open-terminal/lib/pprofutil"
+	"github.com/eviltomorrow/open-terminal/lib/preset"
 	"github.com/eviltomorrow/open-terminal/lib/procutil"
+	"github.com/eviltomorrow/open-terminal/lib/qdrant"
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/setting"
 	"github.com/eviltomorrow/open-terminal/lib/system"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
 	"github.com/eviltomorrow/open-terminal/lib/zlog"
 	"go.uber.org/zap"
 )
@@ -60,13 +73,90 @@ func RunApp() error {
 		return fmt.Errorf("init network failure, nest error: %v", err)
 	}
 
+	var store vectorstore.VectorStore
+	switch c.VectorStore.Kind {
+	case vectorstore.KindQdrant:
+		closeQdrant, err := qdrant.InitQdrant(c.Qdrant)
+		if err != nil {
+			return fmt.Errorf("init qdrant failure, nest error: %v", err)
+		}
+		finalizer.RegisterCleanupFuncs(closeQdrant)
+		store = vectorstore.NewQdrant(qdrant.Client)
+	default:
+		store = vectorstore.NewMemory()
+	}
+
+	providers, err := llm.NewProviders(c.LLM)
+	if err != nil {
+		return fmt.Errorf("init llm providers failure, nest error: %v", err)
+	}
+
+	retriever, err := retrieval.NewRetriever(store, c.Retrieval)
+	if err != nil {
+		return fmt.Errorf("init retriever failure, nest error: %v", err)
+	}
+	// every write goes through the retriever, which drops the keyword index
+	// of the collection written
+	store = retriever.Store()
+
+	sessionOpts := []func(*llm.ChatSession){
+		llm.WithSessionForTools(tool.NewRegistry(tool.NewCurrentTime())),
+		llm.WithSessionForVectorStore(store),
+		llm.WithSessionForRetriever(retriever),
+	}
+	var (
+		kb          *knowledge.Base
+		catalogOpts []func(*llm.Catalog)
+	)
+	if c.LLM.Embedding != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), setting.DEFUALT_HANDLE_30_SECOND)
+		embedder, err := llm.NewEmbedder(ctx, c.LLM.Embedding)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("init llm embedder failure, nest error: %v", err)
+		}
+		sessionOpts = append(sessionOpts, llm.WithSessionForEmbedder(embedder))
+		catalogOpts = append(catalogOpts, llm.WithCatalogForEmbedding(preset.SetString(c.LLM.Embedding.Model, llm.KindLocal), embedder.Dimension()))
+		kb = knowledge.NewBase(store, embedder, retriever)
+
+		if c.Cache.Enabled {
+			ctx, cancel := context.WithTimeout(context.Background(), setting.DEFUALT_HANDLE_30_SECOND)
+			responseCache, err := cache.NewSemantic(ctx, store, embedder, c.Cache)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("init response cache failure, nest error: %v", err)
+			}
+			finalizer.RegisterCleanupFuncs(responseCache.Stop)
+			sessionOpts = append(sessionOpts, llm.WithSessionForResponseCache(responseCache))
+		}
+	} else {
+		zlog.Warn("Embedding is not configured, session memory, knowledge bases and response cache are disabled")
+	}
+
+	catalog := llm.NewCatalog(providers, catalogOpts...)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), setting.DEFUALT_HANDLE_30_SECOND)
+		defer cancel()
+		catalog.Refresh(ctx)
+	}()
+
+	states, err := session.NewStateStore(filepath.Join(system.Directory.VarDir, "sessions"))
+	if err != nil {
+		return fmt.Errorf("init session state store failure, nest error: %v", err)
+	}
+	registry := session.NewRegistry(c.Session.IdleTimeout, c.Session.CheckInterval, states)
+	finalizer.RegisterCleanupFuncs(registry.Stop)
+
+	collector := session.NewCollector(store, states, registry, c.Session.MemoryTTL, c.Session.GCInterval)
+	finalizer.RegisterCleanupFuncs(collector.Stop)
+
 	s := server.NewGRPC(
 		c.GRPC,
 		c.Log,
-		// controller.NewStorage().Service(),
+		controller.NewOpenAI(providers, catalog, registry, states, kb, sessionOpts...).Service(),
 	)
 	if err := s.Serve(); err != nil {
-		return fmt.Errorf("storage serve failure, nest error: %v", err)
+		return fmt.Errorf("open-server serve failure, nest error: %v", err)
 	}
 	finalizer.RegisterCleanupFuncs(s.Stop)
 
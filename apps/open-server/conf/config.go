@@ -1,16 +1,58 @@
 package conf
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/cache"
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
 	"github.com/eviltomorrow/open-terminal/lib/config"
 	"github.com/eviltomorrow/open-terminal/lib/flagsutil"
 	"github.com/eviltomorrow/open-terminal/lib/log"
 	"github.com/eviltomorrow/open-terminal/lib/network"
+	"github.com/eviltomorrow/open-terminal/lib/qdrant"
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
 	jsoniter "github.com/json-iterator/go"
 )
 
 type Config struct {
-	Log  *log.Config     `json:"log" toml:"log" mapstructure:"log"`
-	GRPC *network.Config `json:"grpc" toml:"grpc" mapstructure:"grpc"`
+	Log         *log.Config         `json:"log" toml:"log" mapstructure:"log"`
+	GRPC        *network.Config     `json:"grpc" toml:"grpc" mapstructure:"grpc"`
+	VectorStore *vectorstore.Config `json:"vector_store" toml:"vector_store" mapstructure:"vector_store"`
+	Qdrant      *qdrant.Config      `json:"qdrant" toml:"qdrant" mapstructure:"qdrant"`
+	LLM         *llm.Config         `json:"llm" toml:"llm" mapstructure:"llm"`
+	Retrieval   *retrieval.Config   `json:"retrieval" toml:"retrieval" mapstructure:"retrieval"`
+	Cache       *cache.Config       `json:"cache" toml:"cache" mapstructure:"cache"`
+	Session     *Session            `json:"session" toml:"session" mapstructure:"session"`
+}
+
+type Session struct {
+	IdleTimeout   time.Duration `json:"idle_timeout" toml:"idle_timeout" mapstructure:"idle_timeout"`
+	CheckInterval time.Duration `json:"check_interval" toml:"check_interval" mapstructure:"check_interval"`
+	MemoryTTL     time.Duration `json:"memory_ttl" toml:"memory_ttl" mapstructure:"memory_ttl"`
+	GCInterval    time.Duration `json:"gc_interval" toml:"gc_interval" mapstructure:"gc_interval"`
+}
+
+func (c *Session) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Session) VerifyConfig() error {
+	if c.IdleTimeout <= 0 {
+		return fmt.Errorf("session.idle_timeout has no value")
+	}
+	if c.CheckInterval <= 0 {
+		return fmt.Errorf("session.check_interval has no value")
+	}
+	if c.MemoryTTL <= 0 {
+		return fmt.Errorf("session.memory_ttl has no value")
+	}
+	if c.GCInterval <= 0 {
+		return fmt.Errorf("session.gc_interval has no value")
+	}
+	return nil
 }
 
 func (c *Config) String() string {
@@ -31,6 +73,17 @@ func (c *Config) IsConfigValid() error {
 	for _, f := range []func() error{
 		c.Log.VerifyConfig,
 		c.GRPC.VerifyConfig,
+		c.VectorStore.VerifyConfig,
+		func() error {
+			if c.VectorStore.Kind != vectorstore.KindQdrant {
+				return nil
+			}
+			return c.Qdrant.VerifyConfig()
+		},
+		c.LLM.VerifyConfig,
+		c.Retrieval.VerifyConfig,
+		c.Cache.VerifyConfig,
+		c.Session.VerifyConfig,
 	} {
 		if err := f(); err != nil {
 			return err
@@ -51,5 +104,33 @@ func InitializeDefaultConfig(opts *flagsutil.Flags) *Config {
 			BindPort:   50001,
 			DisableTLS: true,
 		},
+		VectorStore: &vectorstore.Config{
+			Kind: vectorstore.KindMemory,
+		},
+		Qdrant: &qdrant.Config{
+			StartupRetryPeriod: 3 * time.Second,
+			StartupRetryTimes:  3,
+			ConnectTimeout:     5 * time.Second,
+
+			Host: "localhost",
+			Port: 6334,
+		},
+		LLM: &llm.Config{
+			DefaultProvider: llm.KindKimi,
+			DefaultModel:    llm.DefaultKimiModel,
+		},
+		Retrieval: retrieval.DefaultConfig(),
+		Cache: &cache.Config{
+			Enabled:       false,
+			Threshold:     0.95,
+			TTL:           24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Session: &Session{
+			IdleTimeout:   30 * time.Minute,
+			CheckInterval: time.Minute,
+			MemoryTTL:     7 * 24 * time.Hour,
+			GCInterval:    time.Hour,
+		},
 	}
 }
@@ -0,0 +1,325 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Chat serves a conversation on one stream. Frames are read here while the
+// answer of the current turn is forwarded by its own goroutine, so that a
+// control frame takes effect in the middle of an answer. A failed turn is
+// reported in its turn_complete event, only a broken stream or a malformed
+// frame ends the call.
+func (c *OpenAI) Chat(stream grpc.BidiStreamingServer[pb.ChatFrame, pb.ChatEvent]) error {
+	conv := &conversation{c: c, stream: stream}
+	defer conv.stop()
+
+	for {
+		frame, err := stream.Recv()
+		if err == io.EOF {
+			conv.wait()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch f := frame.GetFrame().(type) {
+		case *pb.ChatFrame_Turn:
+			conv.turn(f.Turn)
+		case *pb.ChatFrame_Control:
+			if err := conv.control(f.Control); err != nil {
+				return err
+			}
+		default:
+			return status.Error(codes.InvalidArgument, "frame is nil")
+		}
+	}
+}
+
+// conversation is the state of one Chat stream, it is only touched by the
+// goroutine reading the frames.
+type conversation struct {
+	c      *OpenAI
+	stream grpc.BidiStreamingServer[pb.ChatFrame, pb.ChatEvent]
+	sendMu sync.Mutex
+
+	sessionId   string
+	last        *pb.ChatReq
+	temperature *float32
+	system      *string
+	turns       int32
+	generation  *generation
+}
+
+// generation is the answer being forwarded.
+type generation struct {
+	cancel  context.CancelFunc
+	stopped atomic.Bool
+	done    chan struct{}
+}
+
+func (v *conversation) turn(req *pb.ChatReq) {
+	v.stop()
+	v.turns++
+
+	if req == nil || req.Content == "" {
+		v.fail(status.Error(codes.InvalidArgument, "content is nil"))
+		return
+	}
+	sessionId := v.sessionId
+	if req.SessionId != "" {
+		sessionId = req.SessionId
+	}
+
+	ctx, cancel := context.WithCancel(v.stream.Context())
+	st, refs, err := v.ask(ctx, sessionId, req)
+	if err != nil {
+		cancel()
+		v.fail(err)
+		return
+	}
+	v.last = req
+	v.start(cancel, st, refs)
+}
+
+// ask starts the answer of req in the session, or in a new one when sessionId
+// is empty.
+func (v *conversation) ask(ctx context.Context, sessionId string, req *pb.ChatReq) (*llm.Stream, []*llm.Reference, error) {
+	opts, err := v.requestOpts(req, sessionId != "", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs, err := v.c.references(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(opts, llm.WithChatCompletionRequestForReferences(refs...))
+	ctx = chatContext(ctx, req)
+
+	if sessionId != "" {
+		s, ok := v.c.registry.Get(sessionId)
+		if !ok {
+			return nil, nil, status.Errorf(codes.NotFound, "session not found, id: %s", sessionId)
+		}
+		if v.system != nil && sessionId != v.sessionId {
+			s.SetSystemPrompt(*v.system)
+		}
+		st, err := s.Send(ctx, roleToString(req.Role), req.Content, opts...)
+		if err != nil {
+			return nil, nil, chatError("send chat failure", err)
+		}
+		v.sessionId = sessionId
+		return st, refs, nil
+	}
+
+	provider, modelName, err := v.c.resolve(req.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := provider.NewSession(modelName, v.c.sessionOpts...)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "new session failure, nest error: %v", err)
+	}
+	if v.system != nil {
+		s.SetSystemPrompt(*v.system)
+	}
+	// registered before its memory is made, as CreateSession does
+	v.c.registry.Add(s)
+	st, err := s.StartChat(ctx, roleToString(req.Role), req.Content, opts...)
+	if err != nil {
+		v.c.registry.Remove(s.GetId())
+		return nil, nil, chatError("start chat failure", err)
+	}
+	v.sessionId = s.GetId()
+	return st, refs, nil
+}
+
+func (v *conversation) control(ctl *pb.Control) error {
+	switch ctl.GetAction() {
+	case pb.Control_STOP:
+		v.stop()
+
+	case pb.Control_SET_TEMPERATURE:
+		if ctl.Temperature < 0 {
+			return status.Errorf(codes.InvalidArgument, "temperature is negative, temperature: %v", ctl.Temperature)
+		}
+		temperature := ctl.Temperature
+		v.temperature = &temperature
+
+	case pb.Control_SET_SYSTEM:
+		system := ctl.System
+		v.system = &system
+		if s, ok := v.c.registry.Get(v.sessionId); ok {
+			s.SetSystemPrompt(system)
+		}
+
+	case pb.Control_REGENERATE:
+		v.stop()
+		v.turns++
+
+		ctx, cancel := context.WithCancel(v.stream.Context())
+		st, refs, err := v.regenerate(ctx)
+		if err != nil {
+			cancel()
+			v.fail(err)
+			return nil
+		}
+		v.start(cancel, st, refs)
+
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown control action, action: %v", ctl.GetAction())
+	}
+	return nil
+}
+
+// regenerate asks the last turn again, with its params and knowledge bases
+// but without its attachments, whose text is in the history already.
+func (v *conversation) regenerate(ctx context.Context) (*llm.Stream, []*llm.Reference, error) {
+	if v.last == nil || v.sessionId == "" {
+		return nil, nil, status.Error(codes.FailedPrecondition, "no turn to regenerate")
+	}
+	s, ok := v.c.registry.Get(v.sessionId)
+	if !ok {
+		return nil, nil, status.Errorf(codes.NotFound, "session not found, id: %s", v.sessionId)
+	}
+
+	req := proto.Clone(v.last).(*pb.ChatReq)
+	req.Attachments = nil
+	opts, err := v.requestOpts(req, true, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs, err := v.c.references(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(opts, llm.WithChatCompletionRequestForReferences(refs...))
+
+	st, err := s.Regenerate(chatContext(ctx, req), opts...)
+	if err != nil {
+		return nil, nil, chatError("regenerate chat failure", err)
+	}
+	return st, refs, nil
+}
+
+// requestOpts adds the temperature of the conversation to the opts of req, it
+// gives way to the params of a turn but not of a regenerated one. The model of
+// req is switched to when the session exists already.
+func (v *conversation) requestOpts(req *pb.ChatReq, switchModel bool, regenerate bool) ([]func(*openai.ChatCompletionRequest), error) {
+	opts, err := requestOpts(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Model != "" && switchModel {
+		_, modelName, err := v.c.resolve(req.Model)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, llm.WithChatCompletionRequestForModel(modelName))
+	}
+	if v.temperature == nil {
+		return opts, nil
+	}
+
+	temperature := llm.WithChatCompletionRequestForTemperature(*v.temperature)
+	if regenerate {
+		return append(opts, temperature), nil
+	}
+	return append([]func(*openai.ChatCompletionRequest){temperature}, opts...), nil
+}
+
+// start forwards st until it ends, then completes the turn.
+func (v *conversation) start(cancel context.CancelFunc, st *llm.Stream, refs []*llm.Reference) {
+	g := &generation{cancel: cancel, done: make(chan struct{})}
+	v.generation = g
+
+	sessionId, turn := v.sessionId, v.turns
+	// the session is not expired while its answer is forwarded
+	streamed := v.c.registry.Streaming(sessionId)
+	go func() {
+		defer close(g.done)
+		defer streamed()
+		defer cancel()
+		defer st.Close()
+
+		var answer strings.Builder
+		for event := range st.Events() {
+			if len(refs) != 0 && event.Index == 0 {
+				answer.WriteString(event.Content)
+			}
+			if err := v.send(&pb.ChatEvent{Event: &pb.ChatEvent_Delta{Delta: eventToChatResp(sessionId, event)}}); err != nil {
+				zlog.Error("Send chat event failure", zap.Error(err), zap.String("sessionId", sessionId))
+				return
+			}
+		}
+
+		done := &pb.TurnComplete{
+			SessionId:    sessionId,
+			Turn:         turn,
+			FinishReason: string(st.FinishReason()),
+			Cached:       st.Cached(),
+			Citations:    citationsToPb(answer.String(), refs),
+			Usage:        usageToPb(st.Usage()),
+		}
+		if err := st.Err(); err != nil {
+			if g.stopped.Load() && errors.Is(err, context.Canceled) {
+				done.Stopped = true
+			} else {
+				s := status.Convert(streamError(err))
+				done.Code, done.Error = int32(s.Code()), s.Message()
+			}
+		}
+		if err := v.send(&pb.ChatEvent{Event: &pb.ChatEvent_TurnComplete{TurnComplete: done}}); err != nil {
+			zlog.Error("Send chat event failure", zap.Error(err), zap.String("sessionId", sessionId))
+		}
+	}()
+}
+
+// stop stops the answer being forwarded and waits until its turn completes.
+func (v *conversation) stop() {
+	if v.generation == nil {
+		return
+	}
+	v.generation.stopped.Store(true)
+	v.generation.cancel()
+	v.wait()
+}
+
+func (v *conversation) wait() {
+	if v.generation == nil {
+		return
+	}
+	<-v.generation.done
+	v.generation = nil
+}
+
+// fail completes the current turn with err.
+func (v *conversation) fail(err error) {
+	s := status.Convert(err)
+	done := &pb.TurnComplete{SessionId: v.sessionId, Turn: v.turns, Code: int32(s.Code()), Error: s.Message()}
+	if err := v.send(&pb.ChatEvent{Event: &pb.ChatEvent_TurnComplete{TurnComplete: done}}); err != nil {
+		zlog.Error("Send chat event failure", zap.Error(err), zap.String("sessionId", v.sessionId))
+	}
+}
+
+func (v *conversation) send(event *pb.ChatEvent) error {
+	v.sendMu.Lock()
+	defer v.sendMu.Unlock()
+
+	return v.stream.Send(event)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// recvTurn returns the content of a turn and its turn_complete event.
+func recvTurn(stream grpc.BidiStreamingClient[pb.ChatFrame, pb.ChatEvent]) (string, *pb.TurnComplete, error) {
+	var buf strings.Builder
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return buf.String(), nil, err
+		}
+		if done := event.GetTurnComplete(); done != nil {
+			return buf.String(), done, nil
+		}
+		buf.WriteString(event.GetDelta().GetMessage().GetContent())
+	}
+}
+
+func turnFrame(content string) *pb.ChatFrame {
+	return &pb.ChatFrame{Frame: &pb.ChatFrame_Turn{Turn: &pb.ChatReq{Role: pb.Role_USER, Content: content}}}
+}
+
+func controlFrame(action pb.Control_Action, temperature float32) *pb.ChatFrame {
+	return &pb.ChatFrame{Frame: &pb.ChatFrame_Control{Control: &pb.Control{Action: action, Temperature: temperature}}}
+}
+
+func TestChat(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+	stream, err := client.Chat(context.Background())
+	_assert.Nil(err)
+
+	server.Enqueue(llmtest.Tokens("你", "好"))
+	_assert.Nil(stream.Send(turnFrame("hi")))
+	content, done, err := recvTurn(stream)
+	_assert.Nil(err)
+	_assert.Equal("你好", content)
+	_assert.Equal(int32(1), done.Turn)
+	_assert.Equal(string(openai.FinishReasonStop), done.FinishReason)
+	_assert.Equal(int32(2), done.Usage.CompletionTokens)
+	_assert.Equal(done.Usage.PromptTokens+2, done.Usage.TotalTokens)
+	_assert.NotEmpty(done.SessionId)
+	sessionId := done.SessionId
+
+	// stop in the middle of an answer
+	server.Enqueue(llmtest.Tokens("long ", "answer").WithDelay(20 * time.Millisecond).WithHang())
+	_assert.Nil(stream.Send(turnFrame("tell me a story")))
+	event, err := stream.Recv()
+	_assert.Nil(err)
+	_assert.Equal("long ", event.GetDelta().GetMessage().GetContent())
+	_assert.Nil(stream.Send(controlFrame(pb.Control_STOP, 0)))
+	_, done, err = recvTurn(stream)
+	_assert.Nil(err)
+	_assert.True(done.Stopped)
+	_assert.Equal(int32(0), done.Code)
+	_assert.Equal(sessionId, done.SessionId)
+
+	// change the temperature and regenerate the stopped answer
+	server.Enqueue(llmtest.Tokens("another ", "story"))
+	_assert.Nil(stream.Send(controlFrame(pb.Control_SET_TEMPERATURE, 0.3)))
+	_assert.Nil(stream.Send(controlFrame(pb.Control_REGENERATE, 0)))
+	content, done, err = recvTurn(stream)
+	_assert.Nil(err)
+	_assert.Equal("another story", content)
+	_assert.Equal(int32(3), done.Turn)
+
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	_assert.InDelta(0.3, last.Temperature, 1e-6)
+	var asked int
+	for _, message := range last.Messages {
+		_assert.NotContains(message.Content, "long")
+		if message.Content == "tell me a story" {
+			asked++
+		}
+	}
+	_assert.Equal(1, asked)
+
+	// a failed turn keeps the stream open
+	_assert.Nil(stream.Send(turnFrame("")))
+	_, done, err = recvTurn(stream)
+	_assert.Nil(err)
+	_assert.Equal(int32(codes.InvalidArgument), done.Code)
+
+	_assert.Nil(stream.CloseSend())
+	_, _, err = recvTurn(stream)
+	_assert.NotNil(err)
+}
+
+func TestChatSystem(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+	stream, err := client.Chat(context.Background())
+	_assert.Nil(err)
+
+	_assert.Nil(stream.Send(&pb.ChatFrame{Frame: &pb.ChatFrame_Control{Control: &pb.Control{Action: pb.Control_SET_SYSTEM, System: "be brief"}}}))
+	_assert.Nil(stream.Send(turnFrame("hi")))
+	_, _, err = recvTurn(stream)
+	_assert.Nil(err)
+
+	_assert.Nil(stream.Send(&pb.ChatFrame{Frame: &pb.ChatFrame_Control{Control: &pb.Control{Action: pb.Control_SET_SYSTEM, System: "answer in Chinese"}}}))
+	_assert.Nil(stream.Send(turnFrame("again")))
+	_, _, err = recvTurn(stream)
+	_assert.Nil(err)
+
+	requests := server.Requests()
+	_assert.Equal(2, len(requests))
+	_assert.Equal(openai.ChatMessageRoleSystem, requests[0].Messages[0].Role)
+	_assert.Equal("be brief", requests[0].Messages[0].Content)
+	_assert.Equal("answer in Chinese", requests[1].Messages[0].Content)
+	_assert.Equal(openai.ChatMessageRoleSystem, requests[1].Messages[0].Role)
+	_assert.NotEqual(openai.ChatMessageRoleSystem, requests[1].Messages[1].Role)
+}
@@ -0,0 +1,590 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/knowledge"
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/session"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// knowledgeHits is the number of chunks given to the model when a chat request
+// names knowledge bases.
+const knowledgeHits = 5
+
+const tenantMetadataKey = "x-tenant-id"
+
+type OpenAI struct {
+	providers   *llm.Providers
+	catalog     *llm.Catalog
+	registry    *session.Registry
+	states      *session.StateStore
+	knowledge   *knowledge.Base
+	sessionOpts []func(*llm.ChatSession)
+
+	pb.UnimplementedOpenAIServer
+}
+
+// NewOpenAI creates the controller, kb is nil when no embedder is configured and
+// knowledge bases are disabled then.
+func NewOpenAI(providers *llm.Providers, catalog *llm.Catalog, registry *session.Registry, states *session.StateStore, kb *knowledge.Base, sessionOpts ...func(*llm.ChatSession)) *OpenAI {
+	return &OpenAI{
+		providers:   providers,
+		catalog:     catalog,
+		registry:    registry,
+		states:      states,
+		knowledge:   kb,
+		sessionOpts: sessionOpts,
+	}
+}
+
+func (c *OpenAI) Service() func(*grpc.Server) {
+	return func(server *grpc.Server) {
+		pb.RegisterOpenAIServer(server, c)
+	}
+}
+
+func (c *OpenAI) CreateChat(req *pb.ChatReq, stream grpc.ServerStreamingServer[pb.ChatResp]) error {
+	if req == nil || req.Content == "" {
+		return status.Error(codes.InvalidArgument, "content is nil")
+	}
+
+	provider, modelName, err := c.resolve(req.Model)
+	if err != nil {
+		return err
+	}
+	opts, err := requestOpts(req)
+	if err != nil {
+		return err
+	}
+	refs, err := c.references(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, llm.WithChatCompletionRequestForReferences(refs...))
+	s, err := provider.NewSession(modelName, c.sessionOpts...)
+	if err != nil {
+		return status.Errorf(codes.Internal, "new session failure, nest error: %v", err)
+	}
+	// the session is never registered, its memory is younger than the memory
+	// ttl of the collector meanwhile and Close drops it
+	defer s.Close()
+
+	st, err := s.StartChat(chatContext(stream.Context(), req), roleToString(req.Role), req.Content, opts...)
+	if err != nil {
+		return chatError("start chat failure", err)
+	}
+	return sendChatResp(s.GetId(), st, refs, stream)
+}
+
+func (c *OpenAI) CreateSession(req *pb.ChatReq, stream grpc.ServerStreamingServer[pb.ChatResp]) error {
+	if req == nil || req.Content == "" {
+		return status.Error(codes.InvalidArgument, "content is nil")
+	}
+
+	provider, modelName, err := c.resolve(req.Model)
+	if err != nil {
+		return err
+	}
+	opts, err := requestOpts(req)
+	if err != nil {
+		return err
+	}
+	refs, err := c.references(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, llm.WithChatCompletionRequestForReferences(refs...))
+	s, err := provider.NewSession(modelName, c.sessionOpts...)
+	if err != nil {
+		return status.Errorf(codes.Internal, "new session failure, nest error: %v", err)
+	}
+
+	// the session is registered before its memory is made, so that the
+	// collector never takes it for one left behind
+	c.registry.Add(s)
+	defer c.registry.Streaming(s.GetId())()
+	st, err := s.StartChat(chatContext(stream.Context(), req), roleToString(req.Role), req.Content, opts...)
+	if err != nil {
+		c.registry.Remove(s.GetId())
+		return chatError("start chat failure", err)
+	}
+
+	return sendChatResp(s.GetId(), st, refs, stream)
+}
+
+func (c *OpenAI) Send(req *pb.ChatReq, stream grpc.ServerStreamingServer[pb.ChatResp]) error {
+	if req == nil || req.Content == "" {
+		return status.Error(codes.InvalidArgument, "content is nil")
+	}
+	if req.SessionId == "" {
+		return status.Error(codes.InvalidArgument, "session_id is nil")
+	}
+
+	s, ok := c.registry.Get(req.SessionId)
+	if !ok {
+		return status.Errorf(codes.NotFound, "session not found, id: %s", req.SessionId)
+	}
+	defer c.registry.Streaming(req.SessionId)()
+
+	opts, err := requestOpts(req)
+	if err != nil {
+		return err
+	}
+	refs, err := c.references(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, llm.WithChatCompletionRequestForReferences(refs...))
+	if req.Model != "" {
+		_, modelName, err := c.resolve(req.Model)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, llm.WithChatCompletionRequestForModel(modelName))
+	}
+
+	st, err := s.Send(chatContext(stream.Context(), req), roleToString(req.Role), req.Content, opts...)
+	if err != nil {
+		return chatError("send chat failure", err)
+	}
+	return sendChatResp(s.GetId(), st, refs, stream)
+}
+
+func (c *OpenAI) CloseSession(ctx context.Context, req *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	if req == nil || req.Value == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is nil")
+	}
+
+	if err := c.registry.Remove(req.Value); err != nil {
+		return nil, status.Errorf(codes.NotFound, "close session failure, nest error: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *OpenAI) ListSessions(ctx context.Context, _ *emptypb.Empty) (*pb.Sessions, error) {
+	infos := c.registry.List()
+
+	data := make([]*pb.Session, 0, len(infos))
+	for _, info := range infos {
+		data = append(data, &pb.Session{
+			Id:           info.Id,
+			Model:        info.ModelName,
+			Provider:     info.Provider,
+			CreatedAt:    info.CreatedAt.Unix(),
+			LastActiveAt: info.LastActiveAt.Unix(),
+			Persisted:    info.Persisted,
+		})
+	}
+	return &pb.Sessions{Sessions: data}, nil
+}
+
+func (c *OpenAI) SaveSession(ctx context.Context, req *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	if req == nil || req.Value == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is nil")
+	}
+
+	if !c.registry.Has(req.Value) {
+		return nil, status.Errorf(codes.NotFound, "session not found, id: %s", req.Value)
+	}
+	if err := c.registry.Persist(req.Value); err != nil {
+		return nil, status.Errorf(codes.Internal, "save session failure, nest error: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *OpenAI) OpenSession(ctx context.Context, req *wrapperspb.StringValue) (*pb.Session, error) {
+	if req == nil || req.Value == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is nil")
+	}
+
+	if !c.registry.Has(req.Value) {
+		state, err := c.states.Load(req.Value)
+		if err == session.ErrStateNotFound {
+			return nil, status.Errorf(codes.NotFound, "session not found, id: %s", req.Value)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "load session failure, nest error: %v", err)
+		}
+
+		provider, ok := c.providers.Get(state.Provider)
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "provider not found, name: %s", state.Provider)
+		}
+		s, err := provider.NewSession(state.ModelName, c.sessionOpts...)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "new session failure, nest error: %v", err)
+		}
+		if err := s.Restore(ctx, state); err != nil {
+			return nil, status.Errorf(codes.Internal, "restore session failure, nest error: %v", err)
+		}
+		c.registry.Add(s)
+		zlog.Info("Session reopened", zap.String("sessionId", s.GetId()))
+	}
+
+	for _, info := range c.registry.List() {
+		if info.Id == req.Value {
+			return &pb.Session{
+				Id:           info.Id,
+				Model:        info.ModelName,
+				Provider:     info.Provider,
+				CreatedAt:    info.CreatedAt.Unix(),
+				LastActiveAt: info.LastActiveAt.Unix(),
+				Persisted:    info.Persisted,
+			}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "session not found, id: %s", req.Value)
+}
+
+func (c *OpenAI) Ingest(ctx context.Context, req *pb.IngestReq) (*pb.IngestResp, error) {
+	if req == nil || req.KnowledgeBase == "" {
+		return nil, status.Error(codes.InvalidArgument, "knowledge_base is nil")
+	}
+	if c.knowledge == nil {
+		return nil, status.Error(codes.FailedPrecondition, "knowledge base is disabled, llm.embedding is not configured")
+	}
+
+	splitter, err := knowledge.NewSplitter(int(req.ChunkSize), int(req.ChunkOverlap))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	docs := make([]*knowledge.Document, 0, len(req.Documents))
+	for _, doc := range req.Documents {
+		docs = append(docs, &knowledge.Document{Source: doc.Source, MIMEType: doc.MimeType, Content: doc.Content})
+	}
+
+	n, err := c.knowledge.Ingest(ctx, req.KnowledgeBase, splitter, docs...)
+	if errors.Is(err, knowledge.ErrInvalidDocument) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "ingest documents failure, nest error: %v", err)
+	}
+	return &pb.IngestResp{KnowledgeBase: req.KnowledgeBase, Documents: int32(len(docs)), Chunks: int32(n)}, nil
+}
+
+func (c *OpenAI) ListModels(ctx context.Context, req *pb.ListModelsReq) (*pb.Models, error) {
+	models := c.catalog.List(ctx, req.GetRefresh())
+
+	resp := &pb.Models{Models: make([]*pb.Model, 0, len(models))}
+	for _, model := range models {
+		resp.Models = append(resp.Models, modelToPb(model))
+	}
+	return resp, nil
+}
+
+// resolve picks the provider and the model of a chat request, a model its
+// provider does not list upstream is refused.
+func (c *OpenAI) resolve(model string) (llm.Provider, string, error) {
+	provider, modelName, err := c.providers.Resolve(model)
+	if err != nil {
+		return nil, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !c.catalog.Available(provider.Name(), modelName) {
+		return nil, "", status.Errorf(codes.InvalidArgument, "model is not served by provider[%s] upstream, model: %s", provider.Name(), modelName)
+	}
+	return provider, modelName, nil
+}
+
+// references searches the knowledge bases named by req for its content.
+func (c *OpenAI) references(ctx context.Context, req *pb.ChatReq) ([]*llm.Reference, error) {
+	if len(req.KnowledgeBases) == 0 {
+		return nil, nil
+	}
+	if c.knowledge == nil {
+		return nil, status.Error(codes.FailedPrecondition, "knowledge base is disabled, llm.embedding is not configured")
+	}
+
+	hits, err := c.knowledge.Search(ctx, req.KnowledgeBases, req.Content, knowledgeHits)
+	switch {
+	case errors.Is(err, knowledge.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, knowledge.ErrInvalidDocument):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "search knowledge base failure, nest error: %v", err)
+	}
+
+	refs := make([]*llm.Reference, 0, len(hits))
+	for i, hit := range hits {
+		refs = append(refs, &llm.Reference{
+			Index:         i + 1,
+			KnowledgeBase: hit.KnowledgeBase,
+			Source:        hit.Source,
+			Heading:       hit.Heading,
+			StartLine:     hit.StartLine,
+			EndLine:       hit.EndLine,
+			Content:       hit.Text,
+			Score:         float32(hit.Score),
+		})
+	}
+	return refs, nil
+}
+
+// sendChatResp forwards st to the client, st is generated under the context of
+// the client stream, so it stops as soon as the client goes away. The last
+// frame carries the refs cited by the answer.
+func sendChatResp(sessionId string, st *llm.Stream, refs []*llm.Reference, stream grpc.ServerStreamingServer[pb.ChatResp]) error {
+	defer st.Close()
+
+	var answer strings.Builder
+	for event := range st.Events() {
+		if len(refs) != 0 && event.Index == 0 {
+			answer.WriteString(event.Content)
+		}
+		if err := stream.Send(eventToChatResp(sessionId, event)); err != nil {
+			zlog.Error("Send chat resp failure", zap.Error(err), zap.String("sessionId", sessionId))
+			return err
+		}
+	}
+	if err := st.Err(); err != nil {
+		return streamError(err)
+	}
+
+	return stream.Send(&pb.ChatResp{
+		SessionId:    sessionId,
+		FinishReason: string(st.FinishReason()),
+		Cached:       st.Cached(),
+		Citations:    citationsToPb(answer.String(), refs),
+		Usage:        usageToPb(st.Usage()),
+	})
+}
+
+func usageToPb(usage openai.Usage) *pb.Usage {
+	return &pb.Usage{
+		PromptTokens:     int32(usage.PromptTokens),
+		CompletionTokens: int32(usage.CompletionTokens),
+		TotalTokens:      int32(usage.TotalTokens),
+	}
+}
+
+// citationsToPb returns the refs cited by answer.
+func citationsToPb(answer string, refs []*llm.Reference) []*pb.Citation {
+	var data []*pb.Citation
+	for _, ref := range llm.CitedReferences(answer, refs) {
+		data = append(data, &pb.Citation{
+			Index:         int32(ref.Index),
+			KnowledgeBase: ref.KnowledgeBase,
+			Source:        ref.Source,
+			Heading:       ref.Heading,
+			StartLine:     int32(ref.StartLine),
+			EndLine:       int32(ref.EndLine),
+			Score:         ref.Score,
+		})
+	}
+	return data
+}
+
+// chatContext tags ctx with the tenant of the caller, taken from the
+// x-tenant-id metadata, and with the cache bypass of req. The metadata is not
+// authenticated, any caller may claim any tenant and read its cached answers.
+func chatContext(ctx context.Context, req *pb.ChatReq) context.Context {
+	if values := metadata.ValueFromIncomingContext(ctx, tenantMetadataKey); len(values) != 0 {
+		ctx = llm.WithTenant(ctx, values[0])
+	}
+	if req.NoCache {
+		ctx = llm.WithoutResponseCache(ctx)
+	}
+	return ctx
+}
+
+func chatError(msg string, err error) error {
+	if errors.Is(err, llm.ErrInvalidParams) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Errorf(upstreamCode(err), "%s, nest error: %v", msg, err)
+}
+
+// upstreamCode tells the code of a failure of the provider by its http status,
+// so that a client can tell a rejected api key or a rate limit from a failure
+// of the server.
+func upstreamCode(err error) codes.Code {
+	var httpStatus int
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.As(err, &apiErr):
+		httpStatus = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		httpStatus = reqErr.HTTPStatusCode
+	}
+
+	switch httpStatus {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+func streamError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "chat is canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "chat is timeout")
+	case errors.Is(err, llm.ErrInvalidOutput):
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Errorf(upstreamCode(err), "stream chat failure, nest error: %v", err)
+	}
+}
+
+func eventToChatResp(sessionId string, event *llm.Event) *pb.ChatResp {
+	resp := &pb.ChatResp{SessionId: sessionId, Index: int32(event.Index)}
+	switch {
+	case event.ToolCall != nil:
+		resp.ToolCall = &pb.ToolCall{
+			Id:        event.ToolCall.Id,
+			Name:      event.ToolCall.Name,
+			Arguments: event.ToolCall.Arguments,
+		}
+	case event.JSON != "":
+		resp.Json = event.JSON
+	case event.ToolResult != nil:
+		resp.ToolResult = &pb.ToolResult{
+			Id:      event.ToolResult.Id,
+			Name:    event.ToolResult.Name,
+			Content: event.ToolResult.Content,
+			IsError: event.ToolResult.IsError,
+		}
+	default:
+		resp.Message = &pb.Message{Content: event.Content}
+	}
+	return resp
+}
+
+func modelToPb(model *llm.ModelInfo) *pb.Model {
+	data := &pb.Model{
+		Provider:           model.Provider,
+		Name:               model.Name,
+		Default:            model.Default,
+		ContextWindow:      int32(model.ContextWindow),
+		MaxOutputTokens:    int32(model.MaxOutputTokens),
+		Vision:             model.Vision,
+		Tools:              model.Tools,
+		JsonMode:           model.JSONMode,
+		JsonSchema:         model.JSONSchema,
+		EmbeddingDimension: int32(model.EmbeddingDimension),
+		OwnedBy:            model.OwnedBy,
+	}
+	if model.Kind == llm.ModelKindEmbedding {
+		data.Kind = pb.ModelKind_EMBEDDING
+	}
+	switch model.Status {
+	case llm.ModelStatusAvailable:
+		data.Status = pb.ModelStatus_AVAILABLE
+	case llm.ModelStatusUnavailable:
+		data.Status = pb.ModelStatus_UNAVAILABLE
+	}
+	if model.InputPrice > 0 || model.OutputPrice > 0 {
+		data.Pricing = &pb.Pricing{Input: model.InputPrice, Output: model.OutputPrice, Currency: model.Currency}
+	}
+	return data
+}
+
+func roleToString(role pb.Role) string {
+	switch role {
+	case pb.Role_SYSTEM:
+		return openai.ChatMessageRoleSystem
+	case pb.Role_USER:
+		return openai.ChatMessageRoleUser
+	case pb.Role_ASSISTANT:
+		return openai.ChatMessageRoleAssistant
+	case pb.Role_FUNCTION:
+		return openai.ChatMessageRoleFunction
+	case pb.Role_TOOL:
+		return openai.ChatMessageRoleTool
+	case pb.Role_DEVELOP:
+		return openai.ChatMessageRoleDeveloper
+	default:
+		return openai.ChatMessageRoleUser
+	}
+}
+
+// requestOpts turns the attachments, the params and the json schema of req into
+// request options.
+func requestOpts(req *pb.ChatReq) ([]func(*openai.ChatCompletionRequest), error) {
+	opts := paramsToOpts(req.Params)
+	if len(req.Attachments) != 0 {
+		attachments := make([]*llm.Attachment, 0, len(req.Attachments))
+		for _, a := range req.Attachments {
+			attachments = append(attachments, &llm.Attachment{Name: a.Name, MIMEType: a.MimeType, Data: a.Data, URL: a.Url})
+		}
+		if err := llm.CheckAttachments(attachments...); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		opts = append(opts, llm.WithChatCompletionRequestForAttachments(attachments...))
+	}
+	if req.JsonSchema == nil {
+		return opts, nil
+	}
+
+	maxRetries := -1
+	if req.JsonSchema.MaxRetries != nil {
+		maxRetries = int(req.JsonSchema.GetMaxRetries())
+	}
+	schema, err := llm.NewSchema(req.JsonSchema.Schema, maxRetries)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return append(opts, llm.WithChatCompletionRequestForJSONSchema(schema)), nil
+}
+
+func paramsToOpts(params *pb.GenerationParams) []func(*openai.ChatCompletionRequest) {
+	if params == nil {
+		return nil
+	}
+
+	opts := make([]func(*openai.ChatCompletionRequest), 0, 9)
+	if params.Temperature != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForTemperature(params.GetTemperature()))
+	}
+	if params.MaxTokens != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForMaxTokens(int(params.GetMaxTokens())))
+	}
+	if params.TopP != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForTopP(params.GetTopP()))
+	}
+	if len(params.Stop) != 0 {
+		opts = append(opts, llm.WithChatCompletionRequestForStop(params.Stop...))
+	}
+	if params.PresencePenalty != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForPresencePenalty(params.GetPresencePenalty()))
+	}
+	if params.FrequencyPenalty != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForFrequencyPenalty(params.GetFrequencyPenalty()))
+	}
+	if params.Seed != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForSeed(int(params.GetSeed())))
+	}
+	if params.N != nil {
+		opts = append(opts, llm.WithChatCompletionRequestForN(int(params.GetN())))
+	}
+	if params.ResponseFormat == pb.ResponseFormat_JSON_OBJECT {
+		opts = append(opts, llm.WithChatCompletionRequestForJSONObject())
+	}
+	return opts
+}
@@ -0,0 +1,307 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/knowledge"
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/apps/open-server/domain/session"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// newTestClient serves the controller over an in-memory connection, with the
+// model replaced by a mock server.
+func newTestClient(t *testing.T) (pb.OpenAIClient, *llmtest.Server) {
+	server := llmtest.NewServer()
+	t.Cleanup(server.Close)
+
+	providers, err := llm.NewProviders(&llm.Config{
+		DefaultProvider: "kimi",
+		DefaultModel:    "moonshot-v1-8k",
+		Providers: []*llm.ProviderConfig{
+			{Name: "kimi", Kind: llm.KindKimi, BaseURL: server.BaseURL(), APIKey: "mock", Models: []string{"moonshot-v1-8k"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProviders failure, nest error: %v", err)
+	}
+
+	states, err := session.NewStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStateStore failure, nest error: %v", err)
+	}
+	registry := session.NewRegistry(time.Minute, time.Hour, states)
+	t.Cleanup(func() { registry.Stop() })
+
+	store, embedder := vectorstore.NewMemory(), llm.NewLocalEmbedder(0)
+	retriever, err := retrieval.NewRetriever(store, nil)
+	if err != nil {
+		t.Fatalf("NewRetriever failure, nest error: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	NewOpenAI(providers, llm.NewCatalog(providers), registry, states, knowledge.NewBase(retriever.Store(), embedder, retriever),
+		llm.WithSessionForEmbedder(embedder),
+		llm.WithSessionForVectorStore(retriever.Store()),
+		llm.WithSessionForRetriever(retriever),
+	).Service()(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient failure, nest error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewOpenAIClient(conn), server
+}
+
+// recvAll returns the content, session id and finish reason of a chat stream.
+func recvAll(stream grpc.ServerStreamingClient[pb.ChatResp]) (string, string, string, error) {
+	var (
+		buf                     strings.Builder
+		sessionId, finishReason string
+	)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return buf.String(), sessionId, finishReason, nil
+		}
+		if err != nil {
+			return buf.String(), sessionId, finishReason, err
+		}
+		sessionId = resp.SessionId
+		finishReason = resp.FinishReason
+		buf.WriteString(resp.GetMessage().GetContent())
+	}
+}
+
+func TestCreateChat(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+	server.Enqueue(llmtest.Tokens("he", "llo"), llmtest.Error(400, "bad request"), llmtest.Error(401, "invalid api key"))
+
+	stream, err := client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER, Content: "hi"})
+	_assert.Nil(err)
+	content, _, finishReason, err := recvAll(stream)
+	_assert.Nil(err)
+	_assert.Equal("hello", content)
+	_assert.Equal("stop", finishReason)
+
+	stream, err = client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER, Content: "hi"})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Equal(codes.Internal, status.Code(err))
+
+	// a rejected api key is told from a failure of the server
+	stream, err = client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER, Content: "hi"})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Equal(codes.Unauthenticated, status.Code(err))
+
+	stream, err = client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+	server.Enqueue(llmtest.Tokens("first"), llmtest.Tokens("second"), llmtest.Tokens("third"))
+	ctx := context.Background()
+
+	stream, err := client.CreateSession(ctx, &pb.ChatReq{Role: pb.Role_USER, Content: "one"})
+	_assert.Nil(err)
+	content, sessionId, _, err := recvAll(stream)
+	_assert.Nil(err)
+	_assert.Equal("first", content)
+	_assert.NotEmpty(sessionId)
+
+	stream, err = client.Send(ctx, &pb.ChatReq{Role: pb.Role_USER, Content: "two", SessionId: sessionId})
+	_assert.Nil(err)
+	content, _, _, err = recvAll(stream)
+	_assert.Nil(err)
+	_assert.Equal("second", content)
+
+	sessions, err := client.ListSessions(ctx, &emptypb.Empty{})
+	_assert.Nil(err)
+	_assert.Equal(1, len(sessions.Sessions))
+	_assert.Equal(sessionId, sessions.Sessions[0].Id)
+	_assert.Equal("kimi", sessions.Sessions[0].Provider)
+
+	_, err = client.SaveSession(ctx, wrapperspb.String(sessionId))
+	_assert.Nil(err)
+	_, err = client.CloseSession(ctx, wrapperspb.String(sessionId))
+	_assert.Nil(err)
+
+	stream, err = client.Send(ctx, &pb.ChatReq{Role: pb.Role_USER, Content: "three", SessionId: sessionId})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Equal(codes.NotFound, status.Code(err))
+
+	s, err := client.OpenSession(ctx, wrapperspb.String(sessionId))
+	_assert.Nil(err)
+	_assert.True(s.Persisted)
+	_assert.Equal("kimi", s.Provider)
+
+	stream, err = client.Send(ctx, &pb.ChatReq{Role: pb.Role_USER, Content: "three", SessionId: sessionId})
+	_assert.Nil(err)
+	content, _, _, err = recvAll(stream)
+	_assert.Nil(err)
+	_assert.Equal("third", content)
+
+	requests := server.Requests()
+	var contents []string
+	for _, message := range requests[len(requests)-1].Messages {
+		contents = append(contents, message.Content)
+	}
+	_assert.Subset(contents, []string{"one", "first", "two", "second", "three"})
+
+	_, err = client.OpenSession(ctx, wrapperspb.String("Kimi-1000000000000000000"))
+	_assert.Equal(codes.NotFound, status.Code(err))
+}
+
+func TestGenerationParams(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	stream, err := client.CreateChat(ctx, &pb.ChatReq{
+		Role:    pb.Role_USER,
+		Content: "hi",
+		Model:   "kimi/moonshot-v1-8k",
+		Params: &pb.GenerationParams{
+			Temperature:    proto.Float32(0.5),
+			MaxTokens:      proto.Int32(256),
+			Stop:           []string{"END"},
+			Seed:           proto.Int64(7),
+			ResponseFormat: pb.ResponseFormat_JSON_OBJECT,
+		},
+	})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Nil(err)
+
+	requests := server.Requests()
+	_assert.Equal(1, len(requests))
+	_assert.Equal("moonshot-v1-8k", requests[0].Model)
+	_assert.Equal(float32(0.5), requests[0].Temperature)
+	_assert.Equal(256, requests[0].MaxTokens)
+	_assert.Equal([]string{"END"}, requests[0].Stop)
+	_assert.Equal(7, *requests[0].Seed)
+	_assert.Equal(openai.ChatCompletionResponseFormatTypeJSONObject, requests[0].ResponseFormat.Type)
+
+	for _, req := range []*pb.ChatReq{
+		{Role: pb.Role_USER, Content: "hi", Params: &pb.GenerationParams{Temperature: proto.Float32(1.5)}},
+		{Role: pb.Role_USER, Content: "hi", Params: &pb.GenerationParams{MaxTokens: proto.Int32(100000)}},
+		{Role: pb.Role_USER, Content: "hi", Model: "gpt-4o"},
+	} {
+		stream, err = client.CreateChat(ctx, req)
+		_assert.Nil(err)
+		_, _, _, err = recvAll(stream)
+		_assert.Equal(codes.InvalidArgument, status.Code(err))
+	}
+	_assert.Equal(1, len(server.Requests()))
+}
+
+func TestKnowledgeBase(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+
+	resp, err := client.Ingest(context.Background(), &pb.IngestReq{
+		KnowledgeBase: "ops",
+		Documents: []*pb.Document{
+			{Source: "restart.md", Content: []byte("# Restart\n\nRun systemctl restart open-server to restart the service.")},
+			{Source: "backup.md", Content: []byte("# Backup\n\nBackups are taken nightly by cron.")},
+		},
+	})
+	_assert.Nil(err)
+	_assert.Equal(int32(2), resp.Documents)
+	_assert.Equal(int32(2), resp.Chunks)
+
+	_, err = client.Ingest(context.Background(), &pb.IngestReq{KnowledgeBase: "ops", Documents: []*pb.Document{{Source: "a.bin", Content: []byte{0}}}})
+	_assert.Equal(codes.InvalidArgument, status.Code(err))
+
+	server.Enqueue(llmtest.Tokens("Run systemctl ", "restart [1]."))
+	stream, err := client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER, Content: "how do I restart the service", KnowledgeBases: []string{"ops"}})
+	_assert.Nil(err)
+
+	var last *pb.ChatResp
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			_assert.Equal(io.EOF, err)
+			break
+		}
+		last = resp
+	}
+	_assert.Equal(1, len(last.Citations))
+	_assert.Equal("restart.md", last.Citations[0].Source)
+	_assert.Equal("ops", last.Citations[0].KnowledgeBase)
+	_assert.Equal("Restart", last.Citations[0].Heading)
+
+	requests := server.Requests()
+	messages := requests[len(requests)-1].Messages
+	_assert.Equal(openai.ChatMessageRoleSystem, messages[len(messages)-2].Role)
+	_assert.Contains(messages[len(messages)-2].Content, "restart.md:1-3 (Restart)")
+
+	stream, err = client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER, Content: "hi", KnowledgeBases: []string{"missing"}})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Equal(codes.NotFound, status.Code(err))
+}
+
+func TestListModels(t *testing.T) {
+	_assert := assert.New(t)
+
+	client, server := newTestClient(t)
+
+	resp, err := client.ListModels(context.Background(), &pb.ListModelsReq{})
+	_assert.Nil(err)
+	_assert.Equal(1, len(resp.Models))
+	model := resp.Models[0]
+	_assert.Equal("kimi", model.Provider)
+	_assert.Equal("moonshot-v1-8k", model.Name)
+	_assert.True(model.Default)
+	_assert.Equal(int32(8192), model.ContextWindow)
+	_assert.True(model.Tools)
+	_assert.Equal(pb.ModelStatus_UNKNOWN, model.Status)
+
+	server.SetModels("moonshot-v1-32k")
+	resp, err = client.ListModels(context.Background(), &pb.ListModelsReq{Refresh: true})
+	_assert.Nil(err)
+	_assert.Equal(pb.ModelStatus_UNAVAILABLE, resp.Models[0].Status)
+
+	stream, err := client.CreateChat(context.Background(), &pb.ChatReq{Role: pb.Role_USER, Content: "hi"})
+	_assert.Nil(err)
+	_, _, _, err = recvAll(stream)
+	_assert.Equal(codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Config of the response cache. Its entries are kept apart by the tenant the
+// caller claims, which is not authenticated, so the cache is not isolated
+// between callers.
+type Config struct {
+	Enabled bool `json:"enabled" toml:"enabled" mapstructure:"enabled"`
+	// Threshold is the min cosine similarity of a cached prompt to the
+	// incoming one.
+	Threshold     float32       `json:"threshold" toml:"threshold" mapstructure:"threshold"`
+	TTL           time.Duration `json:"ttl" toml:"ttl" mapstructure:"ttl"`
+	PurgeInterval time.Duration `json:"purge_interval" toml:"purge_interval" mapstructure:"purge_interval"`
+}
+
+func (c *Config) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Config) VerifyConfig() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Threshold <= 0 || c.Threshold > 1 {
+		return fmt.Errorf("cache.threshold has wrong value, threshold: %v", c.Threshold)
+	}
+	if c.TTL <= 0 {
+		return fmt.Errorf("cache.ttl has no value")
+	}
+	if c.PurgeInterval <= 0 {
+		return fmt.Errorf("cache.purge_interval has no value")
+	}
+	return nil
+}
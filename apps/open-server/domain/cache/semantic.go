@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/timeutil"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+)
+
+const (
+	// collection holds the answers of every tenant, the tenant and the scope
+	// are payload fields every lookup filters on.
+	collection = "response_cache"
+
+	// lookupLimit leaves room for expired entries which are not purged yet.
+	lookupLimit = 4
+	// purgeScan is the number of entries a purge checks at a time, it pages
+	// through the whole collection.
+	purgeScan = 10000
+)
+
+// Semantic is a llm.ResponseCache which finds answers by the similarity of
+// the embedded prompts.
+type Semantic struct {
+	store     vectorstore.VectorStore
+	embedder  llm.Embedder
+	threshold float32
+	ttl       time.Duration
+
+	ticker *timeutil.AlignedTicker
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewSemantic(ctx context.Context, store vectorstore.VectorStore, embedder llm.Embedder, c *Config) (*Semantic, error) {
+	if err := c.VerifyConfig(); err != nil {
+		return nil, err
+	}
+
+	ok, err := store.CollectionExists(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := store.CreateCollection(ctx, collection, embedder.Dimension()); err != nil {
+			return nil, fmt.Errorf("create response cache collection failure, nest error: %v", err)
+		}
+	}
+
+	s := &Semantic{
+		store:     store,
+		embedder:  embedder,
+		threshold: c.Threshold,
+		ttl:       c.TTL,
+		ticker:    timeutil.NewAlignedTicker(time.Now(), c.PurgeInterval, 0, 0),
+		done:      make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run()
+	}()
+	return s, nil
+}
+
+func (s *Semantic) embed(ctx context.Context, prompt string) ([]float32, error) {
+	vectors, err := s.embedder.Embeddings(ctx, []string{prompt})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embed prompt failure, expect: 1, actual: %d", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+func (s *Semantic) Get(ctx context.Context, key *llm.CacheKey, prompt string) (string, bool, error) {
+	vec, err := s.embed(ctx, prompt)
+	if err != nil {
+		return "", false, err
+	}
+
+	points, err := s.store.Query(ctx, collection, &vectorstore.Query{
+		Vector:   vec,
+		Limit:    lookupLimit,
+		MinScore: s.threshold,
+		Filter:   vectorstore.Filter{"tenant": key.Tenant, "scope": key.Scope},
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	now := time.Now().Unix()
+	for _, point := range points {
+		if expireAt, _ := point.Payload["expire_at"].(int64); expireAt <= now {
+			continue
+		}
+		answer, _ := point.Payload["answer"].(string)
+		return answer, answer != "", nil
+	}
+	return "", false, nil
+}
+
+func (s *Semantic) Put(ctx context.Context, key *llm.CacheKey, prompt string, answer string) error {
+	vec, err := s.embed(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Upsert(ctx, collection, &vectorstore.Point{
+		Id:     pointId(key, prompt),
+		Vector: vec,
+		Payload: vectorstore.Payload{
+			"tenant":    key.Tenant,
+			"scope":     key.Scope,
+			"prompt":    prompt,
+			"answer":    answer,
+			"expire_at": time.Now().Add(s.ttl).Unix(),
+		},
+	})
+}
+
+// pointId is stable for a prompt under a key, so asking it again refreshes
+// the entry instead of adding one.
+func pointId(key *llm.CacheKey, prompt string) uint64 {
+	h := fnv.New64a()
+	for _, s := range []string{key.Tenant, key.Scope, prompt} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+func (s *Semantic) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-s.ticker.Elapsed():
+			if err := s.purge(context.Background(), now); err != nil {
+				zlog.Error("Purge response cache failure", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *Semantic) purge(ctx context.Context, now time.Time) error {
+	var (
+		offset uint64
+		count  int
+	)
+	for {
+		points, err := s.store.Scroll(ctx, collection, nil, offset, purgeScan)
+		if err != nil {
+			return err
+		}
+
+		var ids []uint64
+		for _, point := range points {
+			if expireAt, _ := point.Payload["expire_at"].(int64); expireAt <= now.Unix() {
+				ids = append(ids, point.Id)
+			}
+		}
+		if len(ids) != 0 {
+			if err := s.store.Delete(ctx, collection, ids...); err != nil {
+				return err
+			}
+			count += len(ids)
+		}
+
+		if len(points) < purgeScan || points[len(points)-1].Id == math.MaxUint64 {
+			break
+		}
+		offset = points[len(points)-1].Id + 1
+	}
+
+	if count != 0 {
+		zlog.Info("Response cache purged", zap.Int("count", count))
+	}
+	return nil
+}
+
+func (s *Semantic) Stop() error {
+	close(s.done)
+	s.wg.Wait()
+	s.ticker.Stop()
+	return nil
+}
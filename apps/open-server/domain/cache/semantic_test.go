@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemantic(t *testing.T) {
+	_assert := assert.New(t)
+	ctx := context.Background()
+
+	store := vectorstore.NewMemory()
+	c, err := NewSemantic(ctx, store, llm.NewLocalEmbedder(0), &Config{Enabled: true, Threshold: 0.8, TTL: time.Hour, PurgeInterval: time.Hour})
+	_assert.Nil(err)
+	defer c.Stop()
+
+	key := &llm.CacheKey{Tenant: "ops", Scope: "a"}
+	_assert.Nil(c.Put(ctx, key, "How do I restart the service?", "systemctl restart open-server"))
+
+	answer, ok, err := c.Get(ctx, key, "how do I restart the service")
+	_assert.Nil(err)
+	_assert.True(ok)
+	_assert.Equal("systemctl restart open-server", answer)
+
+	_, ok, _ = c.Get(ctx, key, "how do I back up the database")
+	_assert.False(ok)
+	_, ok, _ = c.Get(ctx, &llm.CacheKey{Tenant: "dev", Scope: "a"}, "how do I restart the service")
+	_assert.False(ok)
+	_, ok, _ = c.Get(ctx, &llm.CacheKey{Tenant: "ops", Scope: "b"}, "how do I restart the service")
+	_assert.False(ok)
+
+	_assert.Nil(c.purge(ctx, time.Now()))
+	_, ok, _ = c.Get(ctx, key, "how do I restart the service")
+	_assert.True(ok)
+	_assert.Nil(c.purge(ctx, time.Now().Add(2*time.Hour)))
+	points, err := store.Scroll(ctx, collection, nil, 0, 10)
+	_assert.Nil(err)
+	_assert.Empty(points)
+}
+
+func TestSemanticPurge(t *testing.T) {
+	_assert := assert.New(t)
+	ctx := context.Background()
+
+	store := vectorstore.NewMemory()
+	embedder := llm.NewLocalEmbedder(0)
+	c, err := NewSemantic(ctx, store, embedder, &Config{Enabled: true, Threshold: 0.8, TTL: time.Hour, PurgeInterval: time.Hour})
+	_assert.Nil(err)
+	defer c.Stop()
+
+	// the expired entry is after a whole page of live ones
+	now := time.Now()
+	points := make([]*vectorstore.Point, 0, purgeScan+1)
+	for id := uint64(1); id <= purgeScan+1; id++ {
+		expireAt := now.Add(time.Hour).Unix()
+		if id == purgeScan+1 {
+			expireAt = now.Add(-time.Minute).Unix()
+		}
+		points = append(points, &vectorstore.Point{Id: id, Vector: make([]float32, embedder.Dimension()), Payload: vectorstore.Payload{"expire_at": expireAt}})
+	}
+	_assert.Nil(store.Upsert(ctx, collection, points...))
+
+	_assert.Nil(c.purge(ctx, now))
+	left, err := store.Scroll(ctx, collection, nil, 0, 2*purgeScan)
+	_assert.Nil(err)
+	_assert.Equal(purgeScan, len(left))
+	_assert.Equal(uint64(purgeScan), left[len(left)-1].Id)
+}
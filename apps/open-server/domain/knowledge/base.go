@@ -0,0 +1,239 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strconv"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+)
+
+const (
+	MaxDocuments     = 256
+	MaxDocumentBytes = 4 << 20
+
+	embedBatchSize = 32
+
+	// collectionPrefix keeps knowledge collections apart from the memory
+	// collections of sessions, which are named after the session id.
+	collectionPrefix = "kb_"
+)
+
+var (
+	ErrInvalidDocument = errors.New("invalid document")
+	ErrNotFound        = errors.New("knowledge base not found")
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+func collectionOf(name string) string {
+	return collectionPrefix + name
+}
+
+func checkName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w, knowledge base name has wrong value, name: %s", ErrInvalidDocument, name)
+	}
+	return nil
+}
+
+// pointId is stable for a chunk of a source, so ingesting a document again
+// overwrites its chunks.
+func pointId(source string, index int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(source))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(index)))
+	return h.Sum64()
+}
+
+// Base keeps knowledge bases as collections of the vector store, one point per
+// chunk with the source of the chunk in its payload.
+type Base struct {
+	store     vectorstore.VectorStore
+	embedder  llm.Embedder
+	retriever *retrieval.Retriever
+}
+
+// NewBase creates a Base, retriever must search store and store must be
+// retriever.Store(), so that an ingested document is found by keyword.
+func NewBase(store vectorstore.VectorStore, embedder llm.Embedder, retriever *retrieval.Retriever) *Base {
+	return &Base{store: store, embedder: embedder, retriever: retriever}
+}
+
+// Hit is a chunk found by Search, Score is the fused rank score of hybrid
+// retrieval.
+type Hit struct {
+	*Chunk
+	KnowledgeBase string
+	Score         float64
+}
+
+// Ingest splits docs and upserts their chunks into the knowledge base name,
+// which is created on first use. The chunks ingested before for the same
+// sources are replaced. It returns the number of chunks.
+func (b *Base) Ingest(ctx context.Context, name string, splitter *Splitter, docs ...*Document) (int, error) {
+	if err := checkName(name); err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 || len(docs) > MaxDocuments {
+		return 0, fmt.Errorf("%w, documents count has wrong value, count: %d", ErrInvalidDocument, len(docs))
+	}
+
+	chunks := make([][]*Chunk, 0, len(docs))
+	for _, doc := range docs {
+		if len(doc.Content) > MaxDocumentBytes {
+			return 0, fmt.Errorf("%w, document is larger than %d bytes, source: %s", ErrInvalidDocument, MaxDocumentBytes, doc.Source)
+		}
+		data, err := splitter.Split(doc)
+		if err != nil {
+			return 0, err
+		}
+		chunks = append(chunks, data)
+	}
+
+	collection := collectionOf(name)
+	ok, err := b.store.CollectionExists(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if err := b.store.CreateCollection(ctx, collection, b.embedder.Dimension()); err != nil {
+			return 0, fmt.Errorf("create knowledge collection failure, nest error: %v", err)
+		}
+	}
+
+	var total int
+	for i, doc := range docs {
+		if err := b.store.DeleteByFilter(ctx, collection, vectorstore.Filter{"source": doc.Source}); err != nil {
+			return total, fmt.Errorf("delete stale chunks failure, nest error: %v", err)
+		}
+		if err := b.upsert(ctx, collection, chunks[i]); err != nil {
+			return total, err
+		}
+		total += len(chunks[i])
+		zlog.Info("Document ingested", zap.String("knowledgeBase", name), zap.String("source", doc.Source), zap.Int("chunks", len(chunks[i])))
+	}
+	return total, nil
+}
+
+func (b *Base) upsert(ctx context.Context, collection string, chunks []*Chunk) error {
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		batch := chunks[start:min(start+embedBatchSize, len(chunks))]
+
+		input := make([]string, 0, len(batch))
+		for _, chunk := range batch {
+			input = append(input, chunk.embedText())
+		}
+		vectors, err := b.embedder.Embeddings(ctx, input)
+		if err != nil {
+			return fmt.Errorf("embed chunks failure, nest error: %v", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("embed chunks failure, expect: %d, actual: %d", len(batch), len(vectors))
+		}
+
+		points := make([]*vectorstore.Point, 0, len(batch))
+		for i, chunk := range batch {
+			points = append(points, &vectorstore.Point{
+				Id:     pointId(chunk.Source, chunk.Index),
+				Vector: vectors[i],
+				Payload: vectorstore.Payload{
+					"content":    chunk.Text,
+					"source":     chunk.Source,
+					"heading":    chunk.Heading,
+					"start_line": chunk.StartLine,
+					"end_line":   chunk.EndLine,
+					"chunk":      chunk.Index,
+				},
+			})
+		}
+		if err := b.store.Upsert(ctx, collection, points...); err != nil {
+			return fmt.Errorf("upsert chunks failure, nest error: %v", err)
+		}
+	}
+	return nil
+}
+
+// Search looks up query in the knowledge bases names by both meaning and
+// words, and returns at most limit chunks, best first. The top k of the
+// retriever is used when limit is 0, whatever the number of bases.
+func (b *Base) Search(ctx context.Context, names []string, query string, limit int) ([]*Hit, error) {
+	for _, name := range names {
+		if err := checkName(name); err != nil {
+			return nil, err
+		}
+		ok, err := b.store.CollectionExists(ctx, collectionOf(name))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w, name: %s", ErrNotFound, name)
+		}
+	}
+
+	vectors, err := b.embedder.Embeddings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query failure, nest error: %v", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embed query failure, expect: 1, actual: %d", len(vectors))
+	}
+
+	if limit <= 0 {
+		limit = b.retriever.TopK()
+	}
+	var data []*Hit
+	for _, name := range names {
+		hits, err := b.retriever.Search(ctx, &retrieval.Query{Collection: collectionOf(name), Text: query, Vector: vectors[0], TopK: limit})
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range hits {
+			data = append(data, &Hit{Chunk: chunkOf(hit.Payload), KnowledgeBase: name, Score: hit.Score})
+		}
+	}
+	// fused scores are ranks, so the hits of each base interleave
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Score > data[j].Score
+	})
+
+	seen := make(map[string]struct{}, len(data))
+	hits := make([]*Hit, 0, len(data))
+	for _, hit := range data {
+		if len(hits) == limit {
+			break
+		}
+		if _, ok := seen[hit.Text]; ok {
+			continue
+		}
+		seen[hit.Text] = struct{}{}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+func chunkOf(payload vectorstore.Payload) *Chunk {
+	chunk := &Chunk{}
+	chunk.Text, _ = payload["content"].(string)
+	chunk.Source, _ = payload["source"].(string)
+	chunk.Heading, _ = payload["heading"].(string)
+	if n, ok := payload["start_line"].(int64); ok {
+		chunk.StartLine = int(n)
+	}
+	if n, ok := payload["end_line"].(int64); ok {
+		chunk.EndLine = int(n)
+	}
+	if n, ok := payload["chunk"].(int64); ok {
+		chunk.Index = int(n)
+	}
+	return chunk
+}
@@ -0,0 +1,70 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestAndSearch(t *testing.T) {
+	_assert := assert.New(t)
+	ctx := context.Background()
+
+	store := vectorstore.NewMemory()
+	retriever, err := retrieval.NewRetriever(store, nil)
+	_assert.Nil(err)
+	base := NewBase(retriever.Store(), llm.NewLocalEmbedder(0), retriever)
+	splitter, err := NewSplitter(0, 0)
+	_assert.Nil(err)
+
+	n, err := base.Ingest(ctx, "ops", splitter,
+		&Document{Source: "restart.md", Content: []byte("# Restart\n\nRun systemctl restart open-server to restart the service.")},
+		&Document{Source: "backup.md", Content: []byte("# Backup\n\nBackups are taken nightly by cron into the s3 bucket.")},
+	)
+	_assert.Nil(err)
+	_assert.Equal(2, n)
+
+	hits, err := base.Search(ctx, []string{"ops"}, "how do I restart the service", 1)
+	_assert.Nil(err)
+	_assert.Equal(1, len(hits))
+	_assert.Equal("restart.md", hits[0].Source)
+	_assert.Equal("Restart", hits[0].Heading)
+	_assert.Equal(1, hits[0].StartLine)
+	_assert.Equal(3, hits[0].EndLine)
+	_assert.Equal("ops", hits[0].KnowledgeBase)
+
+	// ingesting a source again replaces its chunks
+	n, err = base.Ingest(ctx, "ops", splitter, &Document{Source: "restart.md", Content: []byte("Use the deploy script.")})
+	_assert.Nil(err)
+	_assert.Equal(1, n)
+	hits, err = base.Search(ctx, []string{"ops"}, "restart the service", 10)
+	_assert.Nil(err)
+	_assert.Equal(0, len(hits))
+	hits, err = base.Search(ctx, []string{"ops"}, "deploy script", 10)
+	_assert.Nil(err)
+	_assert.Equal(1, len(hits))
+	_assert.Equal("Use the deploy script.", hits[0].Text)
+
+	// the default limit does not hang on the order of the bases
+	_, err = base.Ingest(ctx, "dev", splitter,
+		&Document{Source: "a.md", Content: []byte("Deploy with the deploy script on staging.")},
+		&Document{Source: "b.md", Content: []byte("The deploy script tags the release.")},
+		&Document{Source: "c.md", Content: []byte("Roll back a deploy with the script of the previous tag.")},
+	)
+	_assert.Nil(err)
+	forward, err := base.Search(ctx, []string{"ops", "dev"}, "deploy script", 0)
+	_assert.Nil(err)
+	backward, err := base.Search(ctx, []string{"dev", "ops"}, "deploy script", 0)
+	_assert.Nil(err)
+	_assert.Equal(4, len(forward))
+	_assert.ElementsMatch(forward, backward)
+
+	_, err = base.Search(ctx, []string{"missing"}, "restart", 1)
+	_assert.ErrorIs(err, ErrNotFound)
+	_, err = base.Ingest(ctx, "bad name", splitter, &Document{Source: "a.txt", Content: []byte("a")})
+	_assert.ErrorIs(err, ErrInvalidDocument)
+}
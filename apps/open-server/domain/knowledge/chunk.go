@@ -0,0 +1,371 @@
+package knowledge
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/textutil"
+)
+
+const (
+	DefaultChunkSize    = 400
+	DefaultChunkOverlap = 50
+	MaxChunkSize        = 2048
+)
+
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindMarkdown
+	KindCode
+	KindLog
+)
+
+var codeExts = map[string]struct{}{
+	".go": {}, ".py": {}, ".js": {}, ".ts": {}, ".tsx": {}, ".jsx": {}, ".java": {}, ".kt": {},
+	".c": {}, ".h": {}, ".cc": {}, ".cpp": {}, ".hpp": {}, ".rs": {}, ".rb": {}, ".php": {},
+	".sh": {}, ".bash": {}, ".sql": {}, ".proto": {}, ".lua": {}, ".swift": {}, ".cs": {},
+	".yaml": {}, ".yml": {}, ".toml": {}, ".json": {}, ".xml": {}, ".html": {}, ".css": {},
+}
+
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+
+// KindOf tells how a document is split, by its mime type first and then by
+// the extension of its source.
+func KindOf(source, mimeType string) Kind {
+	switch {
+	case strings.HasPrefix(mimeType, "text/markdown"):
+		return KindMarkdown
+	case strings.HasPrefix(mimeType, "text/x-log"):
+		return KindLog
+	}
+
+	ext := strings.ToLower(filepath.Ext(source))
+	switch ext {
+	case ".md", ".markdown", ".mdx":
+		return KindMarkdown
+	case ".log", ".out":
+		return KindLog
+	}
+	if _, ok := codeExts[ext]; ok {
+		return KindCode
+	}
+	if strings.Contains(strings.ToLower(filepath.Base(source)), ".log.") {
+		return KindLog
+	}
+	return KindText
+}
+
+type Document struct {
+	Source   string
+	MIMEType string
+	Content  []byte
+}
+
+// Chunk is a piece of a document, lines are numbered from 1 and EndLine is
+// included.
+type Chunk struct {
+	Source    string
+	Heading   string
+	StartLine int
+	EndLine   int
+	Index     int
+	Text      string
+}
+
+// embedText is what gets embedded, the heading path gives the chunk the
+// context of its section.
+func (c *Chunk) embedText() string {
+	if c.Heading == "" {
+		return c.Text
+	}
+	return c.Heading + "\n" + c.Text
+}
+
+type line struct {
+	no     int
+	text   string
+	tokens int
+}
+
+// block is a structural unit of a document: a paragraph, a fenced code block,
+// a declaration or a log record. Blocks are packed into chunks whole when they
+// fit.
+type block struct {
+	heading string
+	lines   []line
+	tokens  int
+}
+
+func (b *block) add(l line) {
+	b.lines = append(b.lines, l)
+	b.tokens += l.tokens
+}
+
+// Splitter chunks documents by structure, up to Size tokens per chunk, and
+// repeats up to Overlap tokens of trailing lines at the head of the next chunk
+// of the same section.
+type Splitter struct {
+	Size    int
+	Overlap int
+}
+
+func NewSplitter(size, overlap int) (*Splitter, error) {
+	if size == 0 {
+		size = DefaultChunkSize
+	}
+	if overlap == 0 {
+		overlap = min(DefaultChunkOverlap, size/4)
+	}
+	if size < 0 || size > MaxChunkSize {
+		return nil, fmt.Errorf("%w, chunk size has wrong value, size: %d", ErrInvalidDocument, size)
+	}
+	if overlap < 0 || overlap >= size {
+		return nil, fmt.Errorf("%w, chunk overlap has wrong value, overlap: %d", ErrInvalidDocument, overlap)
+	}
+	return &Splitter{Size: size, Overlap: overlap}, nil
+}
+
+func (s *Splitter) Split(doc *Document) ([]*Chunk, error) {
+	if doc.Source == "" {
+		return nil, fmt.Errorf("%w, source is nil", ErrInvalidDocument)
+	}
+	if !utf8.Valid(doc.Content) || bytes.IndexByte(doc.Content, 0) != -1 {
+		return nil, fmt.Errorf("%w, content is not utf-8 text, source: %s", ErrInvalidDocument, doc.Source)
+	}
+
+	lines := s.lines(string(doc.Content))
+	var blocks []*block
+	switch KindOf(doc.Source, doc.MIMEType) {
+	case KindMarkdown:
+		blocks = markdownBlocks(lines)
+	case KindLog:
+		blocks = logBlocks(lines)
+	default:
+		blocks = paragraphBlocks(lines)
+	}
+	return s.pack(doc.Source, blocks), nil
+}
+
+// lines numbers the lines of content, a line longer than a chunk is cut into
+// pieces which keep its number.
+func (s *Splitter) lines(content string) []line {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	data := make([]line, 0, strings.Count(content, "\n")+1)
+	for i, text := range strings.Split(content, "\n") {
+		tokens := llm.CountTokens(text)
+		if tokens <= s.Size {
+			data = append(data, line{no: i + 1, text: text, tokens: tokens})
+			continue
+		}
+		for _, piece := range cut(text, s.Size) {
+			data = append(data, line{no: i + 1, text: piece, tokens: llm.CountTokens(piece)})
+		}
+	}
+	return data
+}
+
+// cut splits text into pieces of about size tokens, a CJK rune counts as one
+// token and any other rune as a quarter.
+func cut(text string, size int) []string {
+	var (
+		data  []string
+		start int
+		cost  float64
+	)
+	for i, r := range text {
+		if cost >= float64(size) {
+			data = append(data, text[start:i])
+			start, cost = i, 0
+		}
+		if textutil.IsCJK(r) {
+			cost++
+		} else {
+			cost += 0.25
+		}
+	}
+	return append(data, text[start:])
+}
+
+func markdownBlocks(lines []line) []*block {
+	var (
+		data    []*block
+		cur     *block
+		path    []string
+		heading string
+		fence   string
+	)
+	flush := func() {
+		if cur != nil && len(cur.lines) != 0 {
+			data = append(data, cur)
+		}
+		cur = nil
+	}
+
+	for _, l := range lines {
+		trimmed := strings.TrimSpace(l.text)
+		if fence != "" {
+			cur.add(l)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				flush()
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fence = trimmed[:3]
+			cur = &block{heading: heading}
+			cur.add(l)
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			level := len(m[1])
+			if level-1 < len(path) {
+				path = path[:level-1]
+			}
+			path = append(path, m[2])
+			heading = strings.Join(path, " > ")
+			cur = &block{heading: heading}
+			cur.add(l)
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		if cur == nil {
+			cur = &block{heading: heading}
+		}
+		cur.add(l)
+	}
+	flush()
+	return data
+}
+
+// paragraphBlocks splits text and code at blank lines, which in code mostly
+// fall between declarations.
+func paragraphBlocks(lines []line) []*block {
+	var (
+		data []*block
+		cur  *block
+	)
+	for _, l := range lines {
+		if strings.TrimSpace(l.text) == "" {
+			if cur != nil {
+				data = append(data, cur)
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil {
+			cur = &block{}
+		}
+		cur.add(l)
+	}
+	if cur != nil {
+		data = append(data, cur)
+	}
+	return data
+}
+
+// logBlocks makes a block of every record, indented lines such as stack traces
+// belong to the record above them.
+func logBlocks(lines []line) []*block {
+	var data []*block
+	for _, l := range lines {
+		if strings.TrimSpace(l.text) == "" {
+			continue
+		}
+		continued := strings.HasPrefix(l.text, " ") || strings.HasPrefix(l.text, "\t") || strings.HasPrefix(l.text, "Caused by")
+		if continued && len(data) != 0 {
+			data[len(data)-1].add(l)
+			continue
+		}
+		b := &block{}
+		b.add(l)
+		data = append(data, b)
+	}
+	return data
+}
+
+func (s *Splitter) pack(source string, blocks []*block) []*Chunk {
+	var (
+		data    []*Chunk
+		cur     []line
+		tokens  int
+		fresh   int
+		heading string
+	)
+	emit := func() {
+		if fresh != 0 {
+			data = append(data, newChunk(source, heading, len(data), cur))
+		}
+		fresh = 0
+	}
+
+	for _, b := range blocks {
+		if b.heading != heading {
+			emit()
+			cur, tokens = nil, 0
+			heading = b.heading
+		}
+		if tokens+b.tokens > s.Size && fresh != 0 {
+			emit()
+			cur, tokens = s.overlap(cur)
+		}
+		for _, l := range b.lines {
+			if tokens+l.tokens > s.Size && fresh != 0 {
+				emit()
+				cur, tokens = s.overlap(cur)
+			}
+			cur = append(cur, l)
+			tokens += l.tokens
+			fresh++
+		}
+	}
+	emit()
+	return data
+}
+
+func (s *Splitter) overlap(lines []line) ([]line, int) {
+	var tokens int
+	i := len(lines)
+	for i > 0 && tokens+lines[i-1].tokens <= s.Overlap {
+		i--
+		tokens += lines[i].tokens
+	}
+	return append([]line(nil), lines[i:]...), tokens
+}
+
+// newChunk joins lines back into text, a gap in the line numbers was a blank
+// line.
+func newChunk(source, heading string, index int, lines []line) *Chunk {
+	var buf strings.Builder
+	for i, l := range lines {
+		if i != 0 {
+			buf.WriteString("\n")
+			if l.no > lines[i-1].no+1 {
+				buf.WriteString("\n")
+			}
+		}
+		buf.WriteString(l.text)
+	}
+	return &Chunk{
+		Source:    source,
+		Heading:   heading,
+		StartLine: lines[0].no,
+		EndLine:   lines[len(lines)-1].no,
+		Index:     index,
+		Text:      buf.String(),
+	}
+}
@@ -0,0 +1,94 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	_assert := assert.New(t)
+
+	_assert.Equal(KindMarkdown, KindOf("docs/README.md", ""))
+	_assert.Equal(KindMarkdown, KindOf("notes", "text/markdown; charset=utf-8"))
+	_assert.Equal(KindCode, KindOf("main.go", ""))
+	_assert.Equal(KindLog, KindOf("/var/log/app.log", ""))
+	_assert.Equal(KindLog, KindOf("app.log.1", ""))
+	_assert.Equal(KindText, KindOf("notes.txt", ""))
+}
+
+func TestSplitMarkdown(t *testing.T) {
+	_assert := assert.New(t)
+
+	content := strings.Join([]string{
+		"# 部署",
+		"",
+		"服务部署在 k8s 上。",
+		"",
+		"## 重启",
+		"",
+		"```sh",
+		"kubectl rollout restart deploy/open-server",
+		"",
+		"kubectl rollout status deploy/open-server",
+		"```",
+		"",
+		"# FAQ",
+		"",
+		"Ask in the channel.",
+	}, "\n")
+
+	splitter, err := NewSplitter(0, 0)
+	_assert.Nil(err)
+	chunks, err := splitter.Split(&Document{Source: "ops.md", Content: []byte(content)})
+	_assert.Nil(err)
+	_assert.Equal(3, len(chunks))
+
+	_assert.Equal("部署", chunks[0].Heading)
+	_assert.Equal(1, chunks[0].StartLine)
+	_assert.Equal(3, chunks[0].EndLine)
+	_assert.Equal("# 部署\n\n服务部署在 k8s 上。", chunks[0].Text)
+
+	_assert.Equal("部署 > 重启", chunks[1].Heading)
+	_assert.Equal(5, chunks[1].StartLine)
+	_assert.Equal(11, chunks[1].EndLine)
+	_assert.Contains(chunks[1].Text, "kubectl rollout restart deploy/open-server\n\nkubectl rollout status")
+
+	_assert.Equal("FAQ", chunks[2].Heading)
+	_assert.Equal(2, chunks[2].Index)
+
+	_, err = splitter.Split(&Document{Source: "a.bin", Content: []byte{0x00, 0x01}})
+	_assert.ErrorIs(err, ErrInvalidDocument)
+}
+
+func TestSplitOverlap(t *testing.T) {
+	_assert := assert.New(t)
+
+	lines := make([]string, 0, 40)
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "2024-05-01 10:00:00 INFO request handled ok")
+	}
+	lines[10] = "2024-05-01 10:00:00 ERROR panic: nil map"
+	lines = append(lines[:11], append([]string{"\tat main.go:12"}, lines[11:]...)...)
+
+	splitter, err := NewSplitter(60, 20)
+	_assert.Nil(err)
+	chunks, err := splitter.Split(&Document{Source: "app.log", Content: []byte(strings.Join(lines, "\n"))})
+	_assert.Nil(err)
+	_assert.Greater(len(chunks), 1)
+
+	for i := 1; i < len(chunks); i++ {
+		_assert.Less(chunks[i].StartLine, chunks[i-1].EndLine+1)
+		_assert.Greater(chunks[i].EndLine, chunks[i-1].EndLine)
+	}
+	for _, chunk := range chunks {
+		if strings.Contains(chunk.Text, "panic") {
+			_assert.Contains(chunk.Text, "panic: nil map\n\tat main.go:12")
+		}
+	}
+	_assert.Equal(41, chunks[len(chunks)-1].EndLine)
+
+	_, err = NewSplitter(100, 100)
+	_assert.ErrorIs(err, ErrInvalidDocument)
+}
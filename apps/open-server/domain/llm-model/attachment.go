@@ -0,0 +1,233 @@
+package llm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	MaxAttachments = 8
+	// MaxImageBytes bounds an image sent inline, larger ones should be sent
+	// by url.
+	MaxImageBytes = 5 << 20
+	// MaxInlineTextBytes bounds the text of a file inlined into the prompt,
+	// the rest is cut off with a notice.
+	MaxInlineTextBytes = 64 << 10
+
+	// imageTokens is a rough cost of an image in the prompt budget.
+	imageTokens = 1024
+)
+
+var imageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Attachment is a file sent along with a message, either Data or URL is set,
+// an URL is only accepted for images, which are told by the declared type or
+// the extension of the name or the url.
+type Attachment struct {
+	Name     string
+	MIMEType string
+	Data     []byte
+	URL      string
+}
+
+// mimeType returns the declared type, or guesses it from the name and data.
+func (a *Attachment) mimeType() string {
+	if a.MIMEType != "" {
+		t, _, err := mime.ParseMediaType(a.MIMEType)
+		if err == nil {
+			return t
+		}
+		return strings.ToLower(a.MIMEType)
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Name)); t != "" {
+		t, _, _ = mime.ParseMediaType(t)
+		return t
+	}
+	if u, err := url.Parse(a.URL); err == nil && a.URL != "" {
+		if t := mime.TypeByExtension(path.Ext(u.Path)); t != "" {
+			t, _, _ = mime.ParseMediaType(t)
+			return t
+		}
+	}
+	if len(a.Data) != 0 {
+		t, _, _ := mime.ParseMediaType(http.DetectContentType(a.Data))
+		return t
+	}
+	return ""
+}
+
+func (a *Attachment) IsImage() bool {
+	t := a.mimeType()
+	for _, it := range imageTypes {
+		if t == it {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Attachment) isText() bool {
+	t := a.mimeType()
+	switch {
+	case strings.HasPrefix(t, "text/"):
+		return true
+	case t == "application/json", t == "application/xml", t == "application/yaml", t == "application/x-yaml",
+		t == "application/toml", t == "application/x-sh", t == "application/javascript":
+		return true
+	case t == "" || t == "application/octet-stream":
+		return len(a.Data) != 0 && utf8.Valid(a.Data)
+	}
+	return false
+}
+
+// CheckAttachments rejects what can not be sent to the model, the error wraps
+// ErrInvalidParams.
+func CheckAttachments(attachments ...*Attachment) error {
+	if len(attachments) > MaxAttachments {
+		return fmt.Errorf("%w, attachments allow %d files at most", ErrInvalidParams, MaxAttachments)
+	}
+
+	for _, a := range attachments {
+		switch {
+		case a.URL != "" && len(a.Data) != 0:
+			return fmt.Errorf("%w, attachment[%s] has both data and url", ErrInvalidParams, a.Name)
+		case a.URL != "":
+			if !strings.HasPrefix(a.URL, "https://") && !strings.HasPrefix(a.URL, "http://") {
+				return fmt.Errorf("%w, attachment[%s] has wrong url, url: %s", ErrInvalidParams, a.Name, a.URL)
+			}
+			if !a.IsImage() {
+				return fmt.Errorf("%w, attachment[%s] is sent by url but is not an image, mime_type: %s", ErrInvalidParams, a.Name, a.mimeType())
+			}
+		case len(a.Data) == 0:
+			return fmt.Errorf("%w, attachment[%s] is empty", ErrInvalidParams, a.Name)
+		case a.IsImage():
+			if len(a.Data) > MaxImageBytes {
+				return fmt.Errorf("%w, image[%s] exceeds %d bytes, size: %d", ErrInvalidParams, a.Name, MaxImageBytes, len(a.Data))
+			}
+		case !a.isText():
+			return fmt.Errorf("%w, attachment[%s] has unsupported type, mime_type: %s", ErrInvalidParams, a.Name, a.mimeType())
+		}
+	}
+	return nil
+}
+
+// WithChatCompletionRequestForAttachments attaches files to the message being
+// sent. Text files are inlined into its content, images turn it into a multi
+// content message, which only vision models accept. The attachments must
+// have passed CheckAttachments.
+func WithChatCompletionRequestForAttachments(attachments ...*Attachment) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		if len(ccr.Messages) == 0 || len(attachments) == 0 {
+			return
+		}
+		message := &ccr.Messages[len(ccr.Messages)-1]
+
+		var (
+			text   strings.Builder
+			images []openai.ChatMessagePart
+		)
+		text.WriteString(message.Content)
+		for _, a := range attachments {
+			// an url is only accepted for an image
+			if a.IsImage() {
+				images = append(images, imagePart(a))
+				continue
+			}
+			inlineText(&text, a)
+		}
+
+		message.Content = text.String()
+		if len(images) != 0 {
+			message.MultiContent = append([]openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: message.Content}}, images...)
+			message.Content = ""
+		}
+	}
+}
+
+func imagePart(a *Attachment) openai.ChatMessagePart {
+	imageURL := a.URL
+	if imageURL == "" {
+		imageURL = fmt.Sprintf("data:%s;base64,%s", a.mimeType(), base64.StdEncoding.EncodeToString(a.Data))
+	}
+	return openai.ChatMessagePart{
+		Type:     openai.ChatMessagePartTypeImageURL,
+		ImageURL: &openai.ChatMessageImageURL{URL: imageURL, Detail: openai.ImageURLDetailAuto},
+	}
+}
+
+func inlineText(buf *strings.Builder, a *Attachment) {
+	data := a.Data
+	var omitted int
+	if len(data) > MaxInlineTextBytes {
+		cut := MaxInlineTextBytes
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		omitted = len(data) - cut
+		data = data[:cut]
+	}
+
+	fence := fenceOf(data)
+	fmt.Fprintf(buf, "\n\nFile: %s\n%s\n%s\n%s", a.Name, fence, data, fence)
+	if omitted != 0 {
+		fmt.Fprintf(buf, "\n[truncated, %d bytes of %s omitted]", omitted, a.Name)
+	}
+}
+
+// fenceOf returns a code fence longer than the longest run of backticks in
+// data, so that the data cannot close it, as CommonMark does.
+func fenceOf(data []byte) string {
+	longest, run := 2, 0
+	for _, b := range data {
+		if b != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+func hasImage(message openai.ChatCompletionMessage) bool {
+	for _, part := range message.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeImageURL {
+			return true
+		}
+	}
+	return false
+}
+
+// turnOf keeps the text of message for the history, images are not replayed
+// in later turns.
+func turnOf(message openai.ChatCompletionMessage) Turn {
+	if len(message.MultiContent) == 0 {
+		return Turn{Role: message.Role, Content: message.Content}
+	}
+
+	var (
+		texts  []string
+		images int
+	)
+	for _, part := range message.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			texts = append(texts, part.Text)
+		case openai.ChatMessagePartTypeImageURL:
+			images++
+		}
+	}
+	if images != 0 {
+		texts = append(texts, fmt.Sprintf("[%d image(s) attached]", images))
+	}
+	return Turn{Role: message.Role, Content: strings.Join(texts, "\n")}
+}
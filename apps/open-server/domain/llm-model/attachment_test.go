@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestCheckAttachments(t *testing.T) {
+	_assert := assert.New(t)
+
+	_assert.Nil(CheckAttachments(
+		&Attachment{Name: "app.log", Data: []byte("error: boom")},
+		&Attachment{Name: "shot.png", Data: pngHeader},
+		&Attachment{Name: "remote", URL: "https://example.com/a.png"},
+	))
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "empty.txt"}), ErrInvalidParams)
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "a.bin", Data: []byte{0xff, 0xfe, 0x00}}), ErrInvalidParams)
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "big.png", Data: append(pngHeader, make([]byte, MaxImageBytes)...)}), ErrInvalidParams)
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "a", URL: "file:///etc/passwd"}), ErrInvalidParams)
+	// only images are sent by url
+	_assert.Nil(CheckAttachments(&Attachment{Name: "remote", MIMEType: "image/jpeg", URL: "https://example.com/photo"}))
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "notes", MIMEType: "text/plain", URL: "https://example.com/notes.txt"}), ErrInvalidParams)
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "paper.pdf", URL: "https://example.com/paper.pdf"}), ErrInvalidParams)
+	_assert.ErrorIs(CheckAttachments(&Attachment{Name: "remote", URL: "https://example.com/download?id=1"}), ErrInvalidParams)
+}
+
+func TestAttachments(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+
+	client := NewOpenAIClient(&ProviderConfig{Name: "openai", Kind: KindOpenAI, BaseURL: server.BaseURL(), APIKey: "mock", Models: []string{"gpt-4o", "gpt-3.5-turbo"}})
+	session, err := client.NewSession("gpt-4o")
+	_assert.Nil(err)
+
+	large := strings.Repeat("x", MaxInlineTextBytes+10)
+	stream, err := session.StartChat(context.Background(), openai.ChatMessageRoleUser, "what is wrong?",
+		WithChatCompletionRequestForAttachments(&Attachment{Name: "app.log", Data: []byte(large)}, &Attachment{Name: "shot.png", Data: pngHeader}),
+	)
+	_assert.Nil(err)
+	for range stream.Events() {
+	}
+	_assert.Nil(stream.Err())
+
+	message := server.Requests()[0].Messages[0]
+	_assert.Equal(2, len(message.MultiContent))
+	_assert.Contains(message.MultiContent[0].Text, "File: app.log")
+	_assert.Contains(message.MultiContent[0].Text, "[truncated, 10 bytes of app.log omitted]")
+	_assert.True(strings.HasPrefix(message.MultiContent[1].ImageURL.URL, "data:image/png;base64,"))
+	_assert.Equal("https://example.com/a.png", imagePart(&Attachment{URL: "https://example.com/a.png"}).ImageURL.URL)
+
+	_, err = session.Send(context.Background(), openai.ChatMessageRoleUser, "and this?",
+		WithChatCompletionRequestForModel("gpt-3.5-turbo"),
+		WithChatCompletionRequestForAttachments(&Attachment{Name: "shot.png", Data: pngHeader}),
+	)
+	_assert.ErrorIs(err, ErrInvalidParams)
+
+	stream, err = session.Send(context.Background(), openai.ChatMessageRoleUser, "and this?",
+		WithChatCompletionRequestForModel("gpt-3.5-turbo"),
+		WithChatCompletionRequestForAttachments(&Attachment{Name: "main.go", Data: []byte("package main")}),
+	)
+	_assert.Nil(err)
+	for range stream.Events() {
+	}
+	messages := server.Requests()[1].Messages
+	_assert.Contains(messages[0].Content, "[1 image(s) attached]")
+	_assert.Equal("and this?\n\nFile: main.go\n```\npackage main\n```", messages[len(messages)-1].Content)
+
+	// a file with fences of its own is wrapped in a longer one
+	var buf strings.Builder
+	inlineText(&buf, &Attachment{Name: "README.md", Data: []byte("run:\n```\nmake\n````\n")})
+	_assert.Equal("\n\nFile: README.md\n`````\nrun:\n```\nmake\n````\n\n`````", buf.String())
+}
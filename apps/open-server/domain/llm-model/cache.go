@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultTenant = "default"
+
+	// replayChunkRunes is the size of the deltas a cached answer is replayed in.
+	replayChunkRunes  = 16
+	cacheStoreTimeout = 30 * time.Second
+)
+
+// CacheKey scopes a cached answer, answers are only shared by the prompts of
+// the same tenant under the same model, system prompt and parameters. The
+// tenant only separates callers which do not claim the tenant of another, it
+// is not an authenticated identity.
+type CacheKey struct {
+	Tenant string
+	Scope  string
+}
+
+// ResponseCache answers prompts which are close to a prompt answered before.
+type ResponseCache interface {
+	Get(ctx context.Context, key *CacheKey, prompt string) (string, bool, error)
+	Put(ctx context.Context, key *CacheKey, prompt string, answer string) error
+}
+
+type tenantKey struct{}
+
+type bypassCacheKey struct{}
+
+// WithTenant tags the chats under ctx with tenant, cached answers are never
+// shared across tenants. The tenant is taken as given, it must be checked by
+// the caller to keep the answers of a tenant from another.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func TenantFrom(ctx context.Context) string {
+	if tenant, _ := ctx.Value(tenantKey{}).(string); tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+// WithoutResponseCache makes the chats under ctx neither read nor fill the
+// response cache.
+func WithoutResponseCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// cacheScope is everything besides the prompt which shapes the answer.
+type cacheScope struct {
+	Model            string                               `json:"model"`
+	System           []Turn                               `json:"system"`
+	MaxTokens        int                                  `json:"max_tokens"`
+	Temperature      float32                              `json:"temperature"`
+	TopP             float32                              `json:"top_p"`
+	Stop             []string                             `json:"stop"`
+	PresencePenalty  float32                              `json:"presence_penalty"`
+	FrequencyPenalty float32                              `json:"frequency_penalty"`
+	Seed             *int                                 `json:"seed"`
+	ResponseFormat   *openai.ChatCompletionResponseFormat `json:"response_format"`
+}
+
+// cacheKey returns the key of the answer to next, or nil when the answer can
+// not be cached: only the plain text question which opens a conversation is,
+// since later answers depend on the turns before them.
+func (s *ChatSession) cacheKey(ctx context.Context, req *openai.ChatCompletionRequest, next openai.ChatCompletionMessage) *CacheKey {
+	if s.responseCache == nil || cacheBypassed(ctx) {
+		return nil
+	}
+	if next.Role != openai.ChatMessageRoleUser || len(next.MultiContent) != 0 || next.Content == "" {
+		return nil
+	}
+	if len(req.Messages) != 0 || req.N > 1 || schemaOf(req) != nil {
+		return nil
+	}
+	system, ok := s.history.opening()
+	if !ok {
+		return nil
+	}
+
+	buf, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(&cacheScope{
+		Model:            req.Model,
+		System:           system,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		Stop:             req.Stop,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Seed:             req.Seed,
+		ResponseFormat:   req.ResponseFormat,
+	})
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(buf)
+	return &CacheKey{Tenant: TenantFrom(ctx), Scope: hex.EncodeToString(sum[:])}
+}
+
+// hit looks up the answer of prompt, key is nil when the answer is not
+// cacheable.
+func (s *ChatSession) hit(ctx context.Context, key *CacheKey, prompt string) (string, bool) {
+	if key == nil {
+		return "", false
+	}
+	answer, ok, err := s.responseCache.Get(ctx, key, prompt)
+	if err != nil {
+		zlog.Error("Lookup response cache failure", zap.Error(err), zap.String("sessionId", s.Id))
+		return "", false
+	}
+	return answer, ok
+}
+
+// fill caches a complete answer, it runs after the stream is finished so it
+// must not use the context of the stream.
+func (s *ChatSession) fill(ctx context.Context, key *CacheKey, prompt string, answer string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheStoreTimeout)
+	defer cancel()
+
+	if err := s.responseCache.Put(ctx, key, prompt, answer); err != nil {
+		zlog.Error("Store response cache failure", zap.Error(err), zap.String("sessionId", s.Id))
+	}
+}
+
+// replay streams a cached answer as if the model wrote it.
+func (s *ChatSession) replay(ctx context.Context, next Turn, answer string) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+	stream := newStream(cancel)
+	stream.cached = true
+
+	go func() {
+		var err error
+		for rest := answer; rest != "" && err == nil; {
+			n := 0
+			for i := 0; i < replayChunkRunes && n < len(rest); i++ {
+				_, size := utf8.DecodeRuneInString(rest[n:])
+				n += size
+			}
+			err = stream.send(ctx, &Event{Content: rest[:n]})
+			rest = rest[n:]
+		}
+
+		if err != nil {
+			s.history.add(next)
+		} else {
+			s.history.add(next, Turn{Role: openai.ChatMessageRoleAssistant, Content: answer})
+			stream.finishReason = openai.FinishReasonStop
+		}
+		stream.finish(err)
+	}()
+	return stream
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapCache struct {
+	sync.Mutex
+	answers map[string]string
+}
+
+func (c *mapCache) Get(ctx context.Context, key *CacheKey, prompt string) (string, bool, error) {
+	c.Lock()
+	defer c.Unlock()
+	answer, ok := c.answers[key.Tenant+key.Scope+strings.ToLower(prompt)]
+	return answer, ok, nil
+}
+
+func (c *mapCache) Put(ctx context.Context, key *CacheKey, prompt string, answer string) error {
+	c.Lock()
+	defer c.Unlock()
+	c.answers[key.Tenant+key.Scope+strings.ToLower(prompt)] = answer
+	return nil
+}
+
+func (c *mapCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.answers)
+}
+
+func TestResponseCache(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	client := NewKimiClient(server.BaseURL(), "mock")
+	cache := &mapCache{answers: map[string]string{}}
+
+	chat := func(ctx context.Context, prompt string, opts ...func(*openai.ChatCompletionRequest)) (string, *Stream) {
+		session, err := client.NewSession(DefaultKimiModel, WithSessionForResponseCache(cache))
+		_assert.Nil(err)
+		stream, err := session.StartChat(ctx, openai.ChatMessageRoleUser, prompt, opts...)
+		_assert.Nil(err)
+
+		var buf strings.Builder
+		for event := range stream.Events() {
+			buf.WriteString(event.Content)
+		}
+		_assert.Nil(stream.Err())
+		return buf.String(), stream
+	}
+
+	server.Enqueue(llmtest.Tokens("systemctl ", "restart ", "open-server"))
+	answer, stream := chat(context.Background(), "How do I restart the service?")
+	_assert.Equal("systemctl restart open-server", answer)
+	_assert.False(stream.Cached())
+	_assert.Equal(3, stream.Usage().CompletionTokens)
+	_assert.Eventually(func() bool { return cache.len() == 1 }, time.Second, 10*time.Millisecond)
+
+	answer, stream = chat(context.Background(), "how do I restart the service?")
+	_assert.Equal("systemctl restart open-server", answer)
+	_assert.True(stream.Cached())
+	_assert.Equal(openai.FinishReasonStop, stream.FinishReason())
+	_assert.Equal(openai.Usage{}, stream.Usage())
+	_assert.Equal(1, len(server.Requests()))
+
+	// other tenants, params and the bypass flag all miss
+	server.Enqueue(llmtest.Tokens("a"), llmtest.Tokens("b"), llmtest.Tokens("c"))
+	_, stream = chat(WithTenant(context.Background(), "dev"), "how do I restart the service?")
+	_assert.False(stream.Cached())
+	_, stream = chat(context.Background(), "how do I restart the service?", WithChatCompletionRequestForTemperature(0.1))
+	_assert.False(stream.Cached())
+	_, stream = chat(WithoutResponseCache(context.Background()), "how do I restart the service?")
+	_assert.False(stream.Cached())
+	_assert.Equal(4, len(server.Requests()))
+	_assert.Eventually(func() bool { return cache.len() == 3 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,246 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// DefaultUpstreamTTL is how long the models listed by a provider are trusted.
+const DefaultUpstreamTTL = 10 * time.Minute
+
+type ModelKind string
+
+const (
+	ModelKindChat      ModelKind = "chat"
+	ModelKindEmbedding ModelKind = "embedding"
+)
+
+// ModelStatus tells what the upstream /models endpoint says about a model.
+type ModelStatus int
+
+const (
+	// ModelStatusUnknown is a model of a provider which has not been listed
+	// or can not list its models.
+	ModelStatusUnknown ModelStatus = iota
+	ModelStatusAvailable
+	// ModelStatusUnavailable is a configured model the provider does not list.
+	ModelStatusUnavailable
+)
+
+func (s ModelStatus) String() string {
+	switch s {
+	case ModelStatusAvailable:
+		return "available"
+	case ModelStatusUnavailable:
+		return "unavailable"
+	default:
+		return "unknown"
+	}
+}
+
+// ModelInfo is what a client needs to know to pick a model, the prices are
+// per million tokens.
+type ModelInfo struct {
+	Provider string
+	Name     string
+	Kind     ModelKind
+	Default  bool
+
+	ContextWindow      int
+	MaxOutputTokens    int
+	Vision             bool
+	Tools              bool
+	JSONMode           bool
+	JSONSchema         bool
+	EmbeddingDimension int
+
+	InputPrice  float64
+	OutputPrice float64
+	Currency    string
+
+	Status  ModelStatus
+	OwnedBy string
+}
+
+// Catalog merges the models in config with what the providers list upstream.
+// The upstream lists are cached for ttl, failures included, so that a provider
+// without /models is not asked on every call.
+type Catalog struct {
+	providers *Providers
+	embedding *ModelInfo
+	ttl       time.Duration
+
+	mu       sync.Mutex
+	upstream map[string]*upstreamModels
+}
+
+type upstreamModels struct {
+	models    map[string]openai.Model
+	err       error
+	fetchedAt time.Time
+}
+
+func NewCatalog(providers *Providers, opts ...func(*Catalog)) *Catalog {
+	c := &Catalog{
+		providers: providers,
+		ttl:       DefaultUpstreamTTL,
+		upstream:  make(map[string]*upstreamModels),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithCatalogForEmbedding lists the embedding model in use along with the chat
+// models.
+func WithCatalogForEmbedding(name string, dimension int) func(*Catalog) {
+	return func(c *Catalog) {
+		c.embedding = &ModelInfo{
+			Name:               name,
+			Kind:               ModelKindEmbedding,
+			EmbeddingDimension: dimension,
+		}
+	}
+}
+
+func WithCatalogForTTL(ttl time.Duration) func(*Catalog) {
+	return func(c *Catalog) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// List returns every configured model in provider order. Upstream lists older
+// than ttl are fetched again, all of them when refresh is set.
+func (c *Catalog) List(ctx context.Context, refresh bool) []*ModelInfo {
+	c.fetch(ctx, refresh)
+
+	defaultProvider, defaultModel := c.providers.Default()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var data []*ModelInfo
+	for _, provider := range c.providers.List() {
+		upstream := c.upstream[provider.Name()]
+		for _, name := range provider.Models() {
+			info := newModelInfo(provider, name)
+			info.Default = provider == defaultProvider && name == defaultModel
+			if upstream != nil && upstream.err == nil {
+				if model, ok := lookupModel(upstream.models, name); ok {
+					info.Status = ModelStatusAvailable
+					info.OwnedBy = model.OwnedBy
+				} else {
+					info.Status = ModelStatusUnavailable
+				}
+			}
+			data = append(data, info)
+		}
+	}
+	if c.embedding != nil {
+		info := *c.embedding
+		data = append(data, &info)
+	}
+	return data
+}
+
+// Refresh fetches the upstream lists of every provider.
+func (c *Catalog) Refresh(ctx context.Context) {
+	c.fetch(ctx, true)
+}
+
+// Available reports whether a chat request for the model may go on. It only
+// looks at the cached upstream lists and says no only when the provider did
+// list its models and the model is not among them.
+func (c *Catalog) Available(providerName, modelName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	upstream, ok := c.upstream[providerName]
+	if !ok || upstream.err != nil {
+		return true
+	}
+	_, ok = lookupModel(upstream.models, modelName)
+	return ok
+}
+
+func (c *Catalog) fetch(ctx context.Context, refresh bool) {
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for _, provider := range c.providers.List() {
+		c.mu.Lock()
+		upstream, ok := c.upstream[provider.Name()]
+		c.mu.Unlock()
+		if ok && !refresh && now.Sub(upstream.fetchedAt) < c.ttl {
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider Provider) {
+			defer wg.Done()
+
+			upstream := &upstreamModels{fetchedAt: time.Now()}
+			models, err := provider.UpstreamModels(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				zlog.Warn("List upstream models failure", zap.Error(err), zap.String("provider", provider.Name()))
+				upstream.err = err
+			} else {
+				upstream.models = make(map[string]openai.Model, len(models))
+				for _, model := range models {
+					upstream.models[model.ID] = model
+				}
+			}
+
+			c.mu.Lock()
+			c.upstream[provider.Name()] = upstream
+			c.mu.Unlock()
+		}(provider)
+	}
+	wg.Wait()
+}
+
+// lookupModel finds the model by name, ollama lists a model pulled without a
+// tag as <name>:latest.
+func lookupModel(models map[string]openai.Model, name string) (openai.Model, bool) {
+	if model, ok := models[name]; ok {
+		return model, true
+	}
+	if !strings.Contains(name, ":") {
+		model, ok := models[name+":latest"]
+		return model, ok
+	}
+	return openai.Model{}, false
+}
+
+func newModelInfo(provider Provider, name string) *ModelInfo {
+	limits := limitsFor(provider, name)
+	info := &ModelInfo{
+		Provider:        provider.Name(),
+		Name:            name,
+		Kind:            ModelKindChat,
+		ContextWindow:   limits.ContextWindow,
+		MaxOutputTokens: limits.MaxOutputTokens,
+		Vision:          limits.Vision,
+		Tools:           limits.Tools,
+		JSONMode:        limits.JSONMode,
+		JSONSchema:      limits.JSONSchema,
+	}
+	if spec, ok := provider.Spec(name); ok {
+		info.InputPrice = spec.InputPrice
+		info.OutputPrice = spec.OutputPrice
+		info.Currency = spec.Currency
+	}
+	return info
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	_assert := assert.New(t)
+
+	openaiServer := llmtest.NewServer()
+	defer openaiServer.Close()
+	openaiServer.SetModels("gpt-4o", "llama3:latest")
+	ollamaServer := llmtest.NewServer()
+	defer ollamaServer.Close()
+
+	vision := false
+	providers, err := NewProviders(&Config{
+		DefaultProvider: "openai",
+		DefaultModel:    "gpt-4o",
+		Providers: []*ProviderConfig{
+			{Name: "openai", Kind: KindOpenAI, BaseURL: openaiServer.BaseURL(), APIKey: "key", Models: []string{"gpt-4o", "gpt-4o-mini", "llama3"}, Specs: []*ModelSpec{
+				{Name: "gpt-4o", ContextWindow: 64000, Vision: &vision, InputPrice: 2.5, OutputPrice: 10, Currency: "USD"},
+			}},
+			{Name: "ollama", Kind: KindOllama, BaseURL: ollamaServer.BaseURL(), Models: []string{"qwen2.5:7b"}},
+		},
+	})
+	_assert.Nil(err)
+
+	catalog := NewCatalog(providers, WithCatalogForEmbedding("text-embedding-3-small", 1536))
+	_assert.True(catalog.Available("openai", "gpt-4o-mini"))
+
+	models := catalog.List(context.Background(), false)
+	_assert.Len(models, 5)
+
+	gpt := models[1]
+	_assert.Equal("openai", gpt.Provider)
+	_assert.Equal("gpt-4o", gpt.Name)
+	_assert.True(gpt.Default)
+	_assert.Equal(64000, gpt.ContextWindow)
+	_assert.Equal(16384, gpt.MaxOutputTokens)
+	_assert.False(gpt.Vision)
+	_assert.True(gpt.Tools)
+	_assert.True(gpt.JSONSchema)
+	_assert.Equal(10.0, gpt.OutputPrice)
+	_assert.Equal(ModelStatusAvailable, gpt.Status)
+	_assert.Equal("llmtest", gpt.OwnedBy)
+
+	_assert.Equal(ModelStatusUnavailable, models[2].Status)
+	_assert.Equal(ModelStatusAvailable, models[3].Status)
+	_assert.Equal(ModelStatusUnknown, models[0].Status)
+	_assert.Equal(ModelKindEmbedding, models[4].Kind)
+	_assert.Equal(1536, models[4].EmbeddingDimension)
+
+	_assert.False(catalog.Available("openai", "gpt-4o-mini"))
+	_assert.True(catalog.Available("openai", "llama3"))
+	_assert.True(catalog.Available("ollama", "qwen2.5:7b"))
+
+	catalog.List(context.Background(), false)
+	_assert.Equal(1, openaiServer.Listed())
+	_assert.Equal(1, ollamaServer.Listed())
+
+	openaiServer.SetModels("gpt-4o", "gpt-4o-mini")
+	catalog.List(context.Background(), true)
+	_assert.Equal(2, openaiServer.Listed())
+	_assert.True(catalog.Available("openai", "gpt-4o-mini"))
+}
@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"fmt"
+	"slices"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	KindKimi     = "kimi"
+	KindOpenAI   = "openai"
+	KindDeepSeek = "deepseek"
+	KindOllama   = "ollama"
+	KindLocal    = "local"
+)
+
+type Config struct {
+	DefaultProvider string            `json:"default_provider" toml:"default_provider" mapstructure:"default_provider"`
+	DefaultModel    string            `json:"default_model" toml:"default_model" mapstructure:"default_model"`
+	Providers       []*ProviderConfig `json:"providers" toml:"providers" mapstructure:"providers"`
+	Embedding       *EmbeddingConfig  `json:"embedding" toml:"embedding" mapstructure:"embedding"`
+}
+
+func (c *Config) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Config) VerifyConfig() error {
+	if len(c.Providers) == 0 {
+		return fmt.Errorf("llm.providers is nil")
+	}
+
+	var (
+		names = make(map[string]struct{}, len(c.Providers))
+		found bool
+	)
+	for _, p := range c.Providers {
+		if err := p.VerifyConfig(); err != nil {
+			return err
+		}
+		if _, ok := names[p.Name]; ok {
+			return fmt.Errorf("llm.providers.name is duplicated, name: %s", p.Name)
+		}
+		names[p.Name] = struct{}{}
+
+		if p.Name == c.DefaultProvider {
+			for _, m := range p.Models {
+				if m == c.DefaultModel {
+					found = true
+				}
+			}
+		}
+	}
+	if _, ok := names[c.DefaultProvider]; !ok {
+		return fmt.Errorf("llm.default_provider not found, name: %s", c.DefaultProvider)
+	}
+	if !found {
+		return fmt.Errorf("llm.default_model not found in provider[%s], model: %s", c.DefaultProvider, c.DefaultModel)
+	}
+	if c.Embedding != nil {
+		if err := c.Embedding.VerifyConfig(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ProviderConfig struct {
+	Name    string       `json:"name" toml:"name" mapstructure:"name"`
+	Kind    string       `json:"kind" toml:"kind" mapstructure:"kind"`
+	BaseURL string       `json:"base_url" toml:"base_url" mapstructure:"base_url"`
+	APIKey  string       `json:"-" toml:"api_key" mapstructure:"api_key"`
+	Models  []string     `json:"models" toml:"models" mapstructure:"models"`
+	Specs   []*ModelSpec `json:"specs" toml:"specs" mapstructure:"specs"`
+	Retry   *RetryConfig `json:"retry" toml:"retry" mapstructure:"retry"`
+}
+
+// ModelSpec overrides what is known of a model and adds its pricing, a field
+// left unset keeps the built-in value.
+type ModelSpec struct {
+	Name            string `json:"name" toml:"name" mapstructure:"name"`
+	ContextWindow   int    `json:"context_window" toml:"context_window" mapstructure:"context_window"`
+	MaxOutputTokens int    `json:"max_output_tokens" toml:"max_output_tokens" mapstructure:"max_output_tokens"`
+	Vision          *bool  `json:"vision" toml:"vision" mapstructure:"vision"`
+	Tools           *bool  `json:"tools" toml:"tools" mapstructure:"tools"`
+	JSONMode        *bool  `json:"json_mode" toml:"json_mode" mapstructure:"json_mode"`
+	JSONSchema      *bool  `json:"json_schema" toml:"json_schema" mapstructure:"json_schema"`
+	// InputPrice and OutputPrice are per million tokens in Currency.
+	InputPrice  float64 `json:"input_price" toml:"input_price" mapstructure:"input_price"`
+	OutputPrice float64 `json:"output_price" toml:"output_price" mapstructure:"output_price"`
+	Currency    string  `json:"currency" toml:"currency" mapstructure:"currency"`
+}
+
+func (c *ProviderConfig) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *ProviderConfig) VerifyConfig() error {
+	if c.Name == "" {
+		return fmt.Errorf("llm.providers.name is nil")
+	}
+	switch c.Kind {
+	case KindKimi, KindOpenAI, KindDeepSeek:
+		if c.APIKey == "" {
+			return fmt.Errorf("llm.providers[%s].api_key is nil", c.Name)
+		}
+	case KindOllama:
+	default:
+		return fmt.Errorf("llm.providers[%s].kind has wrong value, kind: %s", c.Name, c.Kind)
+	}
+	if c.BaseURL == "" && c.Kind != KindKimi {
+		return fmt.Errorf("llm.providers[%s].base_url is nil", c.Name)
+	}
+	if len(c.Models) == 0 {
+		return fmt.Errorf("llm.providers[%s].models is nil", c.Name)
+	}
+	for _, spec := range c.Specs {
+		if !slices.Contains(c.Models, spec.Name) {
+			return fmt.Errorf("llm.providers[%s].specs.name not found in models, name: %s", c.Name, spec.Name)
+		}
+		if spec.ContextWindow < 0 || spec.MaxOutputTokens < 0 {
+			return fmt.Errorf("llm.providers[%s].specs[%s] has negative token limit", c.Name, spec.Name)
+		}
+		if spec.InputPrice < 0 || spec.OutputPrice < 0 {
+			return fmt.Errorf("llm.providers[%s].specs[%s] has negative price", c.Name, spec.Name)
+		}
+	}
+	if c.Retry != nil {
+		if err := c.Retry.VerifyConfig(); err != nil {
+			return fmt.Errorf("llm.providers[%s].%v", c.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// Embedder turns text into vectors for session memory, it is configured apart
+// from the chat providers because most chat backends do not serve embeddings.
+type Embedder interface {
+	Embeddings(ctx context.Context, input []string) ([][]float32, error)
+	Dimension() int
+}
+
+// knownDimensions are the output sizes of common embedding models, any model not
+// listed here is probed once on startup.
+var knownDimensions = map[string]int{
+	string(openai.AdaEmbeddingV2):  1536,
+	string(openai.SmallEmbedding3): 1536,
+	string(openai.LargeEmbedding3): 3072,
+	"nomic-embed-text":             768,
+	"mxbai-embed-large":            1024,
+	"bge-m3":                       1024,
+}
+
+type EmbeddingConfig struct {
+	Kind      string       `json:"kind" toml:"kind" mapstructure:"kind"`
+	BaseURL   string       `json:"base_url" toml:"base_url" mapstructure:"base_url"`
+	APIKey    string       `json:"-" toml:"api_key" mapstructure:"api_key"`
+	Model     string       `json:"model" toml:"model" mapstructure:"model"`
+	Dimension int          `json:"dimension" toml:"dimension" mapstructure:"dimension"`
+	Retry     *RetryConfig `json:"retry" toml:"retry" mapstructure:"retry"`
+}
+
+func (c *EmbeddingConfig) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *EmbeddingConfig) VerifyConfig() error {
+	switch c.Kind {
+	case KindLocal:
+		if c.Dimension < 0 {
+			return fmt.Errorf("llm.embedding.dimension has wrong value, dimension: %d", c.Dimension)
+		}
+		return nil
+	case KindOpenAI, KindOllama:
+	default:
+		return fmt.Errorf("llm.embedding.kind has wrong value, kind: %s", c.Kind)
+	}
+	if c.Retry != nil {
+		if err := c.Retry.VerifyConfig(); err != nil {
+			return fmt.Errorf("llm.embedding.%v", err)
+		}
+	}
+	if c.BaseURL == "" {
+		return fmt.Errorf("llm.embedding.base_url is nil")
+	}
+	if c.Model == "" {
+		return fmt.Errorf("llm.embedding.model is nil")
+	}
+	if c.Dimension < 0 {
+		return fmt.Errorf("llm.embedding.dimension has wrong value, dimension: %d", c.Dimension)
+	}
+	return nil
+}
+
+func NewEmbedder(ctx context.Context, c *EmbeddingConfig) (Embedder, error) {
+	if err := c.VerifyConfig(); err != nil {
+		return nil, err
+	}
+
+	if c.Kind == KindLocal {
+		return NewLocalEmbedder(c.Dimension), nil
+	}
+	return NewOpenAIEmbedder(ctx, c)
+}
+
+// OpenAIEmbedder calls the /embeddings api of an OpenAI compatible backend.
+type OpenAIEmbedder struct {
+	Model string
+
+	dimension int
+	ai        *openai.Client
+	retrier   *retrier
+}
+
+func NewOpenAIEmbedder(ctx context.Context, c *EmbeddingConfig) (*OpenAIEmbedder, error) {
+	cfg := openai.DefaultConfig(c.APIKey)
+	cfg.BaseURL = c.BaseURL
+	cfg.HTTPClient = newRetryHTTPClient()
+
+	e := &OpenAIEmbedder{
+		Model: c.Model,
+
+		dimension: c.Dimension,
+		ai:        openai.NewClientWithConfig(cfg),
+		retrier:   newRetrier("embedding", c.Retry),
+	}
+	if e.dimension == 0 {
+		e.dimension = knownDimensions[c.Model]
+	}
+	if e.dimension == 0 {
+		dimension, err := e.probe(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("probe embedding dimension failure, nest error: %v", err)
+		}
+		zlog.Info("Probe embedding dimension success", zap.String("model", c.Model), zap.Int("dimension", dimension))
+		e.dimension = dimension
+	}
+	return e, nil
+}
+
+func (e *OpenAIEmbedder) probe(ctx context.Context) (int, error) {
+	data, err := e.Embeddings(ctx, []string{"dimension probe"})
+	if err != nil {
+		return 0, err
+	}
+	if len(data[0]) == 0 {
+		return 0, fmt.Errorf("panic: empty embedding")
+	}
+	return len(data[0]), nil
+}
+
+func (e *OpenAIEmbedder) Dimension() int {
+	return e.dimension
+}
+
+func (e *OpenAIEmbedder) Embeddings(ctx context.Context, input []string) ([][]float32, error) {
+	var resp openai.EmbeddingResponse
+	err := e.retrier.do(ctx, "embeddings", func(ctx context.Context) error {
+		var err error
+		resp, err = e.ai.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Model: openai.EmbeddingModel(e.Model),
+			Input: input,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(input) {
+		return nil, fmt.Errorf("panic: embeddings result size mismatch, expect: %d, actual: %d", len(input), len(resp.Data))
+	}
+
+	data := make([][]float32, len(resp.Data))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(data) {
+			return nil, fmt.Errorf("panic: embeddings result index out of range, index: %d", d.Index)
+		}
+		if e.dimension != 0 && len(d.Embedding) != e.dimension {
+			return nil, fmt.Errorf("panic: embedding dimension mismatch, expect: %d, actual: %d", e.dimension, len(d.Embedding))
+		}
+		data[d.Index] = d.Embedding
+	}
+	return data, nil
+}
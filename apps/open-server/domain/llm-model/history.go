@@ -0,0 +1,239 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// minRecentTurns is how many recent turns are never compacted.
+	minRecentTurns = 4
+
+	summaryPrompt = "You maintain the running summary of a conversation between a user and an assistant. " +
+		"Merge the previous summary and the new turns into one summary, keep facts, decisions, names, numbers " +
+		"and open questions, drop small talk. Write in the language of the conversation, no more than 300 words."
+)
+
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (t Turn) message() openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{Role: t.Role, Content: t.Content}
+}
+
+func isPinnedRole(role string) bool {
+	return role == openai.ChatMessageRoleSystem || role == openai.ChatMessageRoleDeveloper
+}
+
+// history is the ordered turns of a session. System turns are pinned, the
+// oldest of the others are compacted into a running summary when the history
+// is over budget.
+type history struct {
+	sync.Mutex
+	compactMu sync.Mutex
+
+	pinned  []Turn
+	turns   []Turn
+	summary string
+}
+
+// opening returns the pinned turns when the conversation has no other turns
+// yet, so the next question opens it.
+func (h *history) opening() ([]Turn, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	if len(h.turns) != 0 || h.summary != "" {
+		return nil, false
+	}
+	return append([]Turn(nil), h.pinned...), true
+}
+
+func (h *history) add(turns ...Turn) {
+	h.Lock()
+	defer h.Unlock()
+
+	for _, turn := range turns {
+		if isPinnedRole(turn.Role) {
+			h.pinned = append(h.pinned, turn)
+		} else {
+			h.turns = append(h.turns, turn)
+		}
+	}
+}
+
+// setSystem replaces the pinned system turns with prompt, or drops them when
+// prompt is empty. Developer turns are kept.
+func (h *history) setSystem(prompt string) {
+	h.Lock()
+	defer h.Unlock()
+
+	pinned := make([]Turn, 0, len(h.pinned)+1)
+	if prompt != "" {
+		pinned = append(pinned, Turn{Role: openai.ChatMessageRoleSystem, Content: prompt})
+	}
+	for _, turn := range h.pinned {
+		if turn.Role != openai.ChatMessageRoleSystem {
+			pinned = append(pinned, turn)
+		}
+	}
+	h.pinned = pinned
+}
+
+// rewind drops the last user turn and the turns after it, they are returned
+// so that they can be put back. It fails when the last user turn is already
+// compacted.
+func (h *history) rewind() ([]Turn, bool) {
+	h.compactMu.Lock()
+	defer h.compactMu.Unlock()
+	h.Lock()
+	defer h.Unlock()
+
+	for i := len(h.turns) - 1; i >= 0; i-- {
+		if h.turns[i].Role == openai.ChatMessageRoleUser {
+			dropped := append([]Turn(nil), h.turns[i:]...)
+			h.turns = h.turns[:i]
+			return dropped, true
+		}
+	}
+	return nil, false
+}
+
+func (h *history) summaryMessage() (openai.ChatCompletionMessage, bool) {
+	if h.summary == "" {
+		return openai.ChatCompletionMessage{}, false
+	}
+	return openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: "Summary of the earlier conversation:\n" + h.summary,
+	}, true
+}
+
+// overflow returns how many of the oldest turns need compacting to bring the
+// history within budget tokens.
+func (h *history) overflow(budget int) int {
+	h.Lock()
+	defer h.Unlock()
+
+	var total int
+	for _, turn := range h.pinned {
+		total += countMessageTokens(turn.message())
+	}
+	if message, ok := h.summaryMessage(); ok {
+		total += countMessageTokens(message)
+	}
+	for _, turn := range h.turns {
+		total += countMessageTokens(turn.message())
+	}
+
+	var n int
+	for total > budget && len(h.turns)-n > minRecentTurns {
+		total -= countMessageTokens(h.turns[n].message())
+		n++
+	}
+	return n
+}
+
+// head returns the summary and a copy of the n oldest turns.
+func (h *history) head(n int) (string, []Turn) {
+	h.Lock()
+	defer h.Unlock()
+
+	return h.summary, append([]Turn(nil), h.turns[:n]...)
+}
+
+// compacted replaces the n oldest turns with summary, turns are only appended
+// meanwhile so the n oldest are still the ones that were summarised.
+func (h *history) compacted(n int, summary string) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.turns = append(h.turns[:0:0], h.turns[n:]...)
+	h.summary = summary
+}
+
+// build assembles the prompt: pinned turns, the summary, the memories which fit
+// in budget and are not already in the recent turns, the recent turns, the
+// context of this turn and next.
+func (h *history) build(budget int, memories []string, context []openai.ChatCompletionMessage, next openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	h.Lock()
+	defer h.Unlock()
+
+	messages := make([]openai.ChatCompletionMessage, 0, len(h.pinned)+len(h.turns)+3)
+	for _, turn := range h.pinned {
+		messages = append(messages, turn.message())
+	}
+	if message, ok := h.summaryMessage(); ok {
+		messages = append(messages, message)
+	}
+
+	recent := make(map[string]struct{}, len(h.turns)+1)
+	tail := make([]openai.ChatCompletionMessage, 0, len(h.turns)+1)
+	for _, turn := range h.turns {
+		recent[turn.Content] = struct{}{}
+		tail = append(tail, turn.message())
+	}
+	recent[turnOf(next).Content] = struct{}{}
+	tail = append(tail, context...)
+	tail = append(tail, next)
+
+	remain := budget - countMessageTokens(messages...) - countMessageTokens(tail...) - messageOverheadTokens
+	relevant := make([]string, 0, len(memories))
+	for _, memory := range memories {
+		if _, ok := recent[memory]; ok {
+			continue
+		}
+		cost := CountTokens(memory) + 1
+		if cost > remain {
+			break
+		}
+		remain -= cost
+		relevant = append(relevant, memory)
+	}
+	if len(relevant) != 0 {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "Related content from the earlier conversation:\n" + strings.Join(relevant, "\n---\n"),
+		})
+	}
+	return append(messages, tail...)
+}
+
+func (h *history) restore(state *State) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.pinned = append([]Turn(nil), state.Pinned...)
+	h.turns = append([]Turn(nil), state.Turns...)
+	h.summary = state.Summary
+}
+
+func (h *history) save(state *State) {
+	h.Lock()
+	defer h.Unlock()
+
+	state.Pinned = append([]Turn(nil), h.pinned...)
+	state.Turns = append([]Turn(nil), h.turns...)
+	state.Summary = h.summary
+}
+
+func summaryRequest(summary string, turns []Turn) []openai.ChatCompletionMessage {
+	var buf strings.Builder
+	if summary != "" {
+		fmt.Fprintf(&buf, "Previous summary:\n%s\n\n", summary)
+	}
+	buf.WriteString("New turns:\n")
+	for _, turn := range turns {
+		fmt.Fprintf(&buf, "%s: %s\n", turn.Role, turn.Content)
+	}
+
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: summaryPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: buf.String()},
+	}
+}
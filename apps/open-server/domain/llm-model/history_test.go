@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWindow(t *testing.T) {
+	_assert := assert.New(t)
+
+	_assert.Equal(32768, ContextWindow("moonshot-v1-32k"))
+	_assert.Equal(16*1024, ContextWindow("qwen2.5-16k"))
+	_assert.Equal(DefaultContextWindow, ContextWindow("qwen2.5:7b"))
+	_assert.Equal(32768-4096, PromptBudget("moonshot-v1-32k"))
+	_assert.Equal(8192-2048, PromptBudget("moonshot-v1-8k"))
+
+	_assert.Equal(0, CountTokens(""))
+	_assert.Equal(4, CountTokens("你好世界"))
+	_assert.Equal(4, CountTokens("hello world"))
+}
+
+func TestHistoryCompact(t *testing.T) {
+	_assert := assert.New(t)
+
+	var h history
+	h.add(Turn{Role: openai.ChatMessageRoleSystem, Content: "be brief"})
+	for i := 0; i < 10; i++ {
+		h.add(
+			Turn{Role: openai.ChatMessageRoleUser, Content: strings.Repeat("问", 100)},
+			Turn{Role: openai.ChatMessageRoleAssistant, Content: strings.Repeat("答", 100)},
+		)
+	}
+	_assert.Equal(0, h.overflow(10000))
+
+	n := h.overflow(500)
+	_assert.Equal(len(h.turns)-minRecentTurns, n)
+
+	summary, turns := h.head(n)
+	_assert.Equal("", summary)
+	_assert.Equal(n, len(turns))
+	h.compacted(n, "earlier")
+	_assert.Equal(minRecentTurns, len(h.turns))
+
+	messages := h.build(10000, []string{"memory", h.turns[0].Content}, nil, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "next"})
+	_assert.Equal("be brief", messages[0].Content)
+	_assert.Contains(messages[1].Content, "earlier")
+	_assert.Equal("Related content from the earlier conversation:\nmemory", messages[2].Content)
+	_assert.Equal(3+minRecentTurns+1, len(messages))
+	_assert.Equal("next", messages[len(messages)-1].Content)
+
+	messages = h.build(445, []string{"memory"}, nil, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "next"})
+	_assert.Equal(2+minRecentTurns+1, len(messages))
+}
+
+func TestHistorySetSystem(t *testing.T) {
+	_assert := assert.New(t)
+
+	var h history
+	h.add(
+		Turn{Role: openai.ChatMessageRoleSystem, Content: "be brief"},
+		Turn{Role: openai.ChatMessageRoleDeveloper, Content: "use metric units"},
+		Turn{Role: openai.ChatMessageRoleUser, Content: "hi"},
+	)
+
+	h.setSystem("answer in Chinese")
+	_assert.Equal([]Turn{
+		{Role: openai.ChatMessageRoleSystem, Content: "answer in Chinese"},
+		{Role: openai.ChatMessageRoleDeveloper, Content: "use metric units"},
+	}, h.pinned)
+	_assert.Equal(1, len(h.turns))
+
+	h.setSystem("")
+	_assert.Equal([]Turn{{Role: openai.ChatMessageRoleDeveloper, Content: "use metric units"}}, h.pinned)
+}
@@ -2,60 +2,113 @@ package llm
 
 import (
 	"context"
-	"fmt"
-	"log"
 	"os"
+	"strings"
 	"testing"
-	"time"
 
-	libqdrant "github.com/eviltomorrow/open-terminal/lib/qdrant"
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/eviltomorrow/open-terminal/lib/preset"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
 )
 
-func init() {
-	if _, err := libqdrant.InitQdrant(&libqdrant.Config{
-		StartupRetryPeriod: 3 * time.Second,
-		StartupRetryTimes:  3,
-		ConnectTimeout:     5 * time.Second,
-
-		Host: "localhost",
-		Port: 6334,
-	}); err != nil {
-		log.Fatal(err)
+// newTestKimiClient talks to the live Moonshot endpoint when KIMI_API_KEY is
+// set, and to a local mock server otherwise.
+func newTestKimiClient(t *testing.T) (*KimiClient, *llmtest.Server) {
+	if apiKey := os.Getenv("KIMI_API_KEY"); apiKey != "" {
+		return NewKimiClient(preset.SetString(os.Getenv("KIMI_BASE_URL"), DefaultKimiBaseURL), apiKey), nil
 	}
+
+	server := llmtest.NewServer()
+	t.Cleanup(server.Close)
+	return NewKimiClient(server.BaseURL(), "mock"), server
 }
 
 func TestKimiStream(t *testing.T) {
-	apiKey := os.Getenv("KIMI_API_KEY")
-	if apiKey == "" {
-		t.Fatal("KIMI_API_KEY is nil")
+	_assert := assert.New(t)
+
+	client, server := newTestKimiClient(t)
+	if server != nil {
+		server.Enqueue(llmtest.Tokens("你", "好", "！"), llmtest.Tokens("再见"))
 	}
 
-	client := NewKimiClient("https://api.moonshot.cn/v1", apiKey)
+	session, err := client.NewSession(preset.SetString(os.Getenv("KIMI_MODEL"), DefaultKimiModel),
+		WithSessionForEmbedder(NewLocalEmbedder(0)),
+		WithSessionForVectorStore(vectorstore.NewMemory()),
+	)
+	_assert.Nil(err)
+	defer session.Close()
 
-	session, err := client.NewSession("moonshot-v1-32k")
-	if err != nil {
-		t.Fatalf("NewSession failure, nest error: %v", err)
+	stream, err := session.StartChat(context.Background(), openai.ChatMessageRoleUser, "你好")
+	_assert.Nil(err)
+	var buf strings.Builder
+	for c := range stream.Events() {
+		buf.WriteString(c.Content)
 	}
+	_assert.Nil(stream.Err())
+	_assert.Equal(openai.FinishReasonStop, stream.FinishReason())
+	_assert.NotEmpty(buf.String())
+
+	stream, err = session.Send(context.Background(), openai.ChatMessageRoleUser, "再见")
+	_assert.Nil(err)
+	for range stream.Events() {
+	}
+	_assert.Nil(stream.Err())
+
+	if server == nil {
+		return
+	}
+	_assert.Equal("你好！", buf.String())
+
+	requests := server.Requests()
+	_assert.Equal(2, len(requests))
+	messages := requests[1].Messages
+	_assert.Equal("你好", messages[0].Content)
+	_assert.Equal("你好！", messages[1].Content)
+	_assert.Equal("再见", messages[len(messages)-1].Content)
+}
+
+func TestRegenerateMemory(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	store := vectorstore.NewMemory()
+	session, err := NewKimiClient(server.BaseURL(), "mock").NewSession(DefaultKimiModel,
+		WithSessionForEmbedder(NewLocalEmbedder(0)),
+		WithSessionForVectorStore(store),
+	)
+	_assert.Nil(err)
 	defer session.Close()
 
-	ch, err := session.StartChat(context.Background(), "你好")
-	if err != nil {
-		t.Fatalf("StartChat failure, nest error: %v", err)
-	}
-	for c := range ch {
-		fmt.Println(c)
-	}
-	// ch, err := client.ChatStream(context.Background(), []*chat.Message{
-	// 	{
-	// 		Role:    openai.ChatMessageRoleUser,
-	// 		Content: "帮我介绍一下 Rust 语言，并分析一下日后趋势",
-	// 	},
-	// })
-	// if err != nil {
-	// 	t.Fatalf("ChatStream failure, nest error: %v", err)
-	// }
-
-	// for r := range ch {
-	// 	fmt.Printf("%s", r)
-	// }
+	drain := func(stream *Stream, err error) {
+		_assert.Nil(err)
+		for range stream.Events() {
+		}
+		_assert.Nil(stream.Err())
+	}
+	server.Enqueue(llmtest.Tokens("a"), llmtest.Tokens("b"), llmtest.Tokens("c"), llmtest.Tokens("d"))
+	drain(session.StartChat(context.Background(), openai.ChatMessageRoleUser, "hello"))
+	drain(session.Send(context.Background(), openai.ChatMessageRoleUser, "how do I restart the service?"))
+	drain(session.Regenerate(context.Background()))
+	drain(session.Regenerate(context.Background()))
+
+	points, err := store.Scroll(context.Background(), session.GetId(), nil, 0, 100)
+	_assert.Nil(err)
+	var turns int
+	for _, point := range points {
+		if point.Payload["content"] == "how do I restart the service?" {
+			turns++
+		}
+	}
+	_assert.Equal(1, turns)
+	_assert.Equal(2, len(points))
+
+	// the regenerated turn is not related to itself
+	var asked int
+	for _, message := range server.Requests()[3].Messages {
+		asked += strings.Count(message.Content, "how do I restart the service?")
+	}
+	_assert.Equal(1, asked)
 }
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxStopSequences is what every OpenAI compatible backend accepts at least.
+const maxStopSequences = 4
+
+var ErrInvalidParams = errors.New("invalid generation params")
+
+// ModelLimits are the bounds of the generation params a model accepts.
+type ModelLimits struct {
+	ContextWindow   int
+	MaxOutputTokens int
+	MaxTemperature  float32
+	MaxN            int
+	JSONMode        bool
+	JSONSchema      bool
+	Vision          bool
+	Tools           bool
+}
+
+// modelLimits are the limits which differ from the defaults of the family,
+// the context window comes from contextWindows.
+var modelLimits = map[string]ModelLimits{
+	"gpt-4o":            {MaxOutputTokens: 16384, MaxTemperature: 2, MaxN: 128, JSONMode: true, JSONSchema: true, Vision: true, Tools: true},
+	"gpt-4o-mini":       {MaxOutputTokens: 16384, MaxTemperature: 2, MaxN: 128, JSONMode: true, JSONSchema: true, Vision: true, Tools: true},
+	"gpt-4-turbo":       {MaxOutputTokens: 4096, MaxTemperature: 2, MaxN: 128, JSONMode: true, Vision: true, Tools: true},
+	"gpt-3.5-turbo":     {MaxOutputTokens: 4096, MaxTemperature: 2, MaxN: 128, JSONMode: true, Tools: true},
+	"deepseek-chat":     {MaxOutputTokens: 8192, MaxTemperature: 2, MaxN: 1, JSONMode: true, Tools: true},
+	"deepseek-reasoner": {MaxOutputTokens: 65536, MaxTemperature: 2, MaxN: 1, JSONMode: true},
+}
+
+// visionMarks are the parts of a model name which tell it accepts images, such
+// as moonshot-v1-8k-vision-preview, qwen2.5-vl or llava.
+var visionMarks = []string{"vision", "-vl", "llava"}
+
+// LimitsOf returns the limits of the model, a moonshot model may write up to
+// its whole context window, any other unknown model gets the loose defaults.
+func LimitsOf(modelName string) ModelLimits {
+	window := ContextWindow(modelName)
+
+	limits, ok := modelLimits[modelName]
+	switch {
+	case ok:
+	case strings.HasPrefix(modelName, "moonshot-"):
+		limits = ModelLimits{MaxOutputTokens: window, MaxTemperature: 1, MaxN: 5, JSONMode: true, Tools: true}
+	default:
+		limits = ModelLimits{MaxOutputTokens: window, MaxTemperature: 2, MaxN: 1, JSONMode: true, Tools: true}
+	}
+	limits.ContextWindow = window
+	for _, mark := range visionMarks {
+		if strings.Contains(strings.ToLower(modelName), mark) {
+			limits.Vision = true
+		}
+	}
+	return limits
+}
+
+// limitsFor returns the limits of a model served by provider, with what the
+// spec of the model in config overrides.
+func limitsFor(provider Provider, modelName string) ModelLimits {
+	limits := LimitsOf(modelName)
+	spec, ok := provider.Spec(modelName)
+	if !ok {
+		return limits
+	}
+
+	if spec.ContextWindow > 0 {
+		limits.ContextWindow = spec.ContextWindow
+		limits.MaxOutputTokens = min(limits.MaxOutputTokens, spec.ContextWindow)
+	}
+	if spec.MaxOutputTokens > 0 {
+		limits.MaxOutputTokens = spec.MaxOutputTokens
+	}
+	for _, o := range []struct {
+		value *bool
+		field *bool
+	}{
+		{spec.Vision, &limits.Vision},
+		{spec.Tools, &limits.Tools},
+		{spec.JSONMode, &limits.JSONMode},
+		{spec.JSONSchema, &limits.JSONSchema},
+	} {
+		if o.value != nil {
+			*o.field = *o.value
+		}
+	}
+	return limits
+}
+
+// ValidateRequest checks the generation params of req against the limits of
+// req.Model, the error wraps ErrInvalidParams.
+func ValidateRequest(req *openai.ChatCompletionRequest) error {
+	return validateRequest(req, LimitsOf(req.Model))
+}
+
+func validateRequest(req *openai.ChatCompletionRequest, limits ModelLimits) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w, model: %s, %s", ErrInvalidParams, req.Model, fmt.Sprintf(format, args...))
+	}
+	switch {
+	case req.Temperature < 0 || req.Temperature > limits.MaxTemperature:
+		return invalid("temperature must be in [0, %v]", limits.MaxTemperature)
+	case req.TopP < 0 || req.TopP > 1:
+		return invalid("top_p must be in [0, 1], 0 for the default")
+	case req.MaxTokens < 0 || req.MaxTokens > limits.MaxOutputTokens:
+		return invalid("max_tokens must be in [0, %d], 0 for the default", limits.MaxOutputTokens)
+	case len(req.Stop) > maxStopSequences:
+		return invalid("stop allows %d sequences at most", maxStopSequences)
+	case slices.Contains(req.Stop, ""):
+		return invalid("stop sequence is nil")
+	case req.PresencePenalty < -2 || req.PresencePenalty > 2:
+		return invalid("presence_penalty must be in [-2, 2]")
+	case req.FrequencyPenalty < -2 || req.FrequencyPenalty > 2:
+		return invalid("frequency_penalty must be in [-2, 2]")
+	case req.N < 0 || req.N > max(limits.MaxN, 1):
+		return invalid("n must be in [0, %d], 0 for the default", max(limits.MaxN, 1))
+	case req.ResponseFormat != nil && req.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeText && !limits.JSONMode:
+		return invalid("response_format %s is not supported", req.ResponseFormat.Type)
+	case req.ResponseFormat != nil && req.ResponseFormat.Type == openai.ChatCompletionResponseFormatTypeJSONSchema && schemaOf(req) == nil:
+		return invalid("response_format json_schema has no schema")
+	case schemaOf(req) != nil && req.N > 1:
+		return invalid("n must be 1 with json_schema")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequest(t *testing.T) {
+	_assert := assert.New(t)
+
+	_assert.Nil(ValidateRequest(&openai.ChatCompletionRequest{Model: "moonshot-v1-8k", Temperature: 0.3, MaxTokens: 1024, N: 2, Stop: []string{"\n\n"}}))
+	_assert.Nil(ValidateRequest(&openai.ChatCompletionRequest{Model: "gpt-4o", Temperature: 1.5, PresencePenalty: -2, FrequencyPenalty: 2}))
+
+	for _, req := range []*openai.ChatCompletionRequest{
+		{Model: "moonshot-v1-8k", Temperature: 1.5},
+		{Model: "moonshot-v1-8k", MaxTokens: 8193},
+		{Model: "moonshot-v1-8k", N: 6},
+		{Model: "moonshot-v1-8k", TopP: 1.1},
+		{Model: "gpt-4o", Stop: []string{"a", "b", "c", "d", "e"}},
+		{Model: "gpt-4o", Stop: []string{""}},
+		{Model: "gpt-4o", PresencePenalty: 2.5},
+		{Model: "deepseek-chat", N: 2},
+	} {
+		_assert.ErrorIs(ValidateRequest(req), ErrInvalidParams, req.Model)
+	}
+}
+
+func TestProvidersResolve(t *testing.T) {
+	_assert := assert.New(t)
+
+	providers, err := NewProviders(&Config{
+		DefaultProvider: "kimi",
+		DefaultModel:    "moonshot-v1-8k",
+		Providers: []*ProviderConfig{
+			{Name: "kimi", Kind: KindKimi, APIKey: "key", Models: []string{"moonshot-v1-8k", "moonshot-v1-32k"}},
+			{Name: "ollama", Kind: KindOllama, BaseURL: "http://localhost:11434/v1", Models: []string{"qwen2.5:7b"}},
+		},
+	})
+	_assert.Nil(err)
+
+	provider, modelName, err := providers.Resolve("")
+	_assert.Nil(err)
+	_assert.Equal("kimi", provider.Name())
+	_assert.Equal("moonshot-v1-8k", modelName)
+
+	provider, modelName, err = providers.Resolve("ollama/qwen2.5:7b")
+	_assert.Nil(err)
+	_assert.Equal("ollama", provider.Name())
+	_assert.Equal("qwen2.5:7b", modelName)
+
+	provider, _, err = providers.Resolve("moonshot-v1-32k")
+	_assert.Nil(err)
+	_assert.Equal("kimi", provider.Name())
+
+	_, _, err = providers.Resolve("kimi/qwen2.5:7b")
+	_assert.NotNil(err)
+}
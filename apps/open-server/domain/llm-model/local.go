@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"unicode/utf8"
+
+	"github.com/eviltomorrow/open-terminal/lib/textutil"
+)
+
+const DefaultLocalDimension = 512
+
+// LocalEmbedder builds vectors in process with the hashing trick over words,
+// character trigrams of words and CJK character n-grams. It needs no network
+// and always gives the same vector for the same text.
+type LocalEmbedder struct {
+	dimension int
+}
+
+func NewLocalEmbedder(dimension int) *LocalEmbedder {
+	if dimension <= 0 {
+		dimension = DefaultLocalDimension
+	}
+	return &LocalEmbedder{dimension: dimension}
+}
+
+func (e *LocalEmbedder) Dimension() int {
+	return e.dimension
+}
+
+func (e *LocalEmbedder) Embeddings(ctx context.Context, input []string) ([][]float32, error) {
+	data := make([][]float32, 0, len(input))
+	for _, text := range input {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data = append(data, e.embed(text))
+	}
+	return data, nil
+}
+
+func (e *LocalEmbedder) embed(text string) []float32 {
+	counts := make(map[string]int, 32)
+	for _, term := range textutil.Tokenize(text) {
+		counts[term]++
+
+		r, _ := utf8.DecodeRuneInString(term)
+		if textutil.IsCJK(r) {
+			continue
+		}
+		padded := []rune("^" + term + "$")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts["#"+string(padded[i:i+3])]++
+		}
+	}
+
+	vec := make([]float64, e.dimension)
+	for feature, n := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		weight := 1 + math.Log(float64(n))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vec[sum%uint64(e.dimension)] += weight
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+
+	data := make([]float32, e.dimension)
+	if norm == 0 {
+		return data
+	}
+	for i, v := range vec {
+		data[i] = float32(v / norm)
+	}
+	return data
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/snowflake"
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAIClient is a provider for any backend which serves the OpenAI compatible
+// api, such as OpenAI, DeepSeek and Ollama.
+type OpenAIClient struct {
+	BaseURL    string
+	APIKey     string
+	ModelNames []string
+
+	name          string
+	sessionPrefix string
+	ai            *openai.Client
+	retrier       *retrier
+	specs         map[string]*ModelSpec
+}
+
+func NewOpenAIClient(c *ProviderConfig) *OpenAIClient {
+	client := newOpenAIClient(c.Name, sessionPrefix(c.Kind), c.BaseURL, c.APIKey, c.Models, c.Retry)
+	client.setSpecs(c.Specs)
+	return client
+}
+
+func newOpenAIClient(name, prefix, baseURL, apiKey string, models []string, retry *RetryConfig) *OpenAIClient {
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.BaseURL = baseURL
+	cfg.HTTPClient = newRetryHTTPClient()
+
+	return &OpenAIClient{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		ModelNames: models,
+
+		name:          name,
+		sessionPrefix: prefix,
+		ai:            openai.NewClientWithConfig(cfg),
+		retrier:       newRetrier(name, retry),
+	}
+}
+
+func sessionPrefix(kind string) string {
+	switch kind {
+	case KindKimi:
+		return "Kimi"
+	case KindDeepSeek:
+		return "DeepSeek"
+	case KindOllama:
+		return "Ollama"
+	default:
+		return "OpenAI"
+	}
+}
+
+func (c *OpenAIClient) Name() string {
+	return c.name
+}
+
+func (c *OpenAIClient) Models() []string {
+	return c.ModelNames
+}
+
+func (c *OpenAIClient) setSpecs(specs []*ModelSpec) {
+	c.specs = make(map[string]*ModelSpec, len(specs))
+	for _, spec := range specs {
+		c.specs[spec.Name] = spec
+	}
+}
+
+func (c *OpenAIClient) Spec(modelName string) (*ModelSpec, bool) {
+	spec, ok := c.specs[modelName]
+	return spec, ok
+}
+
+func (c *OpenAIClient) UpstreamModels(ctx context.Context) ([]openai.Model, error) {
+	var models openai.ModelsList
+	err := c.retrier.do(ctx, "list_models", func(ctx context.Context) error {
+		var err error
+		models, err = c.ai.ListModels(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return models.Models, nil
+}
+
+func (c *OpenAIClient) NewSession(modelName string, opts ...func(*ChatSession)) (Session, error) {
+	id := fmt.Sprintf("%s-%v", c.sessionPrefix, snowflake.GenerateID())
+
+	session := &ChatSession{
+		Id:        id,
+		ModelName: modelName,
+
+		provider: c,
+	}
+	for _, opt := range opts {
+		opt(session)
+	}
+	if session.store != nil && session.retriever == nil {
+		retriever, err := retrieval.NewRetriever(session.store, nil)
+		if err != nil {
+			return nil, err
+		}
+		session.retriever, session.store = retriever, retriever.Store()
+	}
+	return session, nil
+}
+
+// ChatCompletionStream retries only the creation of the stream, once the first
+// delta has been received the answer can not be replayed.
+func (c *OpenAIClient) ChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	var resp *openai.ChatCompletionStream
+	err := c.retrier.do(ctx, "chat_completion_stream", func(ctx context.Context) error {
+		var err error
+		resp, err = c.ai.CreateChatCompletionStream(ctx, req)
+		return err
+	})
+	return resp, err
+}
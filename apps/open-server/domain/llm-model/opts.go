@@ -1,9 +1,126 @@
 package llm
 
-import "github.com/sashabaranov/go-openai"
+import (
+	"math"
 
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/sashabaranov/go-openai"
+)
+
+// WithChatCompletionRequestForTemperature sets the temperature, 0 is sent as the
+// smallest float since go-openai omits a zero temperature.
 func WithChatCompletionRequestForTemperature(val float32) func(*openai.ChatCompletionRequest) {
 	return func(ccr *openai.ChatCompletionRequest) {
+		if val == 0 {
+			val = math.SmallestNonzeroFloat32
+		}
 		ccr.Temperature = val
 	}
 }
+
+// WithChatCompletionRequestForModel overrides the model of the session for one
+// request, the model must be served by the same provider.
+func WithChatCompletionRequestForModel(val string) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.Model = val
+	}
+}
+
+func WithChatCompletionRequestForMaxTokens(val int) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.MaxTokens = val
+	}
+}
+
+func WithChatCompletionRequestForTopP(val float32) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.TopP = val
+	}
+}
+
+func WithChatCompletionRequestForStop(val ...string) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.Stop = val
+	}
+}
+
+func WithChatCompletionRequestForPresencePenalty(val float32) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.PresencePenalty = val
+	}
+}
+
+func WithChatCompletionRequestForFrequencyPenalty(val float32) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.FrequencyPenalty = val
+	}
+}
+
+func WithChatCompletionRequestForSeed(val int) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.Seed = &val
+	}
+}
+
+func WithChatCompletionRequestForN(val int) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.N = val
+	}
+}
+
+func WithChatCompletionRequestForJSONObject() func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+}
+
+func WithSessionForTools(tools ToolExecutor) func(*ChatSession) {
+	return func(s *ChatSession) {
+		s.tools = tools
+	}
+}
+
+func WithSessionForEmbedder(embedder Embedder) func(*ChatSession) {
+	return func(s *ChatSession) {
+		s.embedder = embedder
+	}
+}
+
+// WithSessionForRetriever sets how the memory of the session is searched, it
+// must search the vector store of the session, which must be its Store(). A
+// retriever with the default config is used when it is not set.
+func WithSessionForRetriever(retriever *retrieval.Retriever) func(*ChatSession) {
+	return func(s *ChatSession) {
+		s.retriever = retriever
+	}
+}
+
+// WithSessionForResponseCache lets the session answer an opening question from
+// cache when a close one was answered before under the same scope.
+func WithSessionForResponseCache(cache ResponseCache) func(*ChatSession) {
+	return func(s *ChatSession) {
+		s.responseCache = cache
+	}
+}
+
+func WithSessionForVectorStore(store vectorstore.VectorStore) func(*ChatSession) {
+	return func(s *ChatSession) {
+		s.store = store
+	}
+}
+
+// WithChatCompletionRequestForJSONSchema asks for an answer matching schema,
+// the session validates it and re-prompts up to schema.MaxRetries times.
+func WithChatCompletionRequestForJSONSchema(schema *Schema) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		ccr.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "answer",
+				Schema: schema,
+				Strict: true,
+			},
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Provider is a chat backend which speaks the OpenAI compatible api.
+type Provider interface {
+	Name() string
+	Models() []string
+	// Spec returns the spec of the model in config, if any.
+	Spec(modelName string) (*ModelSpec, bool)
+	// UpstreamModels lists the models the backend serves, from its /models
+	// endpoint.
+	UpstreamModels(ctx context.Context) ([]openai.Model, error)
+
+	NewSession(modelName string, opts ...func(*ChatSession)) (Session, error)
+
+	ChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error)
+}
+
+// Session is a multi-turn conversation bound to one provider and model.
+type Session interface {
+	GetId() string
+	GetModelName() string
+
+	StartChat(ctx context.Context, role string, content string, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error)
+	Send(ctx context.Context, role string, content string, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error)
+	// Regenerate drops the last answer and asks its user turn again.
+	Regenerate(ctx context.Context, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error)
+	// SetSystemPrompt replaces the system prompt of the session, an empty
+	// prompt drops it.
+	SetSystemPrompt(prompt string)
+
+	Persist()
+	State() *State
+	Restore(ctx context.Context, state *State) error
+	Close() error
+}
+
+func NewProvider(c *ProviderConfig) (Provider, error) {
+	if err := c.VerifyConfig(); err != nil {
+		return nil, err
+	}
+
+	switch c.Kind {
+	case KindKimi:
+		client := newKimiClient(c.Name, c.BaseURL, c.APIKey, c.Models, c.Retry)
+		client.setSpecs(c.Specs)
+		return client, nil
+	case KindOpenAI, KindDeepSeek, KindOllama:
+		return NewOpenAIClient(c), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider kind: %s", c.Kind)
+	}
+}
+
+// Providers holds every configured provider, looked up by name or by model.
+type Providers struct {
+	defaultProvider string
+	defaultModel    string
+
+	providers map[string]Provider
+}
+
+func NewProviders(c *Config) (*Providers, error) {
+	if err := c.VerifyConfig(); err != nil {
+		return nil, err
+	}
+
+	p := &Providers{
+		defaultProvider: c.DefaultProvider,
+		defaultModel:    c.DefaultModel,
+		providers:       make(map[string]Provider, len(c.Providers)),
+	}
+	for _, pc := range c.Providers {
+		provider, err := NewProvider(pc)
+		if err != nil {
+			return nil, fmt.Errorf("create provider[%s] failure, nest error: %v", pc.Name, err)
+		}
+		p.providers[pc.Name] = provider
+	}
+	return p, nil
+}
+
+func (p *Providers) Get(name string) (Provider, bool) {
+	provider, ok := p.providers[name]
+	return provider, ok
+}
+
+// Default returns the default provider and the default model of it.
+func (p *Providers) Default() (Provider, string) {
+	return p.providers[p.defaultProvider], p.defaultModel
+}
+
+// ForModel returns the first provider which serves the model, in name order.
+func (p *Providers) ForModel(modelName string) (Provider, bool) {
+	for _, provider := range p.List() {
+		for _, name := range provider.Models() {
+			if name == modelName {
+				return provider, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Resolve picks the provider and the model for a model name, which may be
+// empty for the default model, a bare model name or <provider>/<model>.
+func (p *Providers) Resolve(model string) (Provider, string, error) {
+	if model == "" {
+		provider, modelName := p.Default()
+		return provider, modelName, nil
+	}
+
+	if name, modelName, ok := strings.Cut(model, "/"); ok {
+		if provider, ok := p.Get(name); ok && slices.Contains(provider.Models(), modelName) {
+			return provider, modelName, nil
+		}
+	}
+	if provider, ok := p.ForModel(model); ok {
+		return provider, model, nil
+	}
+	return nil, "", fmt.Errorf("model not found, model: %s", model)
+}
+
+func (p *Providers) List() []Provider {
+	data := make([]Provider, 0, len(p.providers))
+	for _, provider := range p.providers {
+		data = append(data, provider)
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Name() < data[j].Name()
+	})
+	return data
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const referencePrompt = "Answer with the numbered excerpts below when they are relevant and cite every excerpt you use as [n], right after the sentence it supports. If the excerpts do not cover the question, say so instead of guessing."
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// Reference is an excerpt of a document given to the model for one turn, the
+// answer cites it as [Index].
+type Reference struct {
+	Index         int
+	KnowledgeBase string
+	Source        string
+	Heading       string
+	StartLine     int
+	EndLine       int
+	Content       string
+	Score         float32
+}
+
+func (r *Reference) label() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "[%d] %s:%d-%d", r.Index, r.Source, r.StartLine, r.EndLine)
+	if r.Heading != "" {
+		fmt.Fprintf(&buf, " (%s)", r.Heading)
+	}
+	return buf.String()
+}
+
+// WithChatCompletionRequestForReferences puts refs in a system message right
+// before the next message, it is context for this turn only and is not kept in
+// the history.
+func WithChatCompletionRequestForReferences(refs ...*Reference) func(*openai.ChatCompletionRequest) {
+	return func(ccr *openai.ChatCompletionRequest) {
+		if len(refs) == 0 {
+			return
+		}
+
+		var buf strings.Builder
+		buf.WriteString(referencePrompt)
+		for _, ref := range refs {
+			buf.WriteString("\n\n")
+			buf.WriteString(ref.label())
+			buf.WriteString("\n")
+			buf.WriteString(ref.Content)
+		}
+
+		last := len(ccr.Messages) - 1
+		ccr.Messages = slices.Insert(ccr.Messages, last, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: buf.String(),
+		})
+	}
+}
+
+// CitedReferences returns the refs cited by answer in the order they are first
+// cited, citations of unknown indexes are ignored.
+func CitedReferences(answer string, refs []*Reference) []*Reference {
+	byIndex := make(map[int]*Reference, len(refs))
+	for _, ref := range refs {
+		byIndex[ref.Index] = ref
+	}
+
+	data := make([]*Reference, 0, len(refs))
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		ref, ok := byIndex[n]
+		if !ok {
+			continue
+		}
+		delete(byIndex, n)
+		data = append(data, ref)
+	}
+	return data
+}
@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferences(t *testing.T) {
+	_assert := assert.New(t)
+
+	refs := []*Reference{
+		{Index: 1, Source: "ops.md", Heading: "Restart", StartLine: 1, EndLine: 3, Content: "systemctl restart"},
+		{Index: 2, Source: "backup.md", StartLine: 4, EndLine: 9, Content: "nightly cron"},
+	}
+
+	req := &openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "restart?"}},
+	}
+	WithChatCompletionRequestForReferences(refs...)(req)
+	_assert.Equal(2, len(req.Messages))
+	_assert.Equal(openai.ChatMessageRoleSystem, req.Messages[0].Role)
+	_assert.Contains(req.Messages[0].Content, "[1] ops.md:1-3 (Restart)\nsystemctl restart")
+	_assert.Contains(req.Messages[0].Content, "[2] backup.md:4-9\nnightly cron")
+	_assert.Equal("restart?", req.Messages[1].Content)
+
+	cited := CitedReferences("Run systemctl [2][1], see [1] and [7].", refs)
+	_assert.Equal(2, len(cited))
+	_assert.Equal(2, cited[0].Index)
+	_assert.Equal(1, cited[1].Index)
+	_assert.Empty(CitedReferences("no citation", refs))
+}
@@ -0,0 +1,237 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultRetryAttempts       = 3
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	DefaultRetryMaxBackoff     = 10 * time.Second
+	DefaultRetryBudget         = 60
+
+	// maxRetryAfter is the longest Retry-After which is waited out, a longer
+	// one fails the call at once.
+	maxRetryAfter = time.Minute
+)
+
+// RetryConfig is the retry policy of a provider. Budget is how many retries
+// the provider may make in a minute over all its calls, so that an outage is
+// not hammered by every session at once.
+type RetryConfig struct {
+	MaxAttempts    int           `json:"max_attempts" toml:"max_attempts" mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `json:"initial_backoff" toml:"initial_backoff" mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `json:"max_backoff" toml:"max_backoff" mapstructure:"max_backoff"`
+	Budget         int           `json:"budget" toml:"budget" mapstructure:"budget"`
+}
+
+func (c *RetryConfig) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *RetryConfig) VerifyConfig() error {
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("retry.max_attempts has wrong value, max_attempts: %d", c.MaxAttempts)
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < 0 {
+		return fmt.Errorf("retry.initial_backoff/max_backoff has wrong value")
+	}
+	if c.InitialBackoff > 0 && c.MaxBackoff > 0 && c.InitialBackoff > c.MaxBackoff {
+		return fmt.Errorf("retry.initial_backoff is greater than retry.max_backoff")
+	}
+	if c.Budget < 0 {
+		return fmt.Errorf("retry.budget has wrong value, budget: %d", c.Budget)
+	}
+	return nil
+}
+
+// retrier runs the idempotent calls of a provider again on transient failures,
+// with exponential backoff and jitter, or after the Retry-After of the server.
+type retrier struct {
+	sync.Mutex
+
+	name string
+	c    RetryConfig
+
+	window time.Time
+	spent  int
+}
+
+// newRetrier fills the zero fields of c with the defaults, c may be nil.
+func newRetrier(name string, c *RetryConfig) *retrier {
+	r := &retrier{name: name}
+	if c != nil {
+		r.c = *c
+	}
+	if r.c.MaxAttempts == 0 {
+		r.c.MaxAttempts = DefaultRetryAttempts
+	}
+	if r.c.InitialBackoff == 0 {
+		r.c.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if r.c.MaxBackoff == 0 {
+		r.c.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if r.c.Budget == 0 {
+		r.c.Budget = DefaultRetryBudget
+	}
+	return r
+}
+
+func (r *retrier) do(ctx context.Context, op string, fn func(context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		hint := &retryHint{}
+		err := fn(context.WithValue(ctx, retryHintKey{}, hint))
+		if err == nil || attempt >= r.c.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := r.backoff(attempt)
+		if hint.after > maxRetryAfter {
+			return err
+		}
+		if hint.after > 0 {
+			wait = hint.after
+		}
+		if !r.take(time.Now()) {
+			zlog.Warn("Retry budget of provider is exhausted", zap.String("provider", r.name), zap.String("op", op), zap.Error(err), zap.String("sessionId", sessionIdFrom(ctx)))
+			return err
+		}
+		zlog.Warn("Retry provider call", zap.String("provider", r.name), zap.String("op", op), zap.Int("attempt", attempt), zap.Duration("wait", wait), zap.Error(err), zap.String("sessionId", sessionIdFrom(ctx)))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff doubles from InitialBackoff up to MaxBackoff, and picks a random
+// wait in the upper half of it.
+func (r *retrier) backoff(attempt int) time.Duration {
+	d := r.c.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > r.c.MaxBackoff {
+		d = r.c.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (r *retrier) take(now time.Time) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if now.Sub(r.window) >= time.Minute {
+		r.window = now
+		r.spent = 0
+	}
+	if r.spent >= r.c.Budget {
+		return false
+	}
+	r.spent++
+	return true
+}
+
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if strings.Contains(apiErr.Type, "quota") {
+			return false
+		}
+		return isRetryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return isRetryableStatus(reqErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+type retryHintKey struct{}
+
+// retryHint carries the Retry-After of a response back to the retrier.
+type retryHint struct {
+	after time.Duration
+}
+
+// retryAfterTransport reads Retry-After for the retrier, go-openai drops the
+// headers of failed responses.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func newRetryHTTPClient() *http.Client {
+	return &http.Client{Transport: &retryAfterTransport{base: http.DefaultTransport}}
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryHintKey{}).(*retryHint); ok {
+		hint.after = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return resp, nil
+}
+
+// parseRetryAfter accepts both delay seconds and an http date.
+func parseRetryAfter(val string, now time.Time) time.Duration {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(val); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+type sessionIdKey struct{}
+
+func withSessionId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIdKey{}, id)
+}
+
+func sessionIdFrom(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIdKey{}).(string)
+	return id
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryEmbeddings(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	server.Dimension = 2
+	server.EnqueueEmbeddings(llmtest.Error(http.StatusTooManyRequests, "rate limit").WithRetryAfter("0"), llmtest.Error(http.StatusBadGateway, ""))
+
+	e, err := NewOpenAIEmbedder(context.Background(), &EmbeddingConfig{
+		Kind:      KindOpenAI,
+		BaseURL:   server.BaseURL(),
+		Model:     "m",
+		Dimension: 2,
+		Retry:     &RetryConfig{InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond},
+	})
+	_assert.Nil(err)
+
+	data, err := e.Embeddings(context.Background(), []string{"hello"})
+	_assert.Nil(err)
+	_assert.Equal(llmtest.Embed("hello", 2), data[0])
+	_assert.Equal(3, len(server.Inputs()))
+
+	server.EnqueueEmbeddings(llmtest.Error(http.StatusBadRequest, "bad request"))
+	_, err = e.Embeddings(context.Background(), []string{"hello"})
+	_assert.NotNil(err)
+	_assert.Equal(4, len(server.Inputs()))
+}
+
+func TestRetryBudget(t *testing.T) {
+	_assert := assert.New(t)
+
+	r := newRetrier("test", &RetryConfig{Budget: 2})
+	now := time.Now()
+	_assert.True(r.take(now))
+	_assert.True(r.take(now))
+	_assert.False(r.take(now))
+	_assert.True(r.take(now.Add(time.Minute)))
+
+	_assert.Equal(3*time.Second, parseRetryAfter("3", now))
+	_assert.Equal(time.Duration(0), parseRetryAfter("soon", now))
+	_assert.Equal(10*time.Second, parseRetryAfter(now.Add(10*time.Second).UTC().Format(http.TimeFormat), now.Truncate(time.Second)))
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultSchemaRetries is how many times an invalid answer is re-prompted when
+// the schema does not say otherwise.
+const DefaultSchemaRetries = 2
+
+var ErrInvalidOutput = errors.New("structured output does not match the schema")
+
+// Schema is the JSON Schema an answer must match. It is carried by the
+// response_format of a request, see WithChatCompletionRequestForJSONSchema.
+type Schema struct {
+	MaxRetries int
+
+	raw    []byte
+	schema *jsonschema.Schema
+}
+
+// NewSchema compiles doc, maxRetries below 0 means DefaultSchemaRetries.
+func NewSchema(doc string, maxRetries int) (*Schema, error) {
+	val, err := jsonschema.UnmarshalJSON(strings.NewReader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("%w, json_schema is not a json document, nest error: %v", ErrInvalidParams, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", val); err != nil {
+		return nil, fmt.Errorf("%w, add json_schema failure, nest error: %v", ErrInvalidParams, err)
+	}
+	schema, err := c.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w, compile json_schema failure, nest error: %v", ErrInvalidParams, err)
+	}
+
+	if maxRetries < 0 {
+		maxRetries = DefaultSchemaRetries
+	}
+	return &Schema{MaxRetries: maxRetries, raw: []byte(doc), schema: schema}, nil
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return s.raw, nil
+}
+
+// Validate parses the answer, which may be wrapped in a markdown code fence,
+// and returns the compact document when it matches the schema.
+func (s *Schema) Validate(answer string) (string, error) {
+	text := strings.TrimSpace(answer)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+
+	val, err := jsonschema.UnmarshalJSON(strings.NewReader(text))
+	if err != nil {
+		return "", fmt.Errorf("answer is not a json document, nest error: %v", err)
+	}
+	if err := s.schema.Validate(val); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(text)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// schemaOf returns the schema carried by req, or nil.
+func schemaOf(req *openai.ChatCompletionRequest) *Schema {
+	if req.ResponseFormat == nil || req.ResponseFormat.JSONSchema == nil {
+		return nil
+	}
+	schema, _ := req.ResponseFormat.JSONSchema.Schema.(*Schema)
+	return schema
+}
+
+// prepareSchema tells the model about the schema. A model without native
+// json_schema support is asked for json_object with the schema in a system
+// message before the last turn.
+func prepareSchema(req *openai.ChatCompletionRequest, schema *Schema, native bool) {
+	if native {
+		return
+	}
+
+	req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	instruction := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: "Reply with one JSON document only, no prose and no code fence. It must match this JSON Schema:\n" + string(schema.raw),
+	}
+
+	last := len(req.Messages) - 1
+	if last < 0 {
+		last = 0
+	}
+	req.Messages = append(req.Messages[:last], append([]openai.ChatCompletionMessage{instruction}, req.Messages[last:]...)...)
+}
+
+func repairMessages(answer string, err error) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleAssistant, Content: answer},
+		{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf("The JSON above is invalid: %v\nReply with the corrected JSON document only.", err)},
+	}
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+const labelSchema = `{"type":"object","properties":{"label":{"enum":["spam","ham"]}},"required":["label"]}`
+
+func TestStructuredOutput(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	server.Enqueue(
+		llmtest.Tokens(`{"label":`, ` 1}`),
+		llmtest.Tokens("```json\n", `{"label": "spam"}`, "\n```"),
+		llmtest.Tokens(`{"label":"eggs"}`),
+	)
+
+	schema, err := NewSchema(labelSchema, -1)
+	_assert.Nil(err)
+	_assert.Equal(DefaultSchemaRetries, schema.MaxRetries)
+
+	client := NewKimiClient(server.BaseURL(), "mock")
+	session, err := client.NewSession("moonshot-v1-8k")
+	_assert.Nil(err)
+
+	stream, err := session.StartChat(context.Background(), openai.ChatMessageRoleUser, "classify: win money now", WithChatCompletionRequestForJSONSchema(schema))
+	_assert.Nil(err)
+
+	var events []*Event
+	for event := range stream.Events() {
+		events = append(events, event)
+	}
+	_assert.Nil(stream.Err())
+	_assert.Equal(1, len(events))
+	_assert.Equal(`{"label":"spam"}`, events[0].JSON)
+
+	requests := server.Requests()
+	_assert.Equal(2, len(requests))
+	_assert.Equal(openai.ChatCompletionResponseFormatTypeJSONObject, requests[0].ResponseFormat.Type)
+	_assert.Contains(requests[0].Messages[0].Content, labelSchema)
+	repair := requests[1].Messages[len(requests[1].Messages)-1]
+	_assert.Contains(repair.Content, "The JSON above is invalid")
+
+	schema, err = NewSchema(labelSchema, 0)
+	_assert.Nil(err)
+	stream, err = session.Send(context.Background(), openai.ChatMessageRoleUser, "classify: hello", WithChatCompletionRequestForJSONSchema(schema))
+	_assert.Nil(err)
+	for range stream.Events() {
+	}
+	_assert.ErrorIs(stream.Err(), ErrInvalidOutput)
+
+	_, err = NewSchema(`{"type": 1}`, 0)
+	_assert.ErrorIs(err, ErrInvalidParams)
+}
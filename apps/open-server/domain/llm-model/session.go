@@ -0,0 +1,572 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// ChatSession is the Session shared by every OpenAI compatible provider.
+type ChatSession struct {
+	sync.RWMutex
+
+	Id        string
+	ModelName string
+
+	provider      Provider
+	embedder      Embedder
+	store         vectorstore.VectorStore
+	retriever     *retrieval.Retriever
+	responseCache ResponseCache
+	tools         ToolExecutor
+	alreadyStart  bool
+	persisted     bool
+	num           uint64
+	history       history
+}
+
+func (s *ChatSession) GetId() string {
+	return s.Id
+}
+
+func (s *ChatSession) GetModelName() string {
+	return s.ModelName
+}
+
+func (s *ChatSession) getNum() uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	s.num = s.num + 1
+	return s.num
+}
+
+func (s *ChatSession) isAlreadyStart() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.alreadyStart
+}
+
+func (s *ChatSession) setAlreadyStart() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.alreadyStart = true
+}
+
+// memoryEnabled reports whether the session is able to cache and search the
+// contents, it needs both an embedder and a vector store.
+func (s *ChatSession) memoryEnabled() bool {
+	return s.embedder != nil && s.store != nil
+}
+
+func (s *ChatSession) embeddings(content string) ([]float32, error) {
+	data, err := s.embedder.Embeddings(withSessionId(context.Background(), s.Id), []string{content})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		return data[0], nil
+	}
+	return nil, fmt.Errorf("panic: no embeddings result")
+}
+
+func (s *ChatSession) cache(id uint64, content string) error {
+	if !s.memoryEnabled() {
+		return nil
+	}
+
+	vec, err := s.embeddings(content)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Upsert(context.Background(), s.Id, &vectorstore.Point{
+		Id:      id,
+		Vector:  vec,
+		Payload: vectorstore.Payload{"content": content},
+	})
+}
+
+func (s *ChatSession) search(content string) ([]string, error) {
+	if !s.memoryEnabled() {
+		return nil, nil
+	}
+
+	vec, err := s.embeddings(content)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := s.retriever.Search(context.Background(), &retrieval.Query{Collection: s.Id, Text: content, Vector: vec})
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		data = append(data, hit.Content())
+	}
+	return data, nil
+}
+
+func (s *ChatSession) StartChat(ctx context.Context, role string, content string, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error) {
+	if s.isAlreadyStart() {
+		return nil, fmt.Errorf("session'chat already start")
+	}
+	ctx = withSessionId(ctx, s.Id)
+
+	req, next, err := s.newRequest(role, content, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.memoryEnabled() {
+		if err := s.store.CreateCollection(context.Background(), s.Id, s.embedder.Dimension()); err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := s.chat(ctx, req, next, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setAlreadyStart()
+	return stream, nil
+}
+
+func (s *ChatSession) Send(ctx context.Context, role string, content string, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error) {
+	if !s.isAlreadyStart() {
+		return nil, fmt.Errorf("session'chat not start")
+	}
+	return s.send(ctx, role, content, false, opts...)
+}
+
+// send asks content with the memories relevant to it. A regenerated turn is
+// in the memory already, it is neither kept again nor taken as relevant to
+// itself.
+func (s *ChatSession) send(ctx context.Context, role string, content string, regenerate bool, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error) {
+	ctx = withSessionId(ctx, s.Id)
+
+	req, next, err := s.newRequest(role, content, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.compact(ctx, s.promptBudget(&req), countMessageTokens(req.Messages...)+countMessageTokens(next))
+
+	relevant, err := s.search(content)
+	if err != nil {
+		return nil, fmt.Errorf("search history content failure, nest error: %v", err)
+	}
+	if regenerate {
+		question := turnOf(next).Content
+		relevant = slices.DeleteFunc(relevant, func(text string) bool { return text == question })
+	}
+	return s.chat(ctx, req, next, relevant, !regenerate)
+}
+
+// Regenerate asks the last user turn again without the response cache, the
+// turn and its answer are put back into the history when the request fails.
+func (s *ChatSession) Regenerate(ctx context.Context, opts ...func(*openai.ChatCompletionRequest)) (*Stream, error) {
+	if !s.isAlreadyStart() {
+		return nil, fmt.Errorf("session'chat not start")
+	}
+
+	dropped, ok := s.history.rewind()
+	if !ok {
+		return nil, fmt.Errorf("%w, no turn to regenerate", ErrInvalidParams)
+	}
+	stream, err := s.send(WithoutResponseCache(ctx), dropped[0].Role, dropped[0].Content, true, opts...)
+	if err != nil {
+		s.history.add(dropped...)
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (s *ChatSession) SetSystemPrompt(prompt string) {
+	s.history.setSystem(prompt)
+}
+
+// newRequest applies opts to a request for the model of the session, which
+// holds only the next message meanwhile, and validates the result. next is the
+// message to send, the messages opts put before it are left in the request as
+// context for this turn only, they never go into the history.
+func (s *ChatSession) newRequest(role string, content string, opts ...func(*openai.ChatCompletionRequest)) (openai.ChatCompletionRequest, openai.ChatCompletionMessage, error) {
+	req := openai.ChatCompletionRequest{
+		Model:  s.ModelName,
+		Stream: true,
+
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    role,
+				Content: content,
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&req)
+	}
+	next := req.Messages[len(req.Messages)-1]
+	req.Messages = req.Messages[:len(req.Messages)-1]
+
+	if !slices.Contains(s.provider.Models(), req.Model) {
+		return req, next, fmt.Errorf("%w, model is not served by provider[%s], model: %s", ErrInvalidParams, s.provider.Name(), req.Model)
+	}
+	limits := limitsFor(s.provider, req.Model)
+	if hasImage(next) && !limits.Vision {
+		return req, next, fmt.Errorf("%w, model does not accept images, model: %s", ErrInvalidParams, req.Model)
+	}
+	if err := validateRequest(&req, limits); err != nil {
+		return req, next, err
+	}
+	return req, next, nil
+}
+
+// promptBudget is the prompt budget of req within the context window of its
+// model, as the provider knows it.
+func (s *ChatSession) promptBudget(req *openai.ChatCompletionRequest) int {
+	return windowBudget(limitsFor(s.provider, req.Model).ContextWindow, req.MaxTokens)
+}
+
+// chat sends the history along with the next message, its text is kept in the
+// history, and in the memory when remember is set, once the request is
+// accepted.
+func (s *ChatSession) chat(ctx context.Context, req openai.ChatCompletionRequest, next openai.ChatCompletionMessage, relevant []string, remember bool) (*Stream, error) {
+	turn := turnOf(next)
+	key := s.cacheKey(ctx, &req, next)
+
+	var stream *Stream
+	if answer, ok := s.hit(ctx, key, turn.Content); ok {
+		zlog.Info("Response cache hit", zap.String("tenant", key.Tenant), zap.String("sessionId", s.Id))
+		stream = s.replay(ctx, turn, answer)
+	} else {
+		req.Messages = s.history.build(s.promptBudget(&req), relevant, req.Messages, next)
+
+		var err error
+		stream, err = s.sendRequest(ctx, req, turn, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !remember {
+		return stream, nil
+	}
+	if err := s.cache(s.getNum(), turn.Content); err != nil {
+		zlog.Error("Cache content failure", zap.Error(err), zap.String("content", turn.Content), zap.String("sessionId", s.Id))
+	}
+	return stream, nil
+}
+
+// compact folds the oldest turns into the running summary when the history
+// and the next turn take more than three quarters of budget, the rest is left
+// for the retrieved memories.
+func (s *ChatSession) compact(ctx context.Context, budget int, extra int) {
+	s.history.compactMu.Lock()
+	defer s.history.compactMu.Unlock()
+
+	n := s.history.overflow(budget*3/4 - extra)
+	if n == 0 {
+		return
+	}
+
+	summary, turns := s.history.head(n)
+	text, err := s.complete(ctx, summaryRequest(summary, turns))
+	if err != nil {
+		zlog.Error("Summarize history failure, drop the oldest turns", zap.Error(err), zap.Int("turns", n), zap.String("sessionId", s.Id))
+		text = summary
+	}
+	s.history.compacted(n, text)
+	zlog.Info("Session history compacted", zap.Int("turns", n), zap.String("sessionId", s.Id))
+}
+
+// complete runs a request without tools and returns the whole reply.
+func (s *ChatSession) complete(ctx context.Context, messages []openai.ChatCompletionMessage) (string, error) {
+	resp, err := s.provider.ChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:    s.ModelName,
+		Stream:   true,
+		Messages: messages,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Close()
+
+	var buf strings.Builder
+	for {
+		stream, err := resp.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(stream.Choices) > 0 {
+			buf.WriteString(stream.Choices[0].Delta.Content)
+		}
+	}
+	if buf.Len() == 0 {
+		return "", fmt.Errorf("panic: no completion result")
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// sendRequest streams the reply of req, next and the reply are appended to the
+// history before the stream ends. The stream stops as soon as ctx is done or
+// the consumer closes it. A complete reply without tool calls is put into the
+// response cache under key unless key is nil.
+func (s *ChatSession) sendRequest(ctx context.Context, req openai.ChatCompletionRequest, next Turn, key *CacheKey) (*Stream, error) {
+	limits := limitsFor(s.provider, req.Model)
+	if s.tools != nil && limits.Tools {
+		req.Tools = s.tools.Definitions()
+	}
+	schema := schemaOf(&req)
+	if schema != nil {
+		prepareSchema(&req, schema, limits.JSONSchema)
+	}
+	// the usage comes in a last chunk without choices
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	ctx, cancel := context.WithCancel(ctx)
+	resp, err := s.provider.ChatCompletionStream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	stream := newStream(cancel)
+	go func() {
+		var reply strings.Builder
+
+		err := s.loop(ctx, req, resp, stream, &reply, schema)
+		if err != nil {
+			zlog.Error("Stream chat failure", zap.Error(err), zap.String("sessionId", s.Id))
+		}
+
+		if reply.Len() == 0 {
+			s.history.add(next)
+		} else {
+			s.history.add(next, Turn{Role: openai.ChatMessageRoleAssistant, Content: reply.String()})
+		}
+		stream.finish(err)
+
+		if key != nil && err == nil && !stream.toolUsed && stream.finishReason == openai.FinishReasonStop && reply.Len() != 0 {
+			s.fill(ctx, key, next.Content, reply.String())
+		}
+	}()
+
+	return stream, nil
+}
+
+// loop receives resp and runs the tool calls the model asks for, until the
+// model answers without them. With a schema the answer is buffered and
+// re-prompted with the validation error until it matches.
+func (s *ChatSession) loop(ctx context.Context, req openai.ChatCompletionRequest, resp *openai.ChatCompletionStream, stream *Stream, reply *strings.Builder, schema *Schema) error {
+	var rounds, repairs int
+	for {
+		answer := reply
+		if schema != nil {
+			answer = &strings.Builder{}
+		}
+		calls, err := s.recv(ctx, resp, stream, answer, schema == nil)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(calls) != 0 && s.tools != nil:
+			if rounds++; rounds > maxToolRounds {
+				return fmt.Errorf("tool rounds exceed limit, limit: %d", maxToolRounds)
+			}
+
+			stream.toolUsed = true
+			req.Messages = append(req.Messages, openai.ChatCompletionMessage{
+				Role:      openai.ChatMessageRoleAssistant,
+				ToolCalls: calls,
+			})
+			for _, call := range calls {
+				message, err := s.callTool(ctx, call, stream)
+				if err != nil {
+					return err
+				}
+				req.Messages = append(req.Messages, message)
+			}
+
+		case schema == nil:
+			return nil
+
+		default:
+			doc, verr := schema.Validate(answer.String())
+			if verr == nil {
+				reply.WriteString(doc)
+				return stream.send(ctx, &Event{JSON: doc})
+			}
+			if repairs >= schema.MaxRetries {
+				return fmt.Errorf("%w, nest error: %v", ErrInvalidOutput, verr)
+			}
+			repairs++
+			zlog.Warn("Structured output is invalid, re-prompt", zap.Int("retry", repairs), zap.Error(verr), zap.String("sessionId", s.Id))
+
+			req.Messages = append(req.Messages, repairMessages(answer.String(), verr)...)
+		}
+
+		resp, err = s.provider.ChatCompletionStream(ctx, req)
+		if err != nil {
+			return fmt.Errorf("create chat completion stream failure, nest error: %w", err)
+		}
+	}
+}
+
+// recv writes the content deltas of resp into reply, forwards them into stream
+// unless buffered, and returns the tool calls which the model asked for.
+func (s *ChatSession) recv(ctx context.Context, resp *openai.ChatCompletionStream, stream *Stream, reply *strings.Builder, forward bool) ([]openai.ToolCall, error) {
+	defer resp.Close()
+
+	var assembler toolCallAssembler
+	for {
+		data, err := resp.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("recv failure, nest error: %w", err)
+		}
+		if data.Usage != nil {
+			stream.addUsage(data.Usage)
+		}
+
+		// only the first choice is kept in the history and may call tools,
+		// the others are forwarded as they are when n > 1.
+		for _, choice := range data.Choices {
+			if choice.Index == 0 && choice.FinishReason != "" {
+				stream.finishReason = choice.FinishReason
+			}
+			if choice.Delta.Content != "" {
+				if choice.Index == 0 {
+					reply.WriteString(choice.Delta.Content)
+				}
+				if forward {
+					if err := stream.send(ctx, &Event{Index: choice.Index, Content: choice.Delta.Content}); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if choice.Index == 0 && len(choice.Delta.ToolCalls) != 0 {
+				assembler.add(choice.Delta.ToolCalls)
+			}
+		}
+	}
+	return assembler.result(), nil
+}
+
+func (s *ChatSession) callTool(ctx context.Context, call openai.ToolCall, stream *Stream) (openai.ChatCompletionMessage, error) {
+	if err := stream.send(ctx, &Event{ToolCall: &ToolCall{Id: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments}}); err != nil {
+		return openai.ChatCompletionMessage{}, err
+	}
+
+	result := &ToolResult{Id: call.ID, Name: call.Function.Name}
+	content, err := s.tools.Call(ctx, call.Function.Name, call.Function.Arguments)
+	if err != nil {
+		zlog.Error("Call tool failure", zap.Error(err), zap.String("tool", call.Function.Name), zap.String("sessionId", s.Id))
+		result.Content = fmt.Sprintf("call tool failure, nest error: %v", err)
+		result.IsError = true
+	} else {
+		result.Content = content
+	}
+	if err := stream.send(ctx, &Event{ToolResult: result}); err != nil {
+		return openai.ChatCompletionMessage{}, err
+	}
+
+	return openai.ChatCompletionMessage{
+		Role:       openai.ChatMessageRoleTool,
+		Content:    result.Content,
+		ToolCallID: call.ID,
+	}, nil
+}
+
+// Persist keeps the memory of the session after it is closed, so that it can
+// be restored later by id.
+func (s *ChatSession) Persist() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.persisted = true
+}
+
+func (s *ChatSession) State() *State {
+	s.RLock()
+	defer s.RUnlock()
+
+	state := &State{
+		Id:        s.Id,
+		Provider:  s.provider.Name(),
+		ModelName: s.ModelName,
+		Num:       s.num,
+		Persisted: s.persisted,
+	}
+	s.history.save(state)
+	return state
+}
+
+// Restore brings back a persisted session, its memory collection is created
+// again when the vector store has lost it.
+func (s *ChatSession) Restore(ctx context.Context, state *State) error {
+	if s.isAlreadyStart() {
+		return fmt.Errorf("session'chat already start")
+	}
+
+	if s.memoryEnabled() {
+		exist, err := s.store.CollectionExists(ctx, state.Id)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			zlog.Warn("Session memory is lost, restore with an empty one", zap.String("sessionId", state.Id))
+			if err := s.store.CreateCollection(ctx, state.Id, s.embedder.Dimension()); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.history.restore(state)
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.Id = state.Id
+	s.ModelName = state.ModelName
+	s.num = state.Num
+	s.persisted = true
+	s.alreadyStart = true
+	return nil
+}
+
+// Close drops the memory of the session unless it is persisted.
+func (s *ChatSession) Close() error {
+	s.RLock()
+	drop := s.alreadyStart && !s.persisted && s.memoryEnabled()
+	s.RUnlock()
+
+	if !drop {
+		return nil
+	}
+	return s.store.DeleteCollection(context.Background(), s.Id)
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/snowflake"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// sessionIdPattern matches the ids made by NewSession, such as Kimi-<snowflake>.
+var sessionIdPattern = regexp.MustCompile(`^[A-Za-z]+-[0-9]{19}$`)
+
+// IsSessionId reports whether name is the id of a session, memory collections
+// of sessions are named by their ids.
+func IsSessionId(name string) bool {
+	return sessionIdPattern.MatchString(name)
+}
+
+// SessionCreatedAt returns when the session named by id was made, ok is false
+// when id is not the id of a session.
+func SessionCreatedAt(id string) (time.Time, bool) {
+	if !IsSessionId(id) {
+		return time.Time{}, false
+	}
+	t, err := snowflake.ParseTime(id[strings.LastIndexByte(id, '-')+1:])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// State is what it takes to restore a session, its memory stays in the vector
+// store under the collection named by Id.
+type State struct {
+	Id           string `json:"id"`
+	Provider     string `json:"provider"`
+	ModelName    string `json:"model_name"`
+	Num          uint64 `json:"num"`
+	Persisted    bool   `json:"persisted"`
+	LastActiveAt int64  `json:"last_active_at"`
+	Pinned       []Turn `json:"pinned,omitempty"`
+	Turns        []Turn `json:"turns,omitempty"`
+	Summary      string `json:"summary,omitempty"`
+}
+
+func (s *State) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(s)
+	return string(buf)
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Stream is the streamed answer of one turn. Events is closed when the answer
+// ends, then Err tells a truncated answer from a complete one and FinishReason
+// tells why the model stopped.
+type Stream struct {
+	events chan *Event
+	cancel context.CancelFunc
+
+	err          error
+	finishReason openai.FinishReason
+	usage        openai.Usage
+	cached       bool
+	toolUsed     bool
+}
+
+func newStream(cancel context.CancelFunc) *Stream {
+	return &Stream{
+		events: make(chan *Event, 64),
+		cancel: cancel,
+	}
+}
+
+func (s *Stream) Events() <-chan *Event {
+	return s.events
+}
+
+// Err returns the error which ended the stream, it must be called after Events
+// is closed.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// FinishReason returns the finish reason of the last completion, it must be
+// called after Events is closed.
+func (s *Stream) FinishReason() openai.FinishReason {
+	return s.finishReason
+}
+
+// Usage returns the tokens spent on the answer, summed over the completions of
+// its tool rounds and repairs. It is zero for a cached answer and for a
+// provider which does not report usage, it must be called after Events is
+// closed.
+func (s *Stream) Usage() openai.Usage {
+	return s.usage
+}
+
+func (s *Stream) addUsage(usage *openai.Usage) {
+	s.usage.PromptTokens += usage.PromptTokens
+	s.usage.CompletionTokens += usage.CompletionTokens
+	s.usage.TotalTokens += usage.TotalTokens
+}
+
+// Cached reports whether the answer is replayed from the response cache.
+func (s *Stream) Cached() bool {
+	return s.cached
+}
+
+// Close stops the stream and waits until it ends, it is safe to call at any
+// time and more than once.
+func (s *Stream) Close() {
+	s.cancel()
+	for range s.events {
+	}
+}
+
+// send delivers event unless ctx is done first, so that an abandoned stream
+// never blocks the producer.
+func (s *Stream) send(ctx context.Context, event *Event) error {
+	select {
+	case s.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Stream) finish(err error) {
+	s.err = err
+	s.cancel()
+	close(s.events)
+}
@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamCancel(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	server.Enqueue(llmtest.Tokens("hi").WithHang(), llmtest.Tokens("again").WithHang())
+
+	client := NewKimiClient(server.BaseURL(), "mock")
+
+	session, err := client.NewSession("moonshot-v1-8k")
+	_assert.Nil(err)
+	stream, err := session.StartChat(context.Background(), openai.ChatMessageRoleUser, "hello")
+	_assert.Nil(err)
+
+	event := <-stream.Events()
+	_assert.Equal("hi", event.Content)
+
+	done := make(chan struct{})
+	go func() {
+		stream.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("stream is not closed")
+	}
+	_assert.ErrorIs(stream.Err(), context.Canceled)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stream, err = session.Send(ctx, openai.ChatMessageRoleUser, "again")
+	_assert.Nil(err)
+	for range stream.Events() {
+	}
+	_assert.ErrorIs(stream.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eviltomorrow/open-terminal/lib/textutil"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	DefaultContextWindow = 8192
+
+	// maxReplyTokens caps the part of the context window kept for the reply.
+	maxReplyTokens = 4096
+	// messageOverheadTokens is what the chat template costs for each message.
+	messageOverheadTokens = 4
+)
+
+var contextWindows = map[string]int{
+	"moonshot-v1-8k":    8192,
+	"moonshot-v1-32k":   32768,
+	"moonshot-v1-128k":  131072,
+	"gpt-4o":            128000,
+	"gpt-4o-mini":       128000,
+	"gpt-4-turbo":       128000,
+	"gpt-3.5-turbo":     16385,
+	"deepseek-chat":     65536,
+	"deepseek-reasoner": 65536,
+}
+
+var windowSuffix = regexp.MustCompile(`(?i)-(\d+)k(-vision-preview)?$`)
+
+// ContextWindow returns the context window in tokens of the model, a model
+// that is not known falls back to the size in its name, such as xxx-32k.
+func ContextWindow(modelName string) int {
+	if n, ok := contextWindows[modelName]; ok {
+		return n
+	}
+	if m := windowSuffix.FindStringSubmatch(modelName); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			return n * 1024
+		}
+	}
+	return DefaultContextWindow
+}
+
+// PromptBudget returns how many tokens the prompt of the model may take, the
+// rest of the context window is kept for the reply.
+func PromptBudget(modelName string) int {
+	return promptBudget(modelName, 0)
+}
+
+// promptBudget keeps maxTokens for the reply when it is set.
+func promptBudget(modelName string, maxTokens int) int {
+	return windowBudget(ContextWindow(modelName), maxTokens)
+}
+
+// windowBudget is promptBudget for a context window of window tokens.
+func windowBudget(window int, maxTokens int) int {
+	if maxTokens > 0 {
+		return max(window-maxTokens, 0)
+	}
+	return window - min(window/4, maxReplyTokens)
+}
+
+// CountTokens estimates the tokens of text without the tokenizer of the model,
+// a CJK character is about one token and other text about four bytes a token.
+// It errs on the high side, so that a prompt under budget fits for real.
+func CountTokens(text string) int {
+	var (
+		tokens int
+		bytes  int
+	)
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		switch {
+		case textutil.IsCJK(r):
+			tokens++
+		case unicode.IsSpace(r):
+			tokens += (bytes + 3) / 4
+			bytes = 0
+		default:
+			bytes += size
+		}
+	}
+	return tokens + (bytes+3)/4
+}
+
+func countMessageTokens(messages ...openai.ChatCompletionMessage) int {
+	var tokens int
+	for _, message := range messages {
+		tokens += messageOverheadTokens + CountTokens(message.Content)
+		for _, part := range message.MultiContent {
+			if part.Type == openai.ChatMessagePartTypeImageURL {
+				tokens += imageTokens
+				continue
+			}
+			tokens += CountTokens(part.Text)
+		}
+	}
+	return tokens
+}
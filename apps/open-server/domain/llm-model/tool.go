@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxToolRounds limits how many times the model may call tools in one turn.
+const maxToolRounds = 8
+
+// ToolExecutor offers tool definitions to the model and runs the calls it makes.
+type ToolExecutor interface {
+	Definitions() []openai.Tool
+	Call(ctx context.Context, name string, arguments string) (string, error)
+}
+
+type ToolCall struct {
+	Id        string
+	Name      string
+	Arguments string
+}
+
+type ToolResult struct {
+	Id      string
+	Name    string
+	Content string
+	IsError bool
+}
+
+// Event is one item of a streamed answer, only one of the fields is set besides
+// Index, the choice the content belongs to. JSON is the validated document of
+// a structured answer, whose deltas are not streamed.
+type Event struct {
+	Index      int
+	Content    string
+	JSON       string
+	ToolCall   *ToolCall
+	ToolResult *ToolResult
+}
+
+// toolCallAssembler joins streamed tool call deltas by their index.
+type toolCallAssembler struct {
+	calls map[int]*openai.ToolCall
+}
+
+func (a *toolCallAssembler) add(deltas []openai.ToolCall) {
+	if a.calls == nil {
+		a.calls = make(map[int]*openai.ToolCall, len(deltas))
+	}
+
+	for i, delta := range deltas {
+		index := i
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+
+		call, ok := a.calls[index]
+		if !ok {
+			call = &openai.ToolCall{Type: openai.ToolTypeFunction}
+			a.calls[index] = call
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+func (a *toolCallAssembler) result() []openai.ToolCall {
+	indexes := make([]int, 0, len(a.calls))
+	for index := range a.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	data := make([]openai.ToolCall, 0, len(indexes))
+	for _, index := range indexes {
+		data = append(data, *a.calls[index])
+	}
+	return data
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoTool struct{}
+
+func (echoTool) Definitions() []openai.Tool {
+	return []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "echo"}}}
+}
+
+func (echoTool) Call(ctx context.Context, name string, arguments string) (string, error) {
+	if name != "echo" {
+		return "", fmt.Errorf("tool not found, name: %s", name)
+	}
+	return arguments, nil
+}
+
+func TestToolCalling(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	server.Enqueue(
+		llmtest.ToolCall("call-1", "echo", `{"text":"hi"}`),
+		llmtest.ToolCall("call-2", "missing", `{}`),
+		llmtest.Tokens("done"),
+	)
+
+	client := NewKimiClient(server.BaseURL(), "mock")
+	session, err := client.NewSession("moonshot-v1-8k", WithSessionForTools(echoTool{}))
+	_assert.Nil(err)
+
+	stream, err := session.StartChat(context.Background(), openai.ChatMessageRoleUser, "call echo")
+	_assert.Nil(err)
+
+	var events []*Event
+	for event := range stream.Events() {
+		events = append(events, event)
+	}
+	_assert.Nil(stream.Err())
+	_assert.Equal(5, len(events))
+	_assert.Equal("echo", events[0].ToolCall.Name)
+	_assert.Equal(`{"text":"hi"}`, events[1].ToolResult.Content)
+	_assert.True(events[3].ToolResult.IsError)
+	_assert.Equal("done", events[4].Content)
+
+	requests := server.Requests()
+	_assert.Equal(3, len(requests))
+	_assert.Equal("echo", requests[0].Tools[0].Function.Name)
+	last := requests[2].Messages
+	_assert.Equal(openai.ChatMessageRoleTool, last[len(last)-1].Role)
+	_assert.Equal("call-2", last[len(last)-1].ToolCallID)
+}
+
+func TestStreamError(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	server.Enqueue(llmtest.Error(400, "bad request"))
+
+	client := NewKimiClient(server.BaseURL(), "mock")
+	session, err := client.NewSession("moonshot-v1-8k")
+	_assert.Nil(err)
+
+	_, err = session.StartChat(context.Background(), openai.ChatMessageRoleUser, "hello")
+	_assert.NotNil(err)
+	_assert.Contains(err.Error(), "bad request")
+}
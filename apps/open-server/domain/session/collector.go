@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/timeutil"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+)
+
+// Collector drops the memory left behind by sessions, a collection is dropped
+// when its session is not alive and either was never persisted or has not been
+// active for longer than ttl. A collection younger than ttl is kept whatever, it
+// may belong to a chat which is not registered, such as a one-shot CreateChat.
+type Collector struct {
+	store    vectorstore.VectorStore
+	states   *StateStore
+	registry *Registry
+	ttl      time.Duration
+
+	ticker *timeutil.AlignedTicker
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewCollector(store vectorstore.VectorStore, states *StateStore, registry *Registry, ttl, interval time.Duration) *Collector {
+	c := &Collector{
+		store:    store,
+		states:   states,
+		registry: registry,
+		ttl:      ttl,
+		ticker:   timeutil.NewAlignedTicker(time.Now(), interval, 0, 0),
+		done:     make(chan struct{}),
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run()
+	}()
+	return c
+}
+
+func (c *Collector) run() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-c.ticker.Elapsed():
+			if err := c.collect(context.Background(), now); err != nil {
+				zlog.Error("Collect session memory failure", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (c *Collector) collect(ctx context.Context, now time.Time) error {
+	names, err := c.store.ListCollections(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if !llm.IsSessionId(name) {
+			continue
+		}
+		if c.registry.Has(name) {
+			continue
+		}
+		if createdAt, ok := llm.SessionCreatedAt(name); ok && now.Sub(createdAt) <= c.ttl {
+			continue
+		}
+
+		state, err := c.states.Load(name)
+		switch {
+		case err == ErrStateNotFound:
+		case err != nil:
+			zlog.Error("Load session state failure", zap.Error(err), zap.String("sessionId", name))
+			continue
+		case now.Sub(time.Unix(state.LastActiveAt, 0)) <= c.ttl:
+			continue
+		}
+
+		if err := c.store.DeleteCollection(ctx, name); err != nil {
+			zlog.Error("Delete session memory failure", zap.Error(err), zap.String("sessionId", name))
+			continue
+		}
+		if err := c.states.Delete(name); err != nil {
+			zlog.Error("Delete session state failure", zap.Error(err), zap.String("sessionId", name))
+		}
+		zlog.Info("Session memory collected", zap.String("sessionId", name))
+	}
+
+	ids, err := c.states.List()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if c.registry.Has(id) {
+			continue
+		}
+		state, err := c.states.Load(id)
+		if err != nil || now.Sub(time.Unix(state.LastActiveAt, 0)) <= c.ttl {
+			continue
+		}
+		if err := c.states.Delete(id); err != nil {
+			zlog.Error("Delete session state failure", zap.Error(err), zap.String("sessionId", id))
+		}
+	}
+	return nil
+}
+
+func (c *Collector) Stop() error {
+	close(c.done)
+	c.wg.Wait()
+	c.ticker.Stop()
+	return nil
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/llmtest"
+	"github.com/eviltomorrow/open-terminal/lib/retrieval"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorCollect(t *testing.T) {
+	_assert := assert.New(t)
+
+	states, err := NewStateStore(t.TempDir())
+	_assert.Nil(err)
+	r := NewRegistry(time.Minute, time.Hour, states)
+	defer r.Stop()
+
+	ctx := context.Background()
+	store := vectorstore.NewMemory()
+	for _, name := range []string{"Kimi-1000000000000000001", "Kimi-1000000000000000002", "Kimi-1000000000000000003", "knowledge"} {
+		_assert.Nil(store.CreateCollection(ctx, name, 4))
+	}
+
+	now := time.Now()
+	_assert.Nil(states.Save(&llm.State{Id: "Kimi-1000000000000000002", Persisted: true, LastActiveAt: now.Add(-time.Hour).Unix()}))
+	_assert.Nil(states.Save(&llm.State{Id: "Kimi-1000000000000000003", Persisted: true, LastActiveAt: now.Add(-48 * time.Hour).Unix()}))
+
+	c := NewCollector(store, states, r, 24*time.Hour, time.Hour)
+	defer c.Stop()
+	_assert.Nil(c.collect(ctx, now))
+
+	names, err := store.ListCollections(ctx)
+	_assert.Nil(err)
+	_assert.ElementsMatch([]string{"Kimi-1000000000000000002", "knowledge"}, names)
+
+	ids, err := states.List()
+	_assert.Nil(err)
+	_assert.Equal([]string{"Kimi-1000000000000000002"}, ids)
+
+	state, err := states.Load("Kimi-1000000000000000002")
+	_assert.Nil(err)
+	_assert.True(state.Persisted)
+	_, err = states.Load("Kimi-1000000000000000003")
+	_assert.Equal(ErrStateNotFound, err)
+}
+
+func TestCollectorInFlight(t *testing.T) {
+	_assert := assert.New(t)
+
+	server := llmtest.NewServer()
+	defer server.Close()
+	server.Enqueue(llmtest.Tokens("he").WithHang(), llmtest.Tokens("he").WithHang())
+
+	ctx := context.Background()
+	embedder := llm.NewLocalEmbedder(0)
+	retriever, err := retrieval.NewRetriever(vectorstore.NewMemory(), nil)
+	_assert.Nil(err)
+	store := retriever.Store()
+	client := llm.NewKimiClient(server.BaseURL(), "mock")
+	newSession := func() llm.Session {
+		s, err := client.NewSession("moonshot-v1-8k",
+			llm.WithSessionForEmbedder(embedder),
+			llm.WithSessionForVectorStore(store),
+			llm.WithSessionForRetriever(retriever),
+		)
+		_assert.Nil(err)
+		return s
+	}
+
+	states, err := NewStateStore(t.TempDir())
+	_assert.Nil(err)
+	r := NewRegistry(time.Minute, time.Hour, states)
+	defer r.Stop()
+	c := NewCollector(store, states, r, 24*time.Hour, time.Hour)
+	defer c.Stop()
+
+	// registered before its memory is made, as CreateSession does, and a
+	// one-shot chat which is never registered
+	registered, oneShot := newSession(), newSession()
+	r.Add(registered)
+	for _, s := range []llm.Session{registered, oneShot} {
+		st, err := s.StartChat(ctx, openai.ChatMessageRoleUser, "hi")
+		_assert.Nil(err)
+		defer st.Close()
+		<-st.Events()
+	}
+
+	_assert.Nil(c.collect(ctx, time.Now()))
+	names, err := store.ListCollections(ctx)
+	_assert.Nil(err)
+	_assert.ElementsMatch([]string{registered.GetId(), oneShot.GetId()}, names)
+
+	// only its age kept the memory of the one-shot chat
+	_assert.Nil(c.collect(ctx, time.Now().Add(48*time.Hour)))
+	names, err = store.ListCollections(ctx)
+	_assert.Nil(err)
+	_assert.Equal([]string{registered.GetId()}, names)
+}
@@ -0,0 +1,237 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/eviltomorrow/open-terminal/lib/timeutil"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+)
+
+type Info struct {
+	Id           string
+	Provider     string
+	ModelName    string
+	CreatedAt    time.Time
+	LastActiveAt time.Time
+	Persisted    bool
+}
+
+type entry struct {
+	session      llm.Session
+	createdAt    time.Time
+	lastActiveAt time.Time
+	// streams counts the answers of the session in flight
+	streams int
+}
+
+// Registry keeps the sessions that are still alive on the server side, a session
+// which is idle longer than idleTimeout will be closed and dropped, a session
+// is not idle while an answer of it streams. The state of a persisted session
+// is saved to states when it is dropped.
+type Registry struct {
+	sync.RWMutex
+
+	idleTimeout time.Duration
+	states      *StateStore
+	sessions    map[string]*entry
+	ticker      *timeutil.AlignedTicker
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+func NewRegistry(idleTimeout, checkInterval time.Duration, states *StateStore) *Registry {
+	r := &Registry{
+		idleTimeout: idleTimeout,
+		states:      states,
+		sessions:    make(map[string]*entry, 32),
+		ticker:      timeutil.NewAlignedTicker(time.Now(), checkInterval, 0, 0),
+		done:        make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run()
+	}()
+	return r
+}
+
+func (r *Registry) run() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-r.ticker.Elapsed():
+			r.expire(now)
+		}
+	}
+}
+
+func (r *Registry) expire(now time.Time) {
+	r.Lock()
+	expired := make([]*entry, 0, 4)
+	for id, e := range r.sessions {
+		if e.streams == 0 && now.Sub(e.lastActiveAt) > r.idleTimeout {
+			expired = append(expired, e)
+			delete(r.sessions, id)
+		}
+	}
+	r.Unlock()
+
+	for _, e := range expired {
+		if err := r.close(e); err != nil {
+			zlog.Error("Close expired session failure", zap.Error(err), zap.String("sessionId", e.session.GetId()))
+			continue
+		}
+		zlog.Info("Session expired", zap.String("sessionId", e.session.GetId()))
+	}
+}
+
+// close saves the state of a persisted session before closing it.
+func (r *Registry) close(e *entry) error {
+	if err := r.save(e.session, e.lastActiveAt); err != nil {
+		return err
+	}
+	return e.session.Close()
+}
+
+func (r *Registry) save(s llm.Session, lastActiveAt time.Time) error {
+	state := s.State()
+	if !state.Persisted || r.states == nil {
+		return nil
+	}
+	state.LastActiveAt = lastActiveAt.Unix()
+	return r.states.Save(state)
+}
+
+func (r *Registry) Add(s llm.Session) {
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+	r.sessions[s.GetId()] = &entry{
+		session:      s,
+		createdAt:    now,
+		lastActiveAt: now,
+	}
+}
+
+// Get returns the session and refresh its last active time.
+func (r *Registry) Get(id string) (llm.Session, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	e, ok := r.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	e.lastActiveAt = time.Now()
+	return e.session, true
+}
+
+// Streaming marks an answer of the session in flight until the returned func
+// is called, the session is not expired meanwhile and is active again once the
+// answer ends.
+func (r *Registry) Streaming(id string) func() {
+	r.Lock()
+	defer r.Unlock()
+
+	e, ok := r.sessions[id]
+	if !ok {
+		return func() {}
+	}
+	e.streams++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.Lock()
+			defer r.Unlock()
+
+			e.streams--
+			e.lastActiveAt = time.Now()
+		})
+	}
+}
+
+// Has reports whether the session is alive without refreshing it.
+func (r *Registry) Has(id string) bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	_, ok := r.sessions[id]
+	return ok
+}
+
+// Persist marks the session as persisted and saves its state right away.
+func (r *Registry) Persist(id string) error {
+	if r.states == nil {
+		return fmt.Errorf("session state store is nil")
+	}
+
+	s, ok := r.Get(id)
+	if !ok {
+		return fmt.Errorf("session not found, id: %s", id)
+	}
+	s.Persist()
+	return r.save(s, time.Now())
+}
+
+func (r *Registry) Remove(id string) error {
+	r.Lock()
+	e, ok := r.sessions[id]
+	if ok {
+		delete(r.sessions, id)
+	}
+	r.Unlock()
+
+	if !ok {
+		return fmt.Errorf("session not found, id: %s", id)
+	}
+	return r.close(e)
+}
+
+func (r *Registry) List() []*Info {
+	r.RLock()
+	defer r.RUnlock()
+
+	data := make([]*Info, 0, len(r.sessions))
+	for _, e := range r.sessions {
+		state := e.session.State()
+		data = append(data, &Info{
+			Id:           e.session.GetId(),
+			Provider:     state.Provider,
+			ModelName:    e.session.GetModelName(),
+			CreatedAt:    e.createdAt,
+			LastActiveAt: e.lastActiveAt,
+			Persisted:    state.Persisted,
+		})
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].CreatedAt.Before(data[j].CreatedAt)
+	})
+	return data
+}
+
+func (r *Registry) Stop() error {
+	close(r.done)
+	r.wg.Wait()
+	r.ticker.Stop()
+
+	r.Lock()
+	sessions := r.sessions
+	r.sessions = make(map[string]*entry)
+	r.Unlock()
+
+	for _, e := range sessions {
+		if err := r.close(e); err != nil {
+			zlog.Error("Close session failure", zap.Error(err), zap.String("sessionId", e.session.GetId()))
+		}
+	}
+	return nil
+}
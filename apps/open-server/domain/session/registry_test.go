@@ -0,0 +1,54 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryExpire(t *testing.T) {
+	_assert := assert.New(t)
+
+	r := NewRegistry(time.Minute, time.Hour, nil)
+	defer r.Stop()
+
+	client := llm.NewKimiClient("http://127.0.0.1:0/v1", "")
+	s1, err := client.NewSession("moonshot-v1-8k")
+	_assert.Nil(err)
+	s2, err := client.NewSession("moonshot-v1-8k")
+	_assert.Nil(err)
+
+	r.Add(s1)
+	r.Add(s2)
+	_assert.Equal(2, len(r.List()))
+
+	r.Lock()
+	r.sessions[s1.GetId()].lastActiveAt = time.Now().Add(-2 * time.Minute)
+	r.Unlock()
+
+	r.expire(time.Now())
+
+	_, ok := r.Get(s1.GetId())
+	_assert.False(ok)
+	_, ok = r.Get(s2.GetId())
+	_assert.True(ok)
+
+	// an answer in flight keeps the session whatever its last active time
+	done := r.Streaming(s2.GetId())
+	r.expire(time.Now().Add(2 * time.Minute))
+	_assert.True(r.Has(s2.GetId()))
+	done()
+	r.expire(time.Now().Add(30 * time.Second))
+	_assert.True(r.Has(s2.GetId()))
+	r.expire(time.Now().Add(2 * time.Minute))
+	_assert.False(r.Has(s2.GetId()))
+
+	s3, err := client.NewSession("moonshot-v1-8k")
+	_assert.Nil(err)
+	r.Add(s3)
+	_assert.Nil(r.Remove(s3.GetId()))
+	_assert.NotNil(r.Remove(s3.GetId()))
+	_assert.Equal(0, len(r.List()))
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	llm "github.com/eviltomorrow/open-terminal/apps/open-server/domain/llm-model"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var ErrStateNotFound = errors.New("session state not found")
+
+// StateStore saves the state of persisted sessions as json files, one file per
+// session, so that they can be reopened after the server restarts.
+type StateStore struct {
+	dir string
+}
+
+func NewStateStore(dir string) (*StateStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create session state dir failure, nest error: %v", err)
+	}
+	return &StateStore{dir: dir}, nil
+}
+
+func (s *StateStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *StateStore) Save(state *llm.State) error {
+	if !llm.IsSessionId(state.Id) {
+		return fmt.Errorf("session id has wrong value, id: %s", state.Id)
+	}
+	buf, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path(state.Id) + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(state.Id))
+}
+
+func (s *StateStore) Load(id string) (*llm.State, error) {
+	if !llm.IsSessionId(id) {
+		return nil, ErrStateNotFound
+	}
+	buf, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, ErrStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &llm.State{}
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(buf, state); err != nil {
+		return nil, fmt.Errorf("unmarshal session state failure, nest error: %v, id: %s", err, id)
+	}
+	return state, nil
+}
+
+func (s *StateStore) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns the ids of all saved sessions.
+func (s *StateStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok || !llm.IsSessionId(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
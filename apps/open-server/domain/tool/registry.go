@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Tool is a function which the model is able to call during a chat.
+type Tool interface {
+	Name() string
+	Description() string
+	// Parameters returns the json schema of the arguments.
+	Parameters() any
+	Call(ctx context.Context, arguments string) (string, error)
+}
+
+type Registry struct {
+	sync.RWMutex
+
+	tools map[string]Tool
+}
+
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{
+		tools: make(map[string]Tool, len(tools)),
+	}
+	for _, t := range tools {
+		r.Register(t)
+	}
+	return r
+}
+
+func (r *Registry) Register(t Tool) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.tools[t.Name()] = t
+}
+
+func (r *Registry) Definitions() []openai.Tool {
+	r.RLock()
+	defer r.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := make([]openai.Tool, 0, len(names))
+	for _, name := range names {
+		t := r.tools[name]
+		data = append(data, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name(),
+				Description: t.Description(),
+				Parameters:  t.Parameters(),
+			},
+		})
+	}
+	return data
+}
+
+func (r *Registry) Call(ctx context.Context, name string, arguments string) (string, error) {
+	r.RLock()
+	t, ok := r.tools[name]
+	r.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("tool not found, name: %s", name)
+	}
+	return t.Call(ctx, arguments)
+}
@@ -0,0 +1,31 @@
+package tool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryCall(t *testing.T) {
+	_assert := assert.New(t)
+
+	r := NewRegistry(NewCurrentTime())
+
+	defs := r.Definitions()
+	_assert.Equal(1, len(defs))
+	_assert.Equal("current_time", defs[0].Function.Name)
+
+	result, err := r.Call(context.Background(), "current_time", `{"timezone":"UTC"}`)
+	_assert.Nil(err)
+	tm, err := time.Parse(time.RFC3339, result)
+	_assert.Nil(err)
+	_assert.Equal(0, func() int { _, offset := tm.Zone(); return offset }())
+
+	_, err = r.Call(context.Background(), "current_time", `{"timezone":"Nowhere/Nothing"}`)
+	_assert.NotNil(err)
+
+	_, err = r.Call(context.Background(), "not_exist", "")
+	_assert.NotNil(err)
+}
@@ -0,0 +1,57 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// CurrentTime tells the model what time it is now, models have no clock.
+type CurrentTime struct{}
+
+func NewCurrentTime() *CurrentTime {
+	return &CurrentTime{}
+}
+
+func (t *CurrentTime) Name() string {
+	return "current_time"
+}
+
+func (t *CurrentTime) Description() string {
+	return "Get the current date and time, optionally in the given IANA timezone such as Asia/Shanghai"
+}
+
+func (t *CurrentTime) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"timezone": map[string]any{
+				"type":        "string",
+				"description": "IANA timezone name, local timezone of the server if empty",
+			},
+		},
+	}
+}
+
+func (t *CurrentTime) Call(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Timezone string `json:"timezone"`
+	}
+	if arguments != "" {
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments, nest error: %v", err)
+		}
+	}
+
+	now := time.Now()
+	if args.Timezone != "" {
+		loc, err := time.LoadLocation(args.Timezone)
+		if err != nil {
+			return "", fmt.Errorf("invalid timezone, nest error: %v", err)
+		}
+		now = now.In(loc)
+	}
+	return now.Format(time.RFC3339), nil
+}
@@ -0,0 +1,207 @@
+package ask
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/chat"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The exit codes of open-terminal ask, a script tells a rejected api key or a
+// slow server from a broken one by them.
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 2
+	ExitServer      = 3
+	ExitAuth        = 4
+	ExitTimeout     = 5
+	ExitInterrupted = 130
+)
+
+// InputName names stdin when it is attached to the question.
+const InputName = "stdin"
+
+// maxInlineText is what open-server inlines of a text attachment at most
+// (llm.MaxInlineTextBytes), it keeps the head of a larger one.
+const maxInlineText = 64 << 10
+
+// MaxInputSize bounds the input kept whatever the limit asked, a cut input
+// along with its notice fits in what open-server inlines, so that the tail
+// kept here is not cut off there.
+var MaxInputSize = maxInlineText - len(truncatedNotice(math.MaxInt64))
+
+// Input is what is piped to the question, Omitted is the bytes cut off its
+// head to keep it within the limit.
+type Input struct {
+	Data    []byte
+	Omitted int64
+}
+
+// ReadInput reads r to the end and keeps its last limit bytes, MaxInputSize
+// at most, the tail of a log is where it fails. A cut input starts at a whole
+// line when there is one.
+func ReadInput(r io.Reader, limit int) (*Input, error) {
+	limit = min(limit, MaxInputSize)
+	var (
+		in    = &Input{}
+		chunk = make([]byte, 32<<10)
+	)
+	for {
+		n, err := r.Read(chunk)
+		in.Data = append(in.Data, chunk[:n]...)
+		// a byte before the tail is kept to tell whether it starts a line
+		if len(in.Data) > 2*limit+1 {
+			in.drop(len(in.Data) - limit - 1)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read input failure, nest error: %v", err)
+		}
+	}
+	if len(in.Data) <= limit {
+		return in, nil
+	}
+
+	cut := len(in.Data) - limit
+	for cut < len(in.Data) && !utf8.RuneStart(in.Data[cut]) {
+		cut++
+	}
+	if in.Data[cut-1] != '\n' {
+		if i := bytes.IndexByte(in.Data[cut:], '\n'); i >= 0 && cut+i+1 < len(in.Data) {
+			cut += i + 1
+		}
+	}
+	in.drop(cut)
+	return in, nil
+}
+
+func (in *Input) drop(n int) {
+	in.Omitted += int64(n)
+	in.Data = append(in.Data[:0], in.Data[n:]...)
+}
+
+// Truncated reports whether the head of the input is cut off.
+func (in *Input) Truncated() bool {
+	return in.Omitted != 0
+}
+
+// attachment sends the input as a text file, a cut input begins with a notice
+// so that the model does not take it for the whole.
+func (in *Input) attachment() *pb.Attachment {
+	data := in.Data
+	if in.Truncated() {
+		data = append([]byte(truncatedNotice(in.Omitted)), data...)
+	}
+	return &pb.Attachment{Name: InputName, MimeType: "text/plain", Data: data}
+}
+
+func truncatedNotice(omitted int64) string {
+	return fmt.Sprintf("[truncated, the first %d bytes of %s omitted]\n", omitted, InputName)
+}
+
+// Answer is printed by --json.
+type Answer struct {
+	Answer         string `json:"answer"`
+	SessionId      string `json:"session_id"`
+	FinishReason   string `json:"finish_reason"`
+	Cached         bool   `json:"cached"`
+	InputTruncated bool   `json:"input_truncated"`
+	Usage          Usage  `json:"usage"`
+}
+
+type Usage struct {
+	PromptTokens     int32 `json:"prompt_tokens"`
+	CompletionTokens int32 `json:"completion_tokens"`
+	TotalTokens      int32 `json:"total_tokens"`
+}
+
+// WriteJSON writes the answer as a line of JSON.
+func (a *Answer) WriteJSON(w io.Writer) error {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w).Encode(a)
+}
+
+// Ask asks question with the input attached, in is nil when nothing is piped.
+// The answer is written to out as it streams, out is io.Discard when only the
+// whole answer is wanted.
+func Ask(conv *chat.Conversation, question string, in *Input, out io.Writer) (*Answer, error) {
+	var attachments []*pb.Attachment
+	if in != nil && len(in.Data) != 0 {
+		attachments = append(attachments, in.attachment())
+	}
+
+	var (
+		content  strings.Builder
+		writeErr error
+	)
+	done, err := conv.Ask(question, func(resp *pb.ChatResp) {
+		delta := resp.GetMessage().GetContent()
+		content.WriteString(delta)
+		if writeErr == nil {
+			_, writeErr = io.WriteString(out, delta)
+		}
+	}, attachments...)
+	if err != nil {
+		return nil, err
+	}
+	if writeErr != nil {
+		return nil, fmt.Errorf("write answer failure, nest error: %v", writeErr)
+	}
+
+	answer := &Answer{
+		Answer:         content.String(),
+		SessionId:      done.SessionId,
+		FinishReason:   done.FinishReason,
+		Cached:         done.Cached,
+		InputTruncated: in != nil && in.Truncated(),
+	}
+	if usage := done.Usage; usage != nil {
+		answer.Usage = Usage{PromptTokens: usage.PromptTokens, CompletionTokens: usage.CompletionTokens, TotalTokens: usage.TotalTokens}
+	}
+	return answer, nil
+}
+
+// ExitCode tells the exit code of err, failures which are not told by the
+// server are ExitFailure.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return ExitFailure
+	}
+	switch s.Code() {
+	case codes.DeadlineExceeded:
+		return ExitTimeout
+	case codes.Canceled:
+		return ExitInterrupted
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ExitAuth
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition, codes.OutOfRange:
+		return ExitUsage
+	case codes.Unavailable, codes.Internal, codes.Unknown, codes.ResourceExhausted, codes.Aborted,
+		codes.Unimplemented, codes.DataLoss:
+		return ExitServer
+	default:
+		return ExitFailure
+	}
+}
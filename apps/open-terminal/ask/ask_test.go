@@ -0,0 +1,139 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/chat"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/internal/chattest"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestConversation answers a turn in two deltas with its usage, a turn
+// "key" fails as a rejected api key does.
+func newTestConversation(t *testing.T) (*chat.Conversation, *chattest.Server) {
+	fake := &chattest.Server{Answer: func(turn *pb.ChatReq) (*chattest.Reply, error) {
+		if turn.Content == "key" {
+			return nil, status.Error(codes.Unauthenticated, "invalid api key")
+		}
+		return &chattest.Reply{
+			Deltas: []string{"it ", "failed"},
+			Done:   &pb.TurnComplete{FinishReason: "stop", Usage: &pb.Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9}},
+		}, nil
+	}}
+
+	conv := chat.NewConversation(context.Background(), chattest.Dial(t, fake), "")
+	t.Cleanup(func() { conv.Close() })
+	return conv, fake
+}
+
+func TestAsk(t *testing.T) {
+	_assert := assert.New(t)
+
+	conv, fake := newTestConversation(t)
+
+	in, err := ReadInput(strings.NewReader("line one\nline two\nline three\n"), 14)
+	_assert.Nil(err)
+	_assert.Equal("line three\n", string(in.Data))
+	_assert.Equal(int64(18), in.Omitted)
+
+	var out strings.Builder
+	answer, err := Ask(conv, "why did this fail?", in, &out)
+	_assert.Nil(err)
+	_assert.Equal("it failed", out.String())
+	_assert.Equal(&Answer{
+		Answer:         "it failed",
+		SessionId:      "Kimi-1",
+		FinishReason:   "stop",
+		InputTruncated: true,
+		Usage:          Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9},
+	}, answer)
+
+	var buf strings.Builder
+	_assert.Nil(answer.WriteJSON(&buf))
+	_assert.Equal(`{"answer":"it failed","session_id":"Kimi-1","finish_reason":"stop","cached":false,"input_truncated":true,`+
+		`"usage":{"prompt_tokens":7,"completion_tokens":2,"total_tokens":9}}`+"\n", buf.String())
+
+	turns := fake.Turns()
+	_assert.Equal(1, len(turns[0].Attachments))
+	_assert.Equal(InputName, turns[0].Attachments[0].Name)
+	_assert.Equal("[truncated, the first 18 bytes of stdin omitted]\nline three\n", string(turns[0].Attachments[0].Data))
+
+	// nothing piped, nothing attached
+	answer, err = Ask(conv, "hi", nil, io.Discard)
+	_assert.Nil(err)
+	_assert.False(answer.InputTruncated)
+
+	// a limit above what open-server inlines is lowered, the tail arrives
+	// whole along with the notice
+	in, err = ReadInput(strings.NewReader(strings.Repeat("log line\n", 20000)+"the end\n"), 1<<20)
+	_assert.Nil(err)
+	_, err = Ask(conv, "why?", in, io.Discard)
+	_assert.Nil(err)
+
+	_, err = Ask(conv, "key", &Input{}, io.Discard)
+	_assert.Equal(ExitAuth, ExitCode(err))
+
+	turns = fake.Turns()
+	_assert.Equal(4, len(turns))
+	_assert.Equal(0, len(turns[1].Attachments))
+	data := string(turns[2].Attachments[0].Data)
+	_assert.LessOrEqual(len(data), maxInlineText)
+	_assert.True(strings.HasPrefix(data, fmt.Sprintf("[truncated, the first %d bytes of stdin omitted]\n", in.Omitted)))
+	_assert.True(strings.HasSuffix(data, "log line\nthe end\n"))
+	_assert.Equal(0, len(turns[3].Attachments))
+}
+
+func TestReadInput(t *testing.T) {
+	_assert := assert.New(t)
+
+	for _, c := range []struct {
+		input   string
+		limit   int
+		data    string
+		omitted int64
+	}{
+		{"short", 10, "short", 0},
+		{"", 10, "", 0},
+		{strings.Repeat("x", 100) + "\ntail", 10, "tail", 101},
+		// a line longer than the limit is kept from a whole rune
+		{"你好世界", 7, "世界", 6},
+		{strings.Repeat("ab\n", 100000), 6, "ab\nab\n", 299994},
+	} {
+		in, err := ReadInput(strings.NewReader(c.input), c.limit)
+		_assert.Nil(err)
+		_assert.Equal(c.data, string(in.Data), c.input)
+		_assert.Equal(c.omitted, in.Omitted, c.input)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	_assert := assert.New(t)
+
+	for _, c := range []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOK},
+		{errors.New("read config failure"), ExitFailure},
+		{fmt.Errorf("ask failure, nest error: %w", context.DeadlineExceeded), ExitTimeout},
+		{fmt.Errorf("ask failure, nest error: %w", context.Canceled), ExitInterrupted},
+		{status.Error(codes.DeadlineExceeded, "deadline"), ExitTimeout},
+		{status.Error(codes.Unauthenticated, "invalid api key"), ExitAuth},
+		{status.Error(codes.PermissionDenied, "forbidden"), ExitAuth},
+		{status.Error(codes.InvalidArgument, "content is nil"), ExitUsage},
+		{status.Error(codes.NotFound, "model not found"), ExitUsage},
+		{status.Error(codes.Unavailable, "connection refused"), ExitServer},
+		{status.Error(codes.Internal, "stream chat failure"), ExitServer},
+		{status.Error(codes.ResourceExhausted, "rate limited"), ExitServer},
+	} {
+		_assert.Equal(c.code, ExitCode(c.err), c.err)
+	}
+}
@@ -0,0 +1,299 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tenantMetadataKey = "x-tenant-id"
+
+// WithTenant tags the calls made under ctx with tenant, an empty tenant is the
+// default one of the server.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	if tenant == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, tenantMetadataKey, tenant)
+}
+
+// Conversation is a chat with open-server on one Chat stream. The stream is
+// opened by the first turn and opened again in the same session after it
+// breaks. Turns are asked one at a time, Stop and Abort may be called from any
+// goroutine meanwhile.
+type Conversation struct {
+	ctx    context.Context
+	client pb.OpenAIClient
+
+	mu          sync.Mutex
+	stream      grpc.BidiStreamingClient[pb.ChatFrame, pb.ChatEvent]
+	cancel      context.CancelFunc
+	sessionId   string
+	model       string
+	temperature *float32
+	system      *string
+}
+
+// NewConversation makes a conversation, ctx carries the metadata of every
+// stream and ends the conversation when it is done.
+func NewConversation(ctx context.Context, client pb.OpenAIClient, model string) *Conversation {
+	return &Conversation{
+		ctx:    ctx,
+		client: client,
+		model:  model,
+	}
+}
+
+func (c *Conversation) SessionId() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sessionId
+}
+
+// Ask sends a user turn with the files attached and calls handle with every
+// delta of the answer until the turn completes. A turn failed by the server
+// returns its turn_complete along with the status error.
+func (c *Conversation) Ask(content string, handle func(*pb.ChatResp), attachments ...*pb.Attachment) (*pb.TurnComplete, error) {
+	return c.ask(&pb.ChatReq{Role: pb.Role_USER, Content: content, Attachments: attachments}, handle)
+}
+
+func (c *Conversation) ask(req *pb.ChatReq, handle func(*pb.ChatResp)) (*pb.TurnComplete, error) {
+	stream, err := c.send(func(sessionId string) *pb.ChatFrame {
+		req.SessionId = sessionId
+		req.Model = c.model
+		return &pb.ChatFrame{Frame: &pb.ChatFrame_Turn{Turn: req}}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.recv(stream, handle)
+}
+
+// Regenerate drops the last answer and asks its turn again.
+func (c *Conversation) Regenerate(handle func(*pb.ChatResp)) (*pb.TurnComplete, error) {
+	stream, err := c.send(func(string) *pb.ChatFrame {
+		return controlFrame(pb.Control_REGENERATE, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.recv(stream, handle)
+}
+
+// SetTemperature sets the temperature of the turns that follow, it is sent
+// again whenever the stream is opened again.
+func (c *Conversation) SetTemperature(temperature float32) error {
+	c.mu.Lock()
+	c.temperature = &temperature
+	stream := c.stream
+	c.mu.Unlock()
+
+	if stream == nil {
+		return nil
+	}
+	_, err := c.send(func(string) *pb.ChatFrame {
+		return controlFrame(pb.Control_SET_TEMPERATURE, temperature)
+	})
+	return err
+}
+
+// SetSystem replaces the system prompt of the session, an empty prompt drops
+// it. Like the temperature it is sent again on a new stream.
+func (c *Conversation) SetSystem(prompt string) error {
+	c.mu.Lock()
+	c.system = &prompt
+	stream := c.stream
+	c.mu.Unlock()
+
+	if stream == nil {
+		return nil
+	}
+	_, err := c.send(func(string) *pb.ChatFrame {
+		return &pb.ChatFrame{Frame: &pb.ChatFrame_Control{Control: &pb.Control{Action: pb.Control_SET_SYSTEM, System: prompt}}}
+	})
+	return err
+}
+
+// Settings returns the model, temperature and system prompt set on the
+// conversation, nil ones are the defaults of the server.
+func (c *Conversation) Settings() (string, *float32, *string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.model, c.temperature, c.system
+}
+
+// SetModel switches the model from the next turn on, the session keeps its
+// history.
+func (c *Conversation) SetModel(model string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.model = model
+}
+
+// Resume ends the stream and continues the session by id from the next turn
+// on, an empty id starts a new session. The system prompt set before belongs
+// to the session left, it is not sent to the one resumed.
+func (c *Conversation) Resume(sessionId string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	if c.stream != nil {
+		err = c.stream.CloseSend()
+		c.reset()
+	}
+	c.sessionId = sessionId
+	if sessionId != "" {
+		c.system = nil
+	}
+	return err
+}
+
+// Stop asks the server to stop the answer being generated, the turn then
+// completes as stopped.
+func (c *Conversation) Stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil {
+		return nil
+	}
+	return c.stream.Send(controlFrame(pb.Control_STOP, 0))
+}
+
+// Abort breaks the stream without waiting for the server, the turn being
+// received fails with codes.Canceled.
+func (c *Conversation) Abort() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset()
+}
+
+// Close ends the stream, the session stays on the server until it is idle.
+func (c *Conversation) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil {
+		return nil
+	}
+	err := c.stream.CloseSend()
+	c.reset()
+	return err
+}
+
+// send opens the stream when there is none and sends the frame made by
+// frameOf, which is given the session to resume on a new stream. A stream the
+// server has ended since the last turn is only found out by sending on it, the
+// frame is sent again on a new stream then.
+func (c *Conversation) send(frameOf func(sessionId string) *pb.ChatFrame) (grpc.BidiStreamingClient[pb.ChatFrame, pb.ChatEvent], error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream != nil {
+		if err := c.stream.Send(frameOf("")); err == nil {
+			return c.stream, nil
+		} else if err = c.broken(err); err != io.EOF {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	stream, err := c.client.Chat(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.stream, c.cancel = stream, cancel
+
+	if c.temperature != nil {
+		if err := c.stream.Send(controlFrame(pb.Control_SET_TEMPERATURE, *c.temperature)); err != nil {
+			return nil, c.broken(err)
+		}
+	}
+	if c.system != nil {
+		if err := c.stream.Send(&pb.ChatFrame{Frame: &pb.ChatFrame_Control{Control: &pb.Control{Action: pb.Control_SET_SYSTEM, System: *c.system}}}); err != nil {
+			return nil, c.broken(err)
+		}
+	}
+	if err := c.stream.Send(frameOf(c.sessionId)); err != nil {
+		return nil, c.broken(err)
+	}
+	return c.stream, nil
+}
+
+// recv receives the answer of the turn sent on stream.
+func (c *Conversation) recv(stream grpc.BidiStreamingClient[pb.ChatFrame, pb.ChatEvent], handle func(*pb.ChatResp)) (*pb.TurnComplete, error) {
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			c.mu.Lock()
+			if c.stream == stream {
+				err = c.broken(err)
+			}
+			c.mu.Unlock()
+			if err == io.EOF {
+				err = status.Error(codes.Unavailable, "chat stream is closed by server")
+			}
+			return nil, err
+		}
+
+		if delta := event.GetDelta(); delta != nil {
+			handle(delta)
+			continue
+		}
+		done := event.GetTurnComplete()
+		if done == nil {
+			continue
+		}
+		if done.SessionId != "" {
+			c.mu.Lock()
+			c.sessionId = done.SessionId
+			c.mu.Unlock()
+		}
+		if done.Code != int32(codes.OK) {
+			return done, status.Error(codes.Code(done.Code), done.Error)
+		}
+		return done, nil
+	}
+}
+
+// broken drops the stream after err, the next turn opens a new one. Send
+// fails with io.EOF, the status of the stream is told by Recv then. The caller
+// holds c.mu.
+func (c *Conversation) broken(err error) error {
+	if err == io.EOF && c.stream != nil {
+		if _, rerr := c.stream.Recv(); rerr != nil && rerr != io.EOF {
+			err = rerr
+		}
+	}
+	if !errors.Is(err, io.EOF) && status.Code(err) != codes.Canceled {
+		zlog.Warn("Chat stream is broken", zap.Error(err), zap.String("sessionId", c.sessionId))
+	}
+	c.reset()
+	return err
+}
+
+func (c *Conversation) reset() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.stream, c.cancel = nil, nil
+}
+
+func controlFrame(action pb.Control_Action, temperature float32) *pb.ChatFrame {
+	return &pb.ChatFrame{Frame: &pb.ChatFrame_Control{Control: &pb.Control{Action: action, Temperature: temperature}}}
+}
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/internal/chattest"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConversation echoes every turn. A turn "hang" is answered by one
+// delta and completes once stopped, a turn "bye" ends the stream after its
+// answer.
+func newTestConversation(t *testing.T) (*Conversation, *chattest.Server) {
+	fake := &chattest.Server{Answer: func(turn *pb.ChatReq) (*chattest.Reply, error) {
+		reply, err := chattest.Echo(turn)
+		switch turn.Content {
+		case "hang":
+			reply.Done = nil
+		case "bye":
+			reply.Hangup = true
+		}
+		return reply, err
+	}}
+
+	conv := NewConversation(WithTenant(context.Background(), "acme"), chattest.Dial(t, fake), "gpt-4o")
+	t.Cleanup(func() { conv.Close() })
+	return conv, fake
+}
+
+func TestConversation(t *testing.T) {
+	_assert := assert.New(t)
+
+	conv, fake := newTestConversation(t)
+
+	var buf strings.Builder
+	handle := func(resp *pb.ChatResp) { buf.WriteString(resp.GetMessage().GetContent()) }
+
+	done, err := conv.Ask("hi", handle)
+	_assert.Nil(err)
+	_assert.Equal("echo: hi", buf.String())
+	_assert.Equal("Kimi-1", conv.SessionId())
+	_assert.Equal(int32(1), done.Turn)
+
+	_assert.Nil(conv.SetTemperature(0.5))
+	buf.Reset()
+	done, err = conv.Ask("hang", func(resp *pb.ChatResp) {
+		handle(resp)
+		_assert.Nil(conv.Stop())
+	})
+	_assert.Nil(err)
+	_assert.True(done.Stopped)
+
+	// the stream ends after bye, the next turn resumes the session on a new one
+	_, err = conv.Ask("bye", handle)
+	_assert.Nil(err)
+	_, err = conv.Ask("again", handle)
+	_assert.Nil(err)
+
+	turns := fake.Turns()
+	_assert.Equal(4, len(turns))
+	_assert.Equal("", turns[0].SessionId)
+	_assert.Equal("gpt-4o", turns[0].Model)
+	_assert.Equal("", turns[2].SessionId)
+	_assert.Equal("Kimi-1", turns[3].SessionId)
+	var tenants []string
+	for _, md := range fake.Metadata() {
+		tenants = append(tenants, md.Get(tenantMetadataKey)...)
+	}
+	_assert.Equal([]string{"acme", "acme"}, tenants)
+
+	// the temperature is sent again on the new stream
+	var temperatures int
+	for _, ctl := range fake.Controls() {
+		if ctl.Action == pb.Control_SET_TEMPERATURE {
+			_assert.Equal(float32(0.5), ctl.Temperature)
+			temperatures++
+		}
+	}
+	_assert.Equal(2, temperatures)
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/ask"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/chat"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/conf"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/term"
+	"github.com/eviltomorrow/open-terminal/lib/buildinfo"
+	"github.com/eviltomorrow/open-terminal/lib/envutil"
+	"github.com/eviltomorrow/open-terminal/lib/finalizer"
+	"github.com/eviltomorrow/open-terminal/lib/flagsutil"
+	"github.com/eviltomorrow/open-terminal/lib/grpc/client"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	flags "github.com/jessevdk/go-flags"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+const askCommand = "ask"
+
+type askFlags struct {
+	ConfigFile   string        `short:"c" long:"config-file" description:"specifying a config file"`
+	Model        string        `short:"m" long:"model" description:"model name or <provider>/<model>, chat.model of the config by default"`
+	System       string        `short:"s" long:"system" description:"system prompt of the question"`
+	JSON         bool          `long:"json" description:"print the answer as JSON along with the usage"`
+	Timeout      time.Duration `long:"timeout" description:"give up after the timeout, ask.timeout of the config by default"`
+	MaxInputSize int           `long:"max-input-size" description:"bytes read from stdin at most, the tail is kept, ask.max_input_size of the config by default and what open-server inlines at most"`
+
+	Args struct {
+		Question []string `positional-arg-name:"question"`
+	} `positional-args:"yes"`
+}
+
+const askDescription = `Ask one question and print only the answer, what is piped to stdin is
+attached to the question:
+
+  journalctl -u x | open-terminal ask "why did this fail?"
+  git diff | open-terminal ask --json "summarise"
+
+Exit codes:
+  0    the answer is printed
+  1    any other failure
+  2    wrong usage, or the question is rejected by the server
+  3    open-server or the model provider fails
+  4    the api key is rejected by the model provider
+  5    no answer within the timeout
+  130  interrupted`
+
+// runAsk runs "open-terminal ask" with the arguments after it and returns the
+// exit code, errors go to stderr so that stdout only has the answer.
+func runAsk(args []string) int {
+	opts := &askFlags{}
+	parser := flags.NewParser(opts, flags.HelpFlag|flags.PassDoubleDash)
+	parser.Name = fmt.Sprintf("%s %s", buildinfo.AppName, askCommand)
+	parser.LongDescription = askDescription
+	if _, err := parser.ParseArgs(args); err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
+			fmt.Fprintln(os.Stdout, err)
+			return ask.ExitOK
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", parser.Name, err)
+		return ask.ExitUsage
+	}
+	if opts.Timeout < 0 || opts.MaxInputSize < 0 {
+		fmt.Fprintf(os.Stderr, "%s: timeout and max-input-size must be positive\n", parser.Name)
+		return ask.ExitUsage
+	}
+	question := strings.TrimSpace(strings.Join(opts.Args.Question, " "))
+	if question == "" {
+		fmt.Fprintf(os.Stderr, "%s: question is nil, see --help\n", parser.Name)
+		return ask.ExitUsage
+	}
+
+	defer func() {
+		finalizer.RunCleanupFuncs()
+	}()
+
+	if err := askQuestion(opts, question); err != nil {
+		msg := err.Error()
+		if s, ok := status.FromError(err); ok {
+			msg = fmt.Sprintf("%s, %s", s.Code(), s.Message())
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", parser.Name, msg)
+		return ask.ExitCode(err)
+	}
+	return ask.ExitOK
+}
+
+func askQuestion(opts *askFlags, question string) error {
+	c, err := conf.ReadConfig(&flagsutil.Flags{ConfigFile: opts.ConfigFile})
+	if err != nil {
+		return fmt.Errorf("read config failure, nest error: %v", err)
+	}
+	if opts.Model != "" {
+		c.Chat.Model = opts.Model
+	}
+	if opts.Timeout != 0 {
+		c.Ask.Timeout = opts.Timeout
+	}
+	if opts.MaxInputSize != 0 {
+		c.Ask.MaxInputSize = opts.MaxInputSize
+	}
+	if err := c.Ask.VerifyConfig(); err != nil {
+		return err
+	}
+	c.Ask.MaxInputSize = min(c.Ask.MaxInputSize, ask.MaxInputSize)
+
+	if err := envutil.InitLog(c.Log); err != nil {
+		return fmt.Errorf("init log failure, nest error: %v", err)
+	}
+	zlog.Info("Config info", zap.String("config", c.String()))
+
+	var in *ask.Input
+	if !term.IsTTY(os.Stdin) {
+		in, err = ask.ReadInput(os.Stdin, c.Ask.MaxInputSize)
+		if err != nil {
+			return err
+		}
+		if in.Truncated() {
+			fmt.Fprintf(os.Stderr, "%s %s: stdin exceeds %d bytes, the first %d bytes are left out\n", buildinfo.AppName, askCommand, c.Ask.MaxInputSize, in.Omitted)
+		}
+	}
+
+	stub, closeConn, err := client.NewOpenAIWithTarget(c.Server.Target)
+	if err != nil {
+		return fmt.Errorf("dial open-server failure, nest error: %v", err)
+	}
+	finalizer.RegisterCleanupFuncs(closeConn)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, c.Ask.Timeout)
+	defer cancel()
+
+	conv := chat.NewConversation(chat.WithTenant(ctx, c.Server.Tenant), stub, c.Chat.Model)
+	defer conv.Close()
+	if opts.System != "" {
+		if err := conv.SetSystem(opts.System); err != nil {
+			return err
+		}
+	}
+
+	// the plain answer is printed as it streams, JSON once it is whole
+	var out io.Writer = os.Stdout
+	if opts.JSON {
+		out = io.Discard
+	}
+	answer, err := ask.Ask(conv, question, in, out)
+	if err != nil {
+		// ctx tells a timeout from an interrupt whatever status the stream
+		// ends with
+		if ctx.Err() != nil {
+			return fmt.Errorf("ask failure, nest error: %w", ctx.Err())
+		}
+		return err
+	}
+	zlog.Info("Question answered", zap.String("session-id", answer.SessionId), zap.Int32("total-tokens", answer.Usage.TotalTokens))
+
+	if opts.JSON {
+		return answer.WriteJSON(os.Stdout)
+	}
+	if answer.Answer != "" && !strings.HasSuffix(answer.Answer, "\n") {
+		fmt.Fprintln(os.Stdout)
+	}
+	return nil
+}
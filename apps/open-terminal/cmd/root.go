@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/chat"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/conf"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/editor"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/repl"
+	"github.com/eviltomorrow/open-terminal/lib/buildinfo"
+	"github.com/eviltomorrow/open-terminal/lib/envutil"
+	"github.com/eviltomorrow/open-terminal/lib/finalizer"
+	"github.com/eviltomorrow/open-terminal/lib/flagsutil"
+	"github.com/eviltomorrow/open-terminal/lib/grpc/client"
+	"github.com/eviltomorrow/open-terminal/lib/system"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+)
+
+func RunApp() error {
+	// ask has flags of its own and exits with codes told by the failure
+	if len(os.Args) > 1 && os.Args[1] == askCommand {
+		os.Exit(runAsk(os.Args[2:]))
+	}
+
+	_, err := flagsutil.Parse(flagsutil.Opts)
+	if err != nil {
+		return err
+	}
+
+	if flagsutil.Opts.Version {
+		fmt.Println(buildinfo.Version())
+		os.Exit(0)
+	}
+	defer func() {
+		finalizer.RunCleanupFuncs()
+	}()
+
+	c, err := conf.ReadConfig(flagsutil.Opts)
+	if err != nil {
+		return fmt.Errorf("read config failure, nest error: %v", err)
+	}
+
+	if err := envutil.InitLog(c.Log); err != nil {
+		return fmt.Errorf("init log failure, nest error: %v", err)
+	}
+
+	stub, closeConn, err := client.NewOpenAIWithTarget(c.Server.Target)
+	if err != nil {
+		return fmt.Errorf("dial open-server failure, nest error: %v", err)
+	}
+	finalizer.RegisterCleanupFuncs(closeConn)
+
+	zlog.Info("System info", zap.String("system", system.String()))
+	zlog.Info("Config info", zap.String("config", c.String()))
+
+	// Ctrl-C is taken by the REPL, it stops the answer instead of the program.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	ctx := chat.WithTenant(context.Background(), c.Server.Tenant)
+	conv := chat.NewConversation(ctx, stub, c.Chat.Model)
+	finalizer.RegisterCleanupFuncs(conv.Close)
+
+	history, err := editor.LoadHistory(filepath.Join(system.Directory.VarDir, fmt.Sprintf("history/%s.history", buildinfo.AppName)), editor.DefaultHistorySize)
+	if err != nil {
+		zlog.Warn("Load input history failure", zap.Error(err))
+		history, _ = editor.LoadHistory("", editor.DefaultHistorySize)
+	}
+
+	if err := repl.New(conv, stub, os.Stdin, os.Stdout, os.Stderr, repl.WithHistoryForREPL(history)).Run(ctx, interrupts); err != nil {
+		return fmt.Errorf("run repl failure, nest error: %v", err)
+	}
+	zlog.Info("App stop complete", zap.String("session-id", conv.SessionId()), zap.String("launched-time", system.LaunchTime()))
+	return nil
+}
@@ -0,0 +1,115 @@
+package conf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/config"
+	"github.com/eviltomorrow/open-terminal/lib/flagsutil"
+	"github.com/eviltomorrow/open-terminal/lib/log"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type Config struct {
+	Log    *log.Config `json:"log" toml:"log" mapstructure:"log"`
+	Server *Server     `json:"server" toml:"server" mapstructure:"server"`
+	Chat   *Chat       `json:"chat" toml:"chat" mapstructure:"chat"`
+	Ask    *Ask        `json:"ask" toml:"ask" mapstructure:"ask"`
+}
+
+// Server is where open-server listens, tenant is sent as x-tenant-id.
+type Server struct {
+	Target string `json:"target" toml:"target" mapstructure:"target"`
+	Tenant string `json:"tenant" toml:"tenant" mapstructure:"tenant"`
+}
+
+func (c *Server) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Server) VerifyConfig() error {
+	if c.Target == "" {
+		return fmt.Errorf("server.target is nil")
+	}
+	return nil
+}
+
+// Chat is what a new conversation starts with, an empty model is the default
+// model of the server.
+type Chat struct {
+	Model string `json:"model" toml:"model" mapstructure:"model"`
+}
+
+func (c *Chat) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+// Ask is the one-shot question asked by "open-terminal ask", max_input_size
+// bounds what is read from stdin, it is lowered to what open-server inlines of
+// a file less the notice of a cut input.
+type Ask struct {
+	Timeout      time.Duration `json:"timeout" toml:"timeout" mapstructure:"timeout"`
+	MaxInputSize int           `json:"max_input_size" toml:"max_input_size" mapstructure:"max_input_size"`
+}
+
+func (c *Ask) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Ask) VerifyConfig() error {
+	if c.Timeout <= 0 {
+		return fmt.Errorf("ask.timeout has no value")
+	}
+	if c.MaxInputSize <= 0 {
+		return fmt.Errorf("ask.max_input_size has no value")
+	}
+	return nil
+}
+
+func (c *Config) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Config) IsConfigValid() error {
+	for _, f := range []func() error{
+		c.Log.VerifyConfig,
+		c.Server.VerifyConfig,
+		c.Ask.VerifyConfig,
+	} {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadConfig(opts *flagsutil.Flags) (*Config, error) {
+	c := InitializeDefaultConfig(opts)
+
+	if err := config.ReadFile(c, opts.ConfigFile); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// InitializeDefaultConfig never logs to stdout, it belongs to the chat.
+func InitializeDefaultConfig(opts *flagsutil.Flags) *Config {
+	return &Config{
+		Log: &log.Config{
+			Level:         "info",
+			DisableStdlog: true,
+		},
+		Server: &Server{
+			Target: "127.0.0.1:50001",
+		},
+		Chat: &Chat{},
+		Ask: &Ask{
+			Timeout:      2 * time.Minute,
+			MaxInputSize: 64 << 10,
+		},
+	}
+}
@@ -0,0 +1,612 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/term"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl-C is pressed.
+var ErrInterrupted = errors.New("interrupted")
+
+// ErrHistory is returned by ReadLine along with the line read when the line
+// fails to be kept in the history file, the history is kept in memory only
+// after it.
+var ErrHistory = errors.New("keep history failure")
+
+const (
+	continuationPrompt = ". "
+	tabSpaces          = "    "
+)
+
+// Editor reads lines from a terminal with readline-style editing:
+//
+//	Ctrl-A/Home, Ctrl-E/End       start, end of the line
+//	Ctrl-B/Left, Ctrl-F/Right     a character back, forward
+//	Alt-B/Ctrl-Left, Alt-F/Ctrl-Right  a word back, forward
+//	Ctrl-H/Backspace, Ctrl-D/Del  delete a character back, forward
+//	Ctrl-W/Alt-Backspace, Alt-D   delete a word back, forward
+//	Ctrl-U, Ctrl-K, Ctrl-Y        kill to the start, the end of the line, yank
+//	Ctrl-P/Up, Ctrl-N/Down        the line above or below, the history past it
+//	Ctrl-R                        reverse-i-search the history
+//	Tab                           complete, twice lists the candidates
+//	Alt-Enter/Ctrl-J, \ at end    a new line instead of the end of the input
+//	Ctrl-L                        clear the screen
+//
+// Pasted text is taken as it is, newlines included.
+type Editor struct {
+	fd     int
+	reader *bufio.Reader
+	out    io.Writer
+	width  func() int
+
+	history  *History
+	complete func(line string) []string
+
+	prompt string
+	buf    []rune
+	pos    int
+	// rows is the row of the cursor below the one the prompt starts on
+	rows int
+	yank []rune
+	// index is the history entry shown, the line being written when it is the
+	// length of the history
+	index   int
+	saved   []rune
+	tabbed  bool
+	lastKey rune
+}
+
+type Option func(*Editor)
+
+// WithHistoryForEditor keeps the lines read in h, for Up, Down and Ctrl-R.
+func WithHistoryForEditor(h *History) Option {
+	return func(e *Editor) {
+		e.history = h
+	}
+}
+
+// WithCompleteForEditor completes the text before the cursor on Tab, complete
+// returns the texts it may be completed to.
+func WithCompleteForEditor(complete func(line string) []string) Option {
+	return func(e *Editor) {
+		e.complete = complete
+	}
+}
+
+// New makes an Editor reading from the terminal in and writing to out.
+func New(in *os.File, out io.Writer, opts ...Option) *Editor {
+	fd := int(in.Fd())
+	e := &Editor{
+		fd:     fd,
+		reader: bufio.NewReader(in),
+		out:    out,
+		width:  func() int { return term.Width(fd) },
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.history == nil {
+		e.history, _ = LoadHistory("", DefaultHistorySize)
+	}
+	return e
+}
+
+// ReadLine reads a line after prompt and keeps it in the history. It returns
+// io.EOF on Ctrl-D at an empty line, ErrInterrupted on Ctrl-C and the line
+// with ErrHistory when the history file fails to be written.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", fmt.Errorf("make terminal raw failure, nest error: %v", err)
+	}
+	defer term.Restore(e.fd, state)
+
+	// bracketed paste tells pasted text from typed keys
+	io.WriteString(e.out, "\x1b[?2004h")
+	defer io.WriteString(e.out, "\x1b[?2004l")
+
+	return e.edit(prompt)
+}
+
+func (e *Editor) edit(prompt string) (string, error) {
+	e.prompt = prompt
+	e.buf, e.pos, e.rows = nil, 0, 0
+	e.index, e.saved = e.history.Len(), nil
+	e.refresh()
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		if r == ctrl('R') {
+			if r, err = e.search(); err != nil {
+				return "", err
+			}
+		}
+
+		line, done, err := e.handle(r)
+		e.tabbed = e.lastKey == '\t'
+		if err != nil || done {
+			return line, err
+		}
+	}
+}
+
+func ctrl(c byte) rune {
+	return rune(c & 0x1f)
+}
+
+// handle runs the key r, done tells that the line is read.
+func (e *Editor) handle(r rune) (string, bool, error) {
+	e.lastKey = r
+	switch r {
+	case '\r':
+		switch {
+		case e.reader.Buffered() != 0:
+			// more input is already there, it is pasted by a terminal
+			// without bracketed paste
+			e.insert([]rune{'\n'})
+		case e.pos == len(e.buf) && e.pos != 0 && e.buf[e.pos-1] == '\\':
+			e.buf[e.pos-1] = '\n'
+		default:
+			line, err := e.submit()
+			return line, true, err
+		}
+	case '\n':
+		e.insert([]rune{'\n'})
+	case ctrl('C'):
+		e.pos = len(e.buf)
+		e.refresh()
+		io.WriteString(e.out, "^C\r\n")
+		return "", true, ErrInterrupted
+	case ctrl('D'):
+		if len(e.buf) == 0 {
+			return "", true, io.EOF
+		}
+		e.delete(e.pos, e.pos+1)
+	case ctrl('A'):
+		e.pos = e.lineStart(e.pos)
+	case ctrl('E'):
+		e.pos = e.lineEnd(e.pos)
+	case ctrl('B'):
+		e.pos = max(e.pos-1, 0)
+	case ctrl('F'):
+		e.pos = min(e.pos+1, len(e.buf))
+	case ctrl('H'), 0x7f:
+		e.delete(e.pos-1, e.pos)
+	case ctrl('W'):
+		e.kill(e.spaceWordStart(), e.pos)
+	case ctrl('U'):
+		e.kill(e.lineStart(e.pos), e.pos)
+	case ctrl('K'):
+		e.kill(e.pos, e.lineEnd(e.pos))
+	case ctrl('Y'):
+		e.insert(e.yank)
+	case ctrl('P'):
+		e.up()
+	case ctrl('N'):
+		e.down()
+	case ctrl('L'):
+		io.WriteString(e.out, "\x1b[H\x1b[2J")
+		e.rows = 0
+	case '\t':
+		e.completeLine()
+	case 0x1b:
+		e.escape()
+	default:
+		if r >= 0x20 {
+			e.insert([]rune{r})
+		}
+	}
+	e.refresh()
+	return "", false, nil
+}
+
+// escape runs the key an escape sequence stands for, a lone escape does
+// nothing.
+func (e *Editor) escape() {
+	if e.reader.Buffered() == 0 {
+		return
+	}
+	r, _, err := e.reader.ReadRune()
+	if err != nil {
+		return
+	}
+
+	var seq string
+	switch r {
+	case '[':
+		seq = e.readCSI()
+	case 'O':
+		if c, err := e.reader.ReadByte(); err == nil {
+			seq = string(c)
+		}
+	default:
+		e.alt(r)
+		return
+	}
+
+	switch seq {
+	case "A":
+		e.up()
+	case "B":
+		e.down()
+	case "C":
+		e.pos = min(e.pos+1, len(e.buf))
+	case "D":
+		e.pos = max(e.pos-1, 0)
+	case "H", "1~", "7~":
+		e.pos = e.lineStart(e.pos)
+	case "F", "4~", "8~":
+		e.pos = e.lineEnd(e.pos)
+	case "3~":
+		e.delete(e.pos, e.pos+1)
+	case "1;5C", "1;3C":
+		e.pos = e.wordEnd()
+	case "1;5D", "1;3D":
+		e.pos = e.wordStart()
+	case "200~":
+		e.paste()
+	}
+}
+
+// alt runs Alt with r.
+func (e *Editor) alt(r rune) {
+	switch r {
+	case 'b', 'B':
+		e.pos = e.wordStart()
+	case 'f', 'F':
+		e.pos = e.wordEnd()
+	case 'd', 'D':
+		e.kill(e.pos, e.wordEnd())
+	case 0x7f, ctrl('H'):
+		e.kill(e.wordStart(), e.pos)
+	case '\r':
+		e.insert([]rune{'\n'})
+	}
+}
+
+// readCSI reads the parameters and the final byte of a control sequence.
+func (e *Editor) readCSI() string {
+	var buf strings.Builder
+	for {
+		c, err := e.reader.ReadByte()
+		if err != nil {
+			return buf.String()
+		}
+		buf.WriteByte(c)
+		if c >= 0x40 && c <= 0x7e {
+			return buf.String()
+		}
+	}
+}
+
+// paste inserts the text pasted until the end of the bracketed paste.
+func (e *Editor) paste() {
+	const end = "\x1b[201~"
+
+	var buf strings.Builder
+	for !strings.HasSuffix(buf.String(), end) {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			break
+		}
+		buf.WriteRune(r)
+	}
+
+	text := strings.TrimSuffix(buf.String(), end)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ReplaceAll(text, "\t", tabSpaces)
+	e.insert([]rune(strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' {
+			return -1
+		}
+		return r
+	}, text)))
+}
+
+func (e *Editor) submit() (string, error) {
+	e.pos = len(e.buf)
+	e.refresh()
+	io.WriteString(e.out, "\r\n")
+
+	line := string(e.buf)
+	if err := e.history.Add(line); err != nil {
+		return line, fmt.Errorf("%w, nest error: %v", ErrHistory, err)
+	}
+	return line, nil
+}
+
+func (e *Editor) insert(text []rune) {
+	e.buf = append(e.buf[:e.pos], append(append([]rune(nil), text...), e.buf[e.pos:]...)...)
+	e.pos += len(text)
+}
+
+func (e *Editor) delete(from, to int) {
+	from, to = max(from, 0), min(to, len(e.buf))
+	if from >= to {
+		return
+	}
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	if e.pos > from {
+		e.pos = max(from, e.pos-(to-from))
+	}
+}
+
+// kill deletes the text between from and to and keeps it for Ctrl-Y.
+func (e *Editor) kill(from, to int) {
+	if from >= to {
+		return
+	}
+	e.yank = append([]rune(nil), e.buf[from:to]...)
+	e.delete(from, to)
+}
+
+// up moves to the line above, or to the history entry before.
+func (e *Editor) up() {
+	start := e.lineStart(e.pos)
+	if start == 0 {
+		e.showHistory(e.index - 1)
+		return
+	}
+	above := e.lineStart(start - 1)
+	e.pos = above + min(e.pos-start, start-1-above)
+}
+
+// down moves to the line below, or to the history entry after.
+func (e *Editor) down() {
+	end := e.lineEnd(e.pos)
+	if end == len(e.buf) {
+		e.showHistory(e.index + 1)
+		return
+	}
+	below := end + 1
+	e.pos = below + min(e.pos-e.lineStart(e.pos), e.lineEnd(below)-below)
+}
+
+func (e *Editor) showHistory(index int) {
+	if index < 0 || index > e.history.Len() {
+		return
+	}
+	if e.index == e.history.Len() {
+		e.saved = append([]rune(nil), e.buf...)
+	}
+	e.index = index
+	if index == e.history.Len() {
+		e.buf = e.saved
+	} else {
+		e.buf = []rune(e.history.Entry(index))
+	}
+	e.pos = len(e.buf)
+}
+
+// search runs reverse-i-search until a key other than the ones editing the
+// query is pressed, which is returned to be run on the match.
+func (e *Editor) search() (rune, error) {
+	saved, savedPos := e.buf, e.pos
+	var query []rune
+	index, offset, found := e.history.Len(), 0, true
+
+	show := func() {
+		prompt := "(reverse-i-search)`"
+		if !found {
+			prompt = "(failing reverse-i-search)`"
+		}
+		e.prompt = prompt + string(query) + "': "
+		if index < e.history.Len() {
+			e.buf, e.pos = []rune(e.history.Entry(index)), offset
+		} else {
+			e.buf, e.pos = saved, savedPos
+		}
+		e.refresh()
+	}
+	// find searches the entries before from, the match stays when none is
+	// found
+	find := func(from int) {
+		i, n := e.history.Search(string(query), from)
+		if found = i >= 0; found {
+			index, offset = i, n
+		}
+	}
+
+	prompt := e.prompt
+	defer func() { e.prompt = prompt }()
+	show()
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case r == ctrl('R'):
+			find(index)
+		case r == ctrl('G') || r == ctrl('C'):
+			e.buf, e.pos = saved, savedPos
+			return 0, nil
+		case r == ctrl('H') || r == 0x7f:
+			if len(query) != 0 {
+				query = query[:len(query)-1]
+				find(e.history.Len())
+			}
+		case r >= 0x20:
+			query = append(query, r)
+			find(min(index+1, e.history.Len()))
+		default:
+			e.index = e.history.Len()
+			return r, nil
+		}
+		show()
+	}
+}
+
+// completeLine completes the text before the cursor to the common prefix of
+// the candidates, a second Tab lists them.
+func (e *Editor) completeLine() {
+	if e.complete == nil {
+		return
+	}
+	head := string(e.buf[:e.pos])
+	candidates := e.complete(head)
+	if len(candidates) == 0 {
+		io.WriteString(e.out, "\a")
+		return
+	}
+
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	if len(prefix) > len(head) && strings.HasPrefix(prefix, head) {
+		e.buf = append([]rune(prefix), e.buf[e.pos:]...)
+		e.pos = len([]rune(prefix))
+		e.lastKey = 0
+		return
+	}
+	if len(candidates) == 1 {
+		return
+	}
+	if !e.tabbed {
+		io.WriteString(e.out, "\a")
+		return
+	}
+
+	// list the candidates under the input, by their last word
+	cut := strings.LastIndexAny(head, " /") + 1
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c[min(cut, len(c)):])
+	}
+	pos := e.pos
+	e.pos = len(e.buf)
+	e.refresh()
+	io.WriteString(e.out, "\r\n"+strings.Join(names, "  ")+"\r\n")
+	e.pos, e.rows = pos, 0
+}
+
+func (e *Editor) lineStart(pos int) int {
+	for pos > 0 && e.buf[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+func (e *Editor) lineEnd(pos int) int {
+	for pos < len(e.buf) && e.buf[pos] != '\n' {
+		pos++
+	}
+	return pos
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordStart is the start of the word before the cursor, a run of letters and
+// digits.
+func (e *Editor) wordStart() int {
+	pos := e.pos
+	for pos > 0 && !isWordRune(e.buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(e.buf[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd is the end of the word after the cursor.
+func (e *Editor) wordEnd() int {
+	pos := e.pos
+	for pos < len(e.buf) && !isWordRune(e.buf[pos]) {
+		pos++
+	}
+	for pos < len(e.buf) && isWordRune(e.buf[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// spaceWordStart is the start of the word before the cursor split by spaces,
+// the word Ctrl-W deletes.
+func (e *Editor) spaceWordStart() int {
+	pos := e.pos
+	for pos > 0 && unicode.IsSpace(e.buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(e.buf[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// refresh draws the prompt and the input again and puts the cursor on its
+// cell. The input wraps at the width of the terminal, a wide character which
+// does not fit on a row goes to the next one, as the terminal does.
+func (e *Editor) refresh() {
+	cols := e.width()
+
+	var buf strings.Builder
+	if e.rows > 0 {
+		fmt.Fprintf(&buf, "\x1b[%dA", e.rows)
+	}
+	buf.WriteString("\r\x1b[J")
+	buf.WriteString(e.prompt)
+
+	width := term.StringWidth(e.prompt)
+	row, col := width/cols, width%cols
+	cursorRow, cursorCol := row, col
+	for i, r := range e.buf {
+		if i == e.pos {
+			cursorRow, cursorCol = row, col
+		}
+		if r == '\n' {
+			buf.WriteString("\r\n" + continuationPrompt)
+			row, col = row+1, term.StringWidth(continuationPrompt)
+			continue
+		}
+		w := term.RuneWidth(r)
+		if col+w > cols {
+			buf.WriteString("\r\n")
+			row, col = row+1, 0
+			if i == e.pos {
+				cursorRow, cursorCol = row, col
+			}
+		}
+		buf.WriteRune(r)
+		col += w
+	}
+	if e.pos == len(e.buf) {
+		cursorRow, cursorCol = row, col
+	}
+	// a full row leaves the cursor on its last column until more is written
+	if col >= cols {
+		buf.WriteString("\r\n")
+		row, col = row+1, 0
+	}
+	if cursorCol >= cols {
+		cursorRow, cursorCol = cursorRow+1, 0
+	}
+
+	if row > cursorRow {
+		fmt.Fprintf(&buf, "\x1b[%dA", row-cursorRow)
+	}
+	buf.WriteString("\r")
+	if cursorCol > 0 {
+		fmt.Fprintf(&buf, "\x1b[%dC", cursorCol)
+	}
+	e.rows = cursorRow
+	io.WriteString(e.out, buf.String())
+}
@@ -0,0 +1,203 @@
+package editor
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// keys is read a key at a time, as a terminal in raw mode gives them.
+type keys []string
+
+func (k *keys) Read(p []byte) (int, error) {
+	if len(*k) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*k)[0])
+	if n == len((*k)[0]) {
+		*k = (*k)[1:]
+	} else {
+		(*k)[0] = (*k)[0][n:]
+	}
+	return n, nil
+}
+
+func newTestEditor(history *History, input ...string) (*Editor, *strings.Builder) {
+	var out strings.Builder
+	k := keys(input)
+	e := &Editor{
+		reader:  bufio.NewReader(&k),
+		out:     &out,
+		width:   func() int { return 10 },
+		history: history,
+	}
+	return e, &out
+}
+
+func TestEdit(t *testing.T) {
+	_assert := assert.New(t)
+
+	history, err := LoadHistory("", DefaultHistorySize)
+	_assert.Nil(err)
+
+	for _, c := range []struct {
+		keys []string
+		line string
+	}{
+		{[]string{"h", "e", "l", "o", "\x1b[D", "l", "\r"}, "hello"},
+		{[]string{"a", "b", "\x01", "x", "\x05", "y", "\r"}, "xaby"},
+		{[]string{"o", "n", "e", " ", "t", "w", "o", "\x17", "\x7f", "\r"}, "one"},
+		{[]string{"o", "n", "e", " ", "t", "w", "o", "\x1bb", "\x0b", "\x01", "\x19", "\r"}, "twoone "},
+		{[]string{"你", "好", "世", "界", "\x1b[1;5D", "\x1bd", "\r"}, ""},
+		{[]string{"你", "好", " ", "世", "界", "\x1b[1;5D", "\x1b\x7f", "\r"}, "世界"},
+		{[]string{"a", "\x1b\r", "b", "\n", "c", "\r"}, "a\nb\nc"},
+		{[]string{"a", "\\", "\r", "b", "\r"}, "a\nb"},
+		{[]string{"a\rb\r"}, "a\nb"},
+		{[]string{"\x1b[200~", "x\ty\r\nz\x1b[201~", "\r"}, "x    y\nz"},
+		{[]string{"a", "\x1b[A", "\x1b[B", "\r"}, "a"},
+	} {
+		e, _ := newTestEditor(history, c.keys...)
+		line, err := e.edit("> ")
+		_assert.Nil(err)
+		_assert.Equal(c.line, line, c.keys)
+	}
+
+	e, _ := newTestEditor(history, "x", "\x03")
+	_, err = e.edit("> ")
+	_assert.Equal(ErrInterrupted, err)
+	e, _ = newTestEditor(history, "\x04")
+	_, err = e.edit("> ")
+	_assert.Equal(io.EOF, err)
+}
+
+func TestHistory(t *testing.T) {
+	_assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "history", "chat.history")
+	history, err := LoadHistory(path, 3)
+	_assert.Nil(err)
+	for _, line := range []string{"first", "second", "second", " secret", "multi\nline \\n", "third", "fourth"} {
+		_assert.Nil(history.Add(line))
+	}
+	_assert.Equal(3, history.Len())
+
+	history, err = LoadHistory(path, 3)
+	_assert.Nil(err)
+	_assert.Equal([]string{"multi\nline \\n", "third", "fourth"}, history.entries)
+
+	// the file is written again once it holds twice the entries kept
+	_assert.Nil(history.Add("fifth"))
+	_assert.Nil(history.Add("sixth"))
+	history, err = LoadHistory(path, 3)
+	_assert.Nil(err)
+	buf, err := os.ReadFile(path)
+	_assert.Nil(err)
+	_assert.Equal("fourth\nfifth\nsixth\n", string(buf))
+
+	// Up walks back, Down comes back to the line being written
+	e, _ := newTestEditor(history, "x", "\x1b[A", "\x1b[A", "\x1b[B", "\x1b[B", "\r")
+	line, err := e.edit("> ")
+	_assert.Nil(err)
+	_assert.Equal("x", line)
+	e, _ = newTestEditor(history, "\x10", "\x10", "\r")
+	line, err = e.edit("> ")
+	_assert.Nil(err)
+	_assert.Equal("sixth", line)
+
+	// reverse-i-search, the lines read are kept as well
+	history, err = LoadHistory(path, DefaultHistorySize)
+	_assert.Nil(err)
+	_assert.Nil(history.Add("git diff"))
+	_assert.Nil(history.Add("go test"))
+	for _, c := range []struct {
+		keys []string
+		line string
+	}{
+		{[]string{"\x12", "t", "\r"}, "go test"},
+		{[]string{"\x12", "t", "\x12", "\r"}, "git diff"},
+		{[]string{"\x12", "x", "t", "\x05", "!", "\r"}, "sixth!"},
+		{[]string{"a", "\x12", "z", "z", "\x07", "\r"}, "a"},
+	} {
+		e, _ := newTestEditor(history, c.keys...)
+		line, err := e.edit("> ")
+		_assert.Nil(err)
+		_assert.Equal(c.line, line, c.keys)
+	}
+
+	// a history file which cannot be written fails the first line only
+	path = filepath.Join(t.TempDir(), "chat.history")
+	history, err = LoadHistory(path, DefaultHistorySize)
+	_assert.Nil(err)
+	_assert.Nil(os.Mkdir(path, 0o755))
+	e, _ = newTestEditor(history, "first\r")
+	line, err = e.edit("> ")
+	_assert.ErrorIs(err, ErrHistory)
+	_assert.Equal("first", line)
+	e, _ = newTestEditor(history, "second\r")
+	line, err = e.edit("> ")
+	_assert.Nil(err)
+	_assert.Equal("second", line)
+	_assert.Equal(2, history.Len())
+}
+
+func TestComplete(t *testing.T) {
+	_assert := assert.New(t)
+
+	history, _ := LoadHistory("", DefaultHistorySize)
+	complete := func(line string) []string {
+		var data []string
+		for _, c := range []string{"/model deepseek-chat", "/model deepseek-reasoner", "/models"} {
+			if strings.HasPrefix(c, line) {
+				data = append(data, c)
+			}
+		}
+		return data
+	}
+
+	e, out := newTestEditor(history, "/", "m", "o", "\t", " ", "\t", "\t", "\t", "c", "\t", "\r")
+	e.complete = complete
+	line, err := e.edit("> ")
+	_assert.Nil(err)
+	_assert.Equal("/model deepseek-chat", line)
+	_assert.Contains(out.String(), "\r\ndeepseek-chat  deepseek-reasoner\r\n")
+
+	// the common prefix is cut at a whole rune, 你 and 们 share a first byte
+	e, _ = newTestEditor(history, "/l", "\t", "\r")
+	e.complete = func(string) []string { return []string{"/load 你", "/load 们"} }
+	line, err = e.edit("> ")
+	_assert.Nil(err)
+	_assert.Equal("/load ", line)
+}
+
+func TestRefresh(t *testing.T) {
+	_assert := assert.New(t)
+
+	history, _ := LoadHistory("", DefaultHistorySize)
+	e, out := newTestEditor(history)
+	e.prompt = "> "
+
+	// the prompt and 4 wide characters fill the row of 10 columns, the cursor
+	// goes to the next one
+	e.buf, e.pos = []rune("你好世界"), 4
+	e.refresh()
+	_assert.Equal("\r\x1b[J> 你好世界\r\n\r", out.String())
+	_assert.Equal(1, e.rows)
+
+	// a wide character which does not fit goes to the next row
+	out.Reset()
+	e.buf, e.pos = []rune("a你好世界"), 1
+	e.refresh()
+	_assert.Equal("\x1b[1A\r\x1b[J> a你好世\r\n界\x1b[1A\r\x1b[3C", out.String())
+	_assert.Equal(0, e.rows)
+
+	out.Reset()
+	e.buf, e.pos = []rune("ab\ncd"), 4
+	e.refresh()
+	_assert.Equal("\r\x1b[J> ab\r\n. cd\r\x1b[3C", out.String())
+	_assert.Equal(1, e.rows)
+}
@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eviltomorrow/open-terminal/lib/fs"
+)
+
+// DefaultHistorySize is the number of entries a history keeps.
+const DefaultHistorySize = 1000
+
+// History is the input history kept in a file, an entry a line with its
+// newlines and backslashes escaped. Entries starting with a space are not
+// kept, neither is an entry equal to the one before it.
+type History struct {
+	path    string
+	size    int
+	entries []string
+}
+
+// LoadHistory loads the history kept in path, which is created on the first
+// entry added. An empty path keeps the history in memory only.
+func LoadHistory(path string, size int) (*History, error) {
+	h := &History{path: path, size: size}
+	if path == "" {
+		return h, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history failure, nest error: %v", err)
+	}
+	defer file.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		lines++
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, unescape(line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history failure, nest error: %v", err)
+	}
+	if len(h.entries) > size {
+		h.entries = h.entries[len(h.entries)-size:]
+	}
+	// the file is only appended to, it is written again once it doubles
+	if lines > 2*size {
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// Entry returns the i-th entry, the oldest first.
+func (h *History) Entry(i int) string {
+	return h.entries[i]
+}
+
+// Add keeps entry and appends it to the file. Once the file fails to be
+// written the history is kept in memory only, so the failure is returned once.
+func (h *History) Add(entry string) error {
+	if strings.TrimSpace(entry) == "" || strings.HasPrefix(entry, " ") {
+		return nil
+	}
+	if len(h.entries) != 0 && h.entries[len(h.entries)-1] == entry {
+		return nil
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	if err := h.append(entry); err != nil {
+		h.path = ""
+		return err
+	}
+	return nil
+}
+
+func (h *History) append(entry string) error {
+	if err := fs.MkdirAll(filepath.Dir(h.path)); err != nil {
+		return fmt.Errorf("create history dir failure, nest error: %v", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open history failure, nest error: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(escape(entry) + "\n"); err != nil {
+		return fmt.Errorf("write history failure, nest error: %v", err)
+	}
+	return nil
+}
+
+// Search returns the latest entry before from which contains query, with the
+// rune offset of the match in it. The index is -1 when none does.
+func (h *History) Search(query string, from int) (int, int) {
+	for i := min(from, len(h.entries)) - 1; i >= 0; i-- {
+		if offset := strings.Index(h.entries[i], query); offset >= 0 {
+			return i, len([]rune(h.entries[i][:offset]))
+		}
+	}
+	return -1, 0
+}
+
+func (h *History) rewrite() error {
+	tmp := h.path + ".tmp"
+	var buf strings.Builder
+	for _, entry := range h.entries {
+		buf.WriteString(escape(entry) + "\n")
+	}
+	if err := os.WriteFile(tmp, []byte(buf.String()), 0o600); err != nil {
+		return fmt.Errorf("write history failure, nest error: %v", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("replace history failure, nest error: %v", err)
+	}
+	return nil
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+func escape(entry string) string {
+	return escaper.Replace(entry)
+}
+
+func unescape(line string) string {
+	return unescaper.Replace(line)
+}
@@ -0,0 +1,175 @@
+// Package chattest serves a fake open-server on an in-memory listener, so that
+// the packages of the terminal talking to it can be tested without one.
+package chattest
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// SessionId is the session of a stream whose turns do not name one.
+const SessionId = "Kimi-1"
+
+// Reply is the scripted answer of one turn. Deltas are sent one by one,
+// followed by Done. A reply without Done leaves the turn open until it is
+// stopped, Hangup ends the stream after the reply.
+type Reply struct {
+	Deltas []string
+	Done   *pb.TurnComplete
+	Hangup bool
+}
+
+// Echo answers a turn with its content.
+func Echo(turn *pb.ChatReq) (*Reply, error) {
+	return &Reply{Deltas: []string{"echo: " + turn.Content}, Done: &pb.TurnComplete{FinishReason: "stop"}}, nil
+}
+
+// Server is a fake open-server. Chat answers every turn by Answer, Echo when
+// it is nil, and a REGENERATE control by Regenerate with the turn asked last,
+// the control is only kept when it is nil. A failure of either ends the
+// stream with it. The other methods are unimplemented unless a test wraps the
+// server with them.
+type Server struct {
+	pb.UnimplementedOpenAIServer
+
+	Answer     func(turn *pb.ChatReq) (*Reply, error)
+	Regenerate func(last *pb.ChatReq) (*Reply, error)
+
+	mu       sync.Mutex
+	turns    []*pb.ChatReq
+	controls []*pb.Control
+	metadata []metadata.MD
+}
+
+func (s *Server) Chat(stream grpc.BidiStreamingServer[pb.ChatFrame, pb.ChatEvent]) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	s.mu.Lock()
+	s.metadata = append(s.metadata, md)
+	s.mu.Unlock()
+
+	var (
+		sessionId = SessionId
+		n         int32
+		last      *pb.ChatReq
+		open      bool
+	)
+	complete := func(done *pb.TurnComplete) error {
+		if done.SessionId == "" {
+			done.SessionId = sessionId
+		}
+		done.Turn, open = n, false
+		return stream.Send(&pb.ChatEvent{Event: &pb.ChatEvent_TurnComplete{TurnComplete: done}})
+	}
+	reply := func(r *Reply, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		n++
+		open = true
+		for _, delta := range r.Deltas {
+			if err := stream.Send(&pb.ChatEvent{Event: &pb.ChatEvent_Delta{Delta: &pb.ChatResp{Message: &pb.Message{Content: delta}}}}); err != nil {
+				return false, err
+			}
+		}
+		if r.Done != nil {
+			if err := complete(r.Done); err != nil {
+				return false, err
+			}
+		}
+		return r.Hangup, nil
+	}
+
+	for {
+		frame, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var hangup bool
+		if ctl := frame.GetControl(); ctl != nil {
+			s.mu.Lock()
+			s.controls = append(s.controls, ctl)
+			s.mu.Unlock()
+
+			switch {
+			case ctl.Action == pb.Control_STOP && open:
+				err = complete(&pb.TurnComplete{Stopped: true})
+			case ctl.Action == pb.Control_REGENERATE && s.Regenerate != nil && last != nil:
+				hangup, err = reply(s.Regenerate(last))
+			}
+		} else if turn := frame.GetTurn(); turn != nil {
+			s.mu.Lock()
+			s.turns = append(s.turns, turn)
+			s.mu.Unlock()
+
+			if turn.SessionId != "" {
+				sessionId = turn.SessionId
+			}
+			last = turn
+			answer := s.Answer
+			if answer == nil {
+				answer = Echo
+			}
+			hangup, err = reply(answer(turn))
+		}
+		if err != nil || hangup {
+			return err
+		}
+	}
+}
+
+// Turns returns the turns received so far.
+func (s *Server) Turns() []*pb.ChatReq {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*pb.ChatReq(nil), s.turns...)
+}
+
+// Controls returns the controls received so far.
+func (s *Server) Controls() []*pb.Control {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*pb.Control(nil), s.controls...)
+}
+
+// Metadata returns the metadata of the streams opened so far.
+func (s *Server) Metadata() []metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]metadata.MD(nil), s.metadata...)
+}
+
+// Dial serves srv on an in-memory listener and returns a client of it, both
+// are closed when the test ends.
+func Dial(t testing.TB, srv pb.OpenAIServer) pb.OpenAIClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterOpenAIServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient failure, nest error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewOpenAIClient(conn)
+}
@@ -0,0 +1,234 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	keywordAttrs = []color.Attribute{color.FgMagenta}
+	stringAttrs  = []color.Attribute{color.FgGreen}
+	numberAttrs  = []color.Attribute{color.FgYellow}
+	commentAttrs = []color.Attribute{color.FgHiBlack, color.Italic}
+)
+
+// language is what the highlighter knows of the code of a fenced block.
+type language struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+}
+
+func newLanguage(keywords string, lineComments []string, blockComment [2]string, quotes string) *language {
+	lang := &language{
+		keywords:     make(map[string]bool),
+		lineComments: lineComments,
+		blockComment: blockComment,
+		quotes:       quotes,
+	}
+	for _, keyword := range strings.Fields(keywords) {
+		lang.keywords[keyword] = true
+	}
+	return lang
+}
+
+var (
+	cStyleComment = [2]string{"/*", "*/"}
+
+	golang = newLanguage(`break case chan const continue default defer else fallthrough for func go goto if import
+		interface map package range return select struct switch type var true false nil iota
+		any bool byte error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr
+		append cap close copy delete len make new panic print println recover`,
+		[]string{"//"}, cStyleComment, "\"'`")
+	python = newLanguage(`and as assert async await break class continue def del elif else except finally for from
+		global if import in is lambda nonlocal not or pass raise return try while with yield True False None self print`,
+		[]string{"#"}, [2]string{}, "\"'")
+	javascript = newLanguage(`async await break case catch class const continue debugger default delete do else export
+		extends finally for from function if import in instanceof interface let new of return static super switch this
+		throw try type typeof var void while yield true false null undefined`,
+		[]string{"//"}, cStyleComment, "\"'`")
+	rust = newLanguage(`as async await break const continue crate dyn else enum extern fn for if impl in let loop match
+		mod move mut pub ref return self Self static struct super trait type unsafe use where while true false
+		Some None Ok Err String Vec Option Result`,
+		[]string{"//"}, cStyleComment, "\"")
+	java = newLanguage(`abstract boolean break byte case catch char class const continue default do double else enum
+		extends final finally float for if implements import instanceof int interface long new package private
+		protected public return short static super switch synchronized this throw throws try void volatile while
+		true false null var record`,
+		[]string{"//"}, cStyleComment, "\"'")
+	clang = newLanguage(`auto bool break case char class const constexpr continue default delete do double else enum
+		extern float for goto if include define inline int long namespace new nullptr private protected public
+		return short signed sizeof static struct switch template this typedef union unsigned using virtual void
+		volatile while true false NULL`,
+		[]string{"//"}, cStyleComment, "\"'")
+	shell = newLanguage(`if then else elif fi for while until do done case esac in function return local export
+		readonly set unset shift exit source echo cd sudo`,
+		[]string{"#"}, [2]string{}, "\"'")
+	sql = newLanguage(`select from where and or not insert into values update set delete create table drop alter
+		index primary key foreign references join left right inner outer on group by order having limit offset
+		as distinct union all null is in like between case when then else end exists default
+		SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE DROP ALTER
+		INDEX PRIMARY KEY FOREIGN REFERENCES JOIN LEFT RIGHT INNER OUTER ON GROUP BY ORDER HAVING LIMIT OFFSET
+		AS DISTINCT UNION ALL NULL IS IN LIKE BETWEEN CASE WHEN THEN ELSE END EXISTS DEFAULT`,
+		[]string{"--"}, cStyleComment, "'\"")
+	data = newLanguage(`true false null yes no on off`, []string{"#"}, [2]string{}, "\"'")
+)
+
+var languages = map[string]*language{
+	"go":         golang,
+	"golang":     golang,
+	"python":     python,
+	"py":         python,
+	"javascript": javascript,
+	"js":         javascript,
+	"jsx":        javascript,
+	"typescript": javascript,
+	"ts":         javascript,
+	"tsx":        javascript,
+	"rust":       rust,
+	"rs":         rust,
+	"java":       java,
+	"kotlin":     java,
+	"c":          clang,
+	"h":          clang,
+	"cpp":        clang,
+	"c++":        clang,
+	"cc":         clang,
+	"hpp":        clang,
+	"sh":         shell,
+	"bash":       shell,
+	"zsh":        shell,
+	"shell":      shell,
+	"console":    shell,
+	"sql":        sql,
+	"json":       data,
+	"yaml":       data,
+	"yml":        data,
+	"toml":       data,
+	"ini":        data,
+}
+
+// lookupLanguage returns the language named by the info string of a fence,
+// nil when it is not known and the code is not highlighted.
+func lookupLanguage(info string) *language {
+	name, _, _ := strings.Cut(info, " ")
+	name = strings.TrimPrefix(strings.ToLower(name), "{.")
+	return languages[strings.TrimRight(name, "}")]
+}
+
+// highlighter colours the code of a fenced block line by line, a block
+// comment carries over to the lines after it.
+type highlighter struct {
+	lang    *language
+	comment bool
+}
+
+func (h *highlighter) highlight(line string) string {
+	if h.lang == nil {
+		return line
+	}
+
+	var buf strings.Builder
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		if h.comment {
+			end := strings.Index(rest, h.lang.blockComment[1])
+			if end < 0 {
+				buf.WriteString(paint(rest, commentAttrs))
+				break
+			}
+			end += len(h.lang.blockComment[1])
+			buf.WriteString(paint(rest[:end], commentAttrs))
+			h.comment = false
+			i += end
+			continue
+		}
+
+		if open := h.lang.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			buf.WriteString(paint(open, commentAttrs))
+			h.comment = true
+			i += len(open)
+			continue
+		}
+		if h.isLineComment(line, i) {
+			buf.WriteString(paint(rest, commentAttrs))
+			break
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(h.lang.quotes, c) >= 0:
+			end := stringEnd(rest)
+			buf.WriteString(paint(rest[:end], stringAttrs))
+			i += end
+		case isDigit(c) && (i == 0 || !isWordByte(line[i-1])):
+			end := wordEnd(rest)
+			buf.WriteString(paint(rest[:end], numberAttrs))
+			i += end
+		case isWordByte(c):
+			end := wordEnd(rest)
+			if h.lang.keywords[rest[:end]] {
+				buf.WriteString(paint(rest[:end], keywordAttrs))
+			} else {
+				buf.WriteString(rest[:end])
+			}
+			i += end
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	return buf.String()
+}
+
+// isLineComment tells whether a line comment starts at i of line, a '#' only
+// starts one at the start of a word, so that $# and url#anchor are no comments.
+func (h *highlighter) isLineComment(line string, i int) bool {
+	for _, prefix := range h.lang.lineComments {
+		if !strings.HasPrefix(line[i:], prefix) {
+			continue
+		}
+		if prefix != "#" || i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+			return true
+		}
+	}
+	return false
+}
+
+// stringEnd returns the end of the string literal s starts with, or the end
+// of s when it is not closed on the line.
+func stringEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func wordEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		if !isWordByte(s[i]) && !(isDigit(s[0]) && s[i] == '.') {
+			return max(i, 1)
+		}
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isWordByte tells whether c is part of an identifier, the bytes of non-ASCII
+// runes are so that they are never split.
+func isWordByte(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/term"
+	"github.com/fatih/color"
+)
+
+var codeSpanAttrs = []color.Attribute{color.FgYellow}
+
+// inline renders the code spans and the emphasis of a line as it streams in.
+// A run of '*' is held back until the rune after it tells whether it opens or
+// closes an emphasis, spans never cross lines.
+type inline struct {
+	emit func(text string, attrs []color.Attribute)
+
+	attrs  []color.Attribute
+	code   bool
+	bold   bool
+	italic bool
+	stars  int
+	prev   rune
+	buf    strings.Builder
+}
+
+// start begins a line whose text takes attrs.
+func (in *inline) start(attrs []color.Attribute) {
+	in.attrs = attrs
+	in.code, in.bold, in.italic = false, false, false
+	in.stars, in.prev = 0, ' '
+}
+
+func (in *inline) write(s string) {
+	for _, r := range s {
+		switch {
+		case in.code:
+			if r == '`' {
+				in.flush()
+				in.code = false
+			} else {
+				in.text(r)
+			}
+		case r == '*':
+			in.stars++
+		default:
+			in.resolve(r)
+			if r == '`' {
+				in.flush()
+				in.code = true
+			} else {
+				in.text(r)
+			}
+		}
+	}
+	in.flush()
+}
+
+// end ends the line, the spans left open end with it.
+func (in *inline) end() {
+	in.resolve(' ')
+	in.flush()
+	in.code, in.bold, in.italic = false, false, false
+}
+
+// resolve turns the stars held back into emphasis, next is the rune after
+// them. A run opens when it is followed by a non-space and closes when it
+// follows one, it is literal otherwise.
+func (in *inline) resolve(next rune) {
+	n := in.stars
+	in.stars = 0
+	for n > 0 {
+		var opened *bool
+		size := 1
+		if n >= 2 {
+			opened, size = &in.bold, 2
+		} else {
+			opened = &in.italic
+		}
+
+		switch {
+		case *opened && !unicode.IsSpace(in.prev):
+		case !*opened && !unicode.IsSpace(next):
+		default:
+			for i := 0; i < size; i++ {
+				in.text('*')
+			}
+			n -= size
+			continue
+		}
+		in.flush()
+		*opened = !*opened
+		n -= size
+	}
+}
+
+func (in *inline) text(r rune) {
+	in.buf.WriteRune(r)
+	in.prev = r
+}
+
+// flush emits the text buffered in the current style.
+func (in *inline) flush() {
+	if in.buf.Len() == 0 {
+		return
+	}
+	text := in.buf.String()
+	in.buf.Reset()
+
+	if in.code {
+		in.emit(text, codeSpanAttrs)
+		return
+	}
+	attrs := in.attrs
+	if in.bold {
+		attrs = append(attrs[:len(attrs):len(attrs)], color.Bold)
+	}
+	if in.italic {
+		attrs = append(attrs[:len(attrs):len(attrs)], color.Italic)
+	}
+	in.emit(text, attrs)
+}
+
+// renderInline renders s as a whole line in attrs, it returns the width of
+// its visible text along with it.
+func renderInline(s string, attrs []color.Attribute) (string, int) {
+	var (
+		buf strings.Builder
+		n   int
+	)
+	in := inline{emit: func(text string, attrs []color.Attribute) {
+		buf.WriteString(paint(text, attrs))
+		n += term.StringWidth(text)
+	}}
+	in.start(attrs)
+	in.write(s)
+	in.end()
+	return buf.String(), n
+}
@@ -0,0 +1,324 @@
+package render
+
+import (
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	heading1Attrs = []color.Attribute{color.FgMagenta, color.Bold, color.Underline}
+	headingAttrs  = []color.Attribute{color.FgCyan, color.Bold}
+	markerAttrs   = []color.Attribute{color.FgCyan}
+	quoteAttrs    = []color.Attribute{color.Faint, color.Italic}
+	faintAttrs    = []color.Attribute{color.Faint}
+)
+
+const ruleWidth = 40
+
+// Markdown renders the markdown written to it on a terminal as it streams in.
+// The text of headings, list items, quotes and paragraphs is written as soon
+// as the block of its line is known, a code line once it is complete and a
+// table once it ends. Unless styled the markdown is written as it is, for
+// pipes and files.
+type Markdown struct {
+	out    io.Writer
+	styled bool
+
+	// pending is the start of the line until its block is known
+	pending string
+	// open is set once the block of the line is known, the rest of the line
+	// streams through inline
+	open   bool
+	inline inline
+
+	// fence is the fence of the code block being rendered, "" outside one
+	fence string
+	code  highlighter
+
+	table []string
+	err   error
+}
+
+// NewMarkdown makes a Markdown on out, styled tells whether out is a terminal.
+func NewMarkdown(out io.Writer, styled bool) *Markdown {
+	m := &Markdown{
+		out:    out,
+		styled: styled,
+	}
+	m.inline.emit = func(text string, attrs []color.Attribute) {
+		m.write(paint(text, attrs))
+	}
+	return m
+}
+
+func (m *Markdown) Write(p []byte) (int, error) {
+	return m.WriteString(string(p))
+}
+
+func (m *Markdown) WriteString(s string) (int, error) {
+	if !m.styled {
+		return io.WriteString(m.out, s)
+	}
+
+	n := len(s)
+	for {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			m.feed(s)
+			break
+		}
+		m.feed(s[:i])
+		m.endLine(true)
+		s = s[i+1:]
+	}
+	return n, m.err
+}
+
+// Flush renders what is held back, a line without its end and a table, and
+// leaves the code block. The next write starts a new document.
+func (m *Markdown) Flush() error {
+	if !m.styled {
+		return nil
+	}
+
+	if m.pending != "" || m.open {
+		m.endLine(false)
+	}
+	if len(m.table) != 0 {
+		m.renderTable()
+	}
+	m.fence, m.code = "", highlighter{}
+	err := m.err
+	m.err = nil
+	return err
+}
+
+func (m *Markdown) feed(s string) {
+	if m.open {
+		m.inline.write(s)
+		return
+	}
+
+	m.pending += s
+	if m.fence != "" {
+		return
+	}
+	if b, ok := classify(m.pending, false); ok {
+		m.start(b)
+	}
+}
+
+// endLine ends the line, newline tells whether the line ends with one.
+func (m *Markdown) endLine(newline bool) {
+	line := m.pending
+	m.pending = ""
+
+	switch {
+	case m.fence != "":
+		if isFenceEnd(line, m.fence) {
+			m.fence, m.code = "", highlighter{}
+			m.write(paint(line, faintAttrs))
+		} else {
+			m.write(m.code.highlight(line))
+		}
+	case !m.open:
+		b, _ := classify(line, true)
+		m.pending = line
+		m.start(b)
+		m.pending = ""
+		if b.kind == blockRow {
+			return
+		}
+	}
+
+	if m.open {
+		m.inline.end()
+		m.open = false
+	}
+	if newline {
+		m.write("\n")
+	}
+}
+
+// start renders the start of the pending line as the block b, whose text
+// streams in from then on.
+func (m *Markdown) start(b block) {
+	line := m.pending
+	m.pending = ""
+
+	if b.kind != blockRow && len(m.table) != 0 {
+		m.renderTable()
+	}
+
+	switch b.kind {
+	case blockBlank:
+		m.write(line)
+		return
+	case blockRule:
+		m.write(paint(strings.Repeat("─", ruleWidth), faintAttrs))
+		return
+	case blockFence:
+		m.fence = b.marker
+		m.code = highlighter{lang: lookupLanguage(b.info)}
+		m.write(paint(line, faintAttrs))
+		return
+	case blockRow:
+		m.table = append(m.table, line)
+		return
+	}
+
+	m.write(line[:b.indent])
+	var attrs []color.Attribute
+	switch b.kind {
+	case blockHeading:
+		attrs = headingAttrs
+		if b.level == 1 {
+			attrs = heading1Attrs
+		}
+	case blockBullet:
+		m.write(paint("•", markerAttrs) + " ")
+	case blockOrdered:
+		m.write(paint(b.marker, markerAttrs) + " ")
+	case blockQuote:
+		m.write(paint("│", faintAttrs) + " ")
+		attrs = quoteAttrs
+	}
+	m.open = true
+	m.inline.start(attrs)
+	m.inline.write(line[b.text:])
+}
+
+func (m *Markdown) write(s string) {
+	if m.err != nil || s == "" {
+		return
+	}
+	_, m.err = io.WriteString(m.out, s)
+}
+
+// paint wraps s in the escape sequences of attrs.
+func paint(s string, attrs []color.Attribute) string {
+	if len(attrs) == 0 || s == "" {
+		return s
+	}
+	c := color.New(attrs...)
+	c.EnableColor()
+	return c.Sprint(s)
+}
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockBlank
+	blockHeading
+	blockBullet
+	blockOrdered
+	blockQuote
+	blockRule
+	blockFence
+	blockRow
+)
+
+// block is the kind of a line and where its text starts.
+type block struct {
+	kind   blockKind
+	indent int
+	text   int
+	level  int
+	// marker is the number of an ordered item, or the fence of a code block
+	marker string
+	info   string
+}
+
+// classify tells the block of line, complete tells whether line is whole. It
+// is not ok when more of the line is needed to tell.
+func classify(line string, complete bool) (block, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent := len(line) - len(trimmed)
+	paragraph := block{kind: blockParagraph}
+	if trimmed == "" {
+		return block{kind: blockBlank}, complete
+	}
+
+	c := trimmed[0]
+	run := len(trimmed) - len(strings.TrimLeft(trimmed, string(c)))
+	switch c {
+	case '#':
+		switch {
+		case run == len(trimmed):
+			if !complete {
+				return block{}, false
+			}
+			if run <= 6 {
+				return block{kind: blockHeading, indent: indent, text: len(line), level: run}, true
+			}
+		case run <= 6 && trimmed[run] == ' ':
+			return block{kind: blockHeading, indent: indent, text: indent + run + 1, level: run}, true
+		}
+		return paragraph, true
+
+	case '`', '~':
+		switch {
+		case run >= 3:
+			if !complete {
+				return block{}, false
+			}
+			info := strings.TrimSpace(trimmed[run:])
+			if c == '`' && strings.Contains(info, "`") {
+				return paragraph, true
+			}
+			return block{kind: blockFence, marker: trimmed[:run], info: info}, true
+		case run == len(trimmed) && !complete:
+			return block{}, false
+		}
+		return paragraph, true
+
+	case '|':
+		return block{kind: blockRow}, complete
+
+	case '>':
+		if len(trimmed) == 1 && !complete {
+			return block{}, false
+		}
+		text := indent + 1
+		if len(trimmed) > 1 && trimmed[1] == ' ' {
+			text++
+		}
+		return block{kind: blockQuote, indent: indent, text: text}, true
+
+	case '-', '*', '+', '_':
+		if strings.Trim(trimmed, string(c)+" ") == "" {
+			if !complete {
+				return block{}, false
+			}
+			if c != '+' && strings.Count(trimmed, string(c)) >= 3 {
+				return block{kind: blockRule}, true
+			}
+		}
+		if c != '_' && len(trimmed) > 1 && trimmed[1] == ' ' {
+			return block{kind: blockBullet, indent: indent, text: indent + 2}, true
+		}
+		return paragraph, true
+
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		digits := len(trimmed) - len(strings.TrimLeft(trimmed, "0123456789"))
+		switch {
+		case digits == len(trimmed) || (digits+1 == len(trimmed) && (trimmed[digits] == '.' || trimmed[digits] == ')')):
+			if !complete {
+				return block{}, false
+			}
+		case digits <= 9 && digits+1 < len(trimmed) && (trimmed[digits] == '.' || trimmed[digits] == ')') && trimmed[digits+1] == ' ':
+			return block{kind: blockOrdered, indent: indent, text: indent + digits + 2, marker: trimmed[:digits+1]}, true
+		}
+		return paragraph, true
+	}
+	return paragraph, true
+}
+
+// isFenceEnd tells whether line closes the code block opened by fence.
+func isFenceEnd(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
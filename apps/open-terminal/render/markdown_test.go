@@ -0,0 +1,132 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func renderMarkdown(chunks ...string) string {
+	var buf strings.Builder
+	m := NewMarkdown(&buf, true)
+	for _, chunk := range chunks {
+		m.WriteString(chunk)
+	}
+	m.Flush()
+	return buf.String()
+}
+
+// runes splits s into chunks of one rune, as tokens may arrive.
+func runes(s string) []string {
+	var chunks []string
+	for _, r := range s {
+		chunks = append(chunks, string(r))
+	}
+	return chunks
+}
+
+const answer = "# 标题\n" +
+	"Some **bold**, *italic* and `code`, 2 * 3 = 6.\n" +
+	"- item one\n" +
+	"12. twelve\n" +
+	"> quoted\n" +
+	"---\n" +
+	"\n" +
+	"| 名称 | Size |\n" +
+	"|:-----|-----:|\n" +
+	"| 中文 | 1 |\n" +
+	"| ascii | 12345 |\n" +
+	"\n" +
+	"```go\n" +
+	"func main() { /* start\n" +
+	"end */ x := \"s\" // done\n" +
+	"```\n" +
+	"after"
+
+func TestMarkdown(t *testing.T) {
+	_assert := assert.New(t)
+
+	whole := renderMarkdown(answer)
+	plain := escapes.ReplaceAllString(whole, "")
+	// streamed rune by rune the text is styled a chunk at a time, it reads the same
+	_assert.Equal(plain, escapes.ReplaceAllString(renderMarkdown(runes(answer)...), ""))
+
+	_assert.Equal("标题\n"+
+		"Some bold, italic and code, 2 * 3 = 6.\n"+
+		"• item one\n"+
+		"12. twelve\n"+
+		"│ quoted\n"+
+		strings.Repeat("─", ruleWidth)+"\n"+
+		"\n"+
+		"┌───────┬───────┐\n"+
+		"│ 名称  │  Size │\n"+
+		"├───────┼───────┤\n"+
+		"│ 中文  │     1 │\n"+
+		"│ ascii │ 12345 │\n"+
+		"└───────┴───────┘\n"+
+		"\n"+
+		"```go\n"+
+		"func main() { /* start\n"+
+		"end */ x := \"s\" // done\n"+
+		"```\n"+
+		"after", plain)
+
+	_assert.Contains(whole, paint("bold", []color.Attribute{color.Bold}))
+	_assert.Contains(whole, paint("code", codeSpanAttrs))
+	_assert.Contains(whole, paint("func", keywordAttrs))
+	_assert.Contains(whole, paint("end */", commentAttrs))
+	_assert.Contains(whole, paint(`"s"`, stringAttrs))
+	_assert.Contains(whole, paint("// done", commentAttrs))
+}
+
+func TestMarkdownPlain(t *testing.T) {
+	_assert := assert.New(t)
+
+	var buf strings.Builder
+	m := NewMarkdown(&buf, false)
+	for _, chunk := range runes(answer) {
+		m.WriteString(chunk)
+	}
+	_assert.Nil(m.Flush())
+	_assert.Equal(answer, buf.String())
+}
+
+func TestClassify(t *testing.T) {
+	_assert := assert.New(t)
+
+	for _, c := range []struct {
+		line     string
+		complete bool
+		kind     blockKind
+		ok       bool
+	}{
+		{"#", false, 0, false},
+		{"## a", false, blockHeading, true},
+		{"#hashtag", false, blockParagraph, true},
+		{"-", false, 0, false},
+		{"- -", false, 0, false},
+		{"- a", false, blockBullet, true},
+		{"- - -", true, blockRule, true},
+		{"**bold", false, blockParagraph, true},
+		{"1", false, 0, false},
+		{"1.5 apples", false, blockParagraph, true},
+		{"1) a", false, blockOrdered, true},
+		{"``", false, 0, false},
+		{"```py", false, 0, false},
+		{"```py", true, blockFence, true},
+		{"`x` y", false, blockParagraph, true},
+		{"| a", false, 0, false},
+		{"   ", true, blockBlank, true},
+	} {
+		b, ok := classify(c.line, c.complete)
+		_assert.Equal(c.ok, ok, c.line)
+		if ok {
+			_assert.Equal(c.kind, b.kind, c.line)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var tableHeaderAttrs = []color.Attribute{color.Bold}
+
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+type cell struct {
+	text  string
+	width int
+}
+
+// renderTable renders the rows read so far as a table aligned by the width of
+// their visible text, wide CJK characters take two cells. Rows without a
+// delimiter row under the header are no table and render as paragraphs.
+func (m *Markdown) renderTable() {
+	rows := m.table
+	m.table = nil
+
+	if len(rows) < 2 || !isDelimiterRow(rows[1]) {
+		for _, row := range rows {
+			text, _ := renderInline(row, nil)
+			m.write(text + "\n")
+		}
+		return
+	}
+
+	aligns := parseAlignments(splitRow(rows[1]))
+	table := make([][]cell, 0, len(rows)-1)
+	for i, row := range rows {
+		if i == 1 {
+			continue
+		}
+		var attrs []color.Attribute
+		if i == 0 {
+			attrs = tableHeaderAttrs
+		}
+		var cells []cell
+		for _, s := range splitRow(row) {
+			text, width := renderInline(s, attrs)
+			cells = append(cells, cell{text: text, width: width})
+		}
+		table = append(table, cells)
+	}
+
+	var widths []int
+	for _, cells := range table {
+		for i, c := range cells {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], c.width)
+		}
+	}
+
+	m.write(border("┌", "┬", "┐", widths))
+	for i, cells := range table {
+		var buf strings.Builder
+		buf.WriteString(paint("│", faintAttrs))
+		for j, width := range widths {
+			var c cell
+			if j < len(cells) {
+				c = cells[j]
+			}
+			align := alignLeft
+			if j < len(aligns) {
+				align = aligns[j]
+			}
+			buf.WriteString(" " + pad(c, width, align) + " ")
+			buf.WriteString(paint("│", faintAttrs))
+		}
+		m.write(buf.String() + "\n")
+		if i == 0 {
+			m.write(border("├", "┼", "┤", widths))
+		}
+	}
+	m.write(border("└", "┴", "┘", widths))
+}
+
+func border(left, middle, right string, widths []int) string {
+	var segments []string
+	for _, width := range widths {
+		segments = append(segments, strings.Repeat("─", width+2))
+	}
+	return paint(left+strings.Join(segments, middle)+right, faintAttrs) + "\n"
+}
+
+func pad(c cell, width int, align alignment) string {
+	space := width - c.width
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", space) + c.text
+	case alignCenter:
+		return strings.Repeat(" ", space/2) + c.text + strings.Repeat(" ", space-space/2)
+	default:
+		return c.text + strings.Repeat(" ", space)
+	}
+}
+
+// splitRow returns the cells of a table row, a '|' escaped or inside a code
+// span does not split them.
+func splitRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+
+	var (
+		cells []string
+		buf   strings.Builder
+		code  bool
+	)
+	for i := 0; i < len(row); i++ {
+		switch c := row[i]; {
+		case c == '\\' && i+1 < len(row) && row[i+1] == '|':
+			buf.WriteByte('|')
+			i++
+		case c == '`':
+			code = !code
+			buf.WriteByte(c)
+		case c == '|' && !code:
+			cells = append(cells, strings.TrimSpace(buf.String()))
+			buf.Reset()
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(buf.String()))
+}
+
+func isDelimiterRow(row string) bool {
+	for _, c := range splitRow(row) {
+		if strings.Trim(c, ":") == "" || strings.Trim(strings.Trim(c, ":"), "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseAlignments(cells []string) []alignment {
+	aligns := make([]alignment, 0, len(cells))
+	for _, c := range cells {
+		switch {
+		case strings.HasPrefix(c, ":") && strings.HasSuffix(c, ":"):
+			aligns = append(aligns, alignCenter)
+		case strings.HasSuffix(c, ":"):
+			aligns = append(aligns, alignRight)
+		default:
+			aligns = append(aligns, alignLeft)
+		}
+	}
+	return aligns
+}
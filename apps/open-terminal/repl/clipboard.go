@@ -0,0 +1,35 @@
+package repl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// clipboardTools are tried in order, the first which succeeds wins.
+var clipboardTools = [][]string{
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"pbcopy"},
+}
+
+// copyToClipboard puts text on the clipboard with a clipboard tool, or with
+// the OSC 52 sequence of the terminal when none works, such as over ssh.
+func copyToClipboard(out io.Writer, text string) error {
+	for _, tool := range clipboardTools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+
+	_, err := fmt.Fprintf(out, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
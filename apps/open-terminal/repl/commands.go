@@ -0,0 +1,457 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// rpcTimeout bounds the unary calls made by the commands.
+const rpcTimeout = 10 * time.Second
+
+var errQuit = errors.New("quit")
+
+type command struct {
+	name  string
+	args  string
+	usage string
+	run   func(r *REPL, arg string) error
+	// complete returns the candidates of the argument, nil when it takes none.
+	complete func(r *REPL, arg string) []string
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "/model", args: "[name]", usage: "list the models or switch to one, the session keeps its history unless the provider changes", run: (*REPL).model, complete: (*REPL).completeModel},
+		{name: "/temp", args: "[value]", usage: "show or set the temperature of the turns that follow", run: (*REPL).temperature},
+		{name: "/system", args: "[prompt|-]", usage: "show, set or drop (-) the system prompt of the session", run: (*REPL).system},
+		{name: "/reset", usage: "close the session and start a new one with the same settings", run: (*REPL).reset},
+		{name: "/save", args: "[file]", usage: "keep the session on the server for /load, and write the transcript to file", run: (*REPL).save, complete: (*REPL).completeFile},
+		{name: "/load", args: "<id>", usage: "continue a saved session", run: (*REPL).load, complete: (*REPL).completeSession},
+		{name: "/sessions", usage: "list the sessions alive on the server", run: (*REPL).sessions},
+		{name: "/retry", usage: "drop the last answer and ask its question again", run: (*REPL).retry},
+		{name: "/copy", usage: "copy the last answer to the clipboard", run: (*REPL).copy},
+		{name: "/help", usage: "show this help", run: (*REPL).help},
+		{name: "/exit", usage: "quit, so does Ctrl-D", run: func(*REPL, string) error { return errQuit }},
+	}
+}
+
+func lookupCommand(name string) (*command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	if name == "/quit" {
+		return lookupCommand("/exit")
+	}
+	return nil, false
+}
+
+// command runs a slash command line.
+func (r *REPL) command(line string) error {
+	name, arg, _ := strings.Cut(line, " ")
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		return fmt.Errorf("unknown command %s, /help lists them", name)
+	}
+	return cmd.run(r, strings.TrimSpace(arg))
+}
+
+// Complete returns the lines line may be completed to, the command names
+// first and then their arguments.
+func (r *REPL) Complete(line string) []string {
+	if !strings.HasPrefix(line, "/") {
+		return nil
+	}
+
+	name, arg, ok := strings.Cut(line, " ")
+	if !ok {
+		var data []string
+		for _, cmd := range commands {
+			if strings.HasPrefix(cmd.name, name) {
+				if cmd.args != "" {
+					data = append(data, cmd.name+" ")
+				} else {
+					data = append(data, cmd.name)
+				}
+			}
+		}
+		return data
+	}
+
+	cmd, found := lookupCommand(name)
+	if !found || cmd.complete == nil {
+		return nil
+	}
+	var data []string
+	for _, candidate := range cmd.complete(r, strings.TrimLeft(arg, " ")) {
+		data = append(data, name+" "+candidate)
+	}
+	return data
+}
+
+func (r *REPL) help(string) error {
+	w := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.usage)
+	}
+	fmt.Fprintln(w, "  Ctrl-C\tstop the answer, twice to give up waiting for the server")
+	return w.Flush()
+}
+
+func (r *REPL) model(arg string) error {
+	models, err := r.listModels(arg == "")
+	if err != nil {
+		return err
+	}
+	current, _, _ := r.conv.Settings()
+
+	if arg == "" {
+		w := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+		for _, m := range models {
+			if m.Kind != pb.ModelKind_CHAT {
+				continue
+			}
+			mark := " "
+			if current == m.Name || current == m.Provider+"/"+m.Name || (current == "" && m.Default) {
+				mark = "*"
+			}
+			fmt.Fprintf(w, "%s %s/%s\t%s\t%s\t%s\t%s\n", mark, m.Provider, m.Name, formatWindow(m.ContextWindow), formatAbilities(m), formatPricing(m.Pricing), formatModelStatus(m.Status))
+		}
+		return w.Flush()
+	}
+
+	for _, m := range models {
+		if m.Kind != pb.ModelKind_CHAT || (arg != m.Name && arg != m.Provider+"/"+m.Name) {
+			continue
+		}
+		if m.Status == pb.ModelStatus_UNAVAILABLE {
+			return fmt.Errorf("model is not served by provider %s now, model: %s", m.Provider, m.Name)
+		}
+
+		// the server only switches models within the provider of a session,
+		// another provider takes a new session
+		provider, err := r.sessionProvider()
+		if err != nil {
+			return err
+		}
+		if provider != "" && provider != m.Provider {
+			fmt.Fprintf(r.out, "the session is served by provider %s, %s/%s starts a new one\n", provider, m.Provider, m.Name)
+			if err := r.reset(""); err != nil {
+				return err
+			}
+		}
+		r.conv.SetModel(arg)
+		fmt.Fprintf(r.out, "model: %s\n", arg)
+		return nil
+	}
+	return fmt.Errorf("model not found, /model lists them, model: %s", arg)
+}
+
+// sessionProvider returns the provider of the session being continued, it is
+// empty when no question is asked yet or the server has dropped the session.
+func (r *REPL) sessionProvider() (string, error) {
+	id := r.conv.SessionId()
+	if id == "" {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	s, err := r.client.OpenSession(ctx, wrapperspb.String(id))
+	if status.Code(err) == codes.NotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.Provider, nil
+}
+
+func (r *REPL) temperature(arg string) error {
+	if arg == "" {
+		_, temperature, _ := r.conv.Settings()
+		if temperature == nil {
+			fmt.Fprintln(r.out, "temperature: default of the model")
+		} else {
+			fmt.Fprintf(r.out, "temperature: %g\n", *temperature)
+		}
+		return nil
+	}
+
+	val, err := strconv.ParseFloat(arg, 32)
+	if err != nil || val < 0 {
+		return fmt.Errorf("temperature is not a non-negative number, temperature: %s", arg)
+	}
+	return r.conv.SetTemperature(float32(val))
+}
+
+func (r *REPL) system(arg string) error {
+	switch arg {
+	case "":
+		_, _, system := r.conv.Settings()
+		if system == nil || *system == "" {
+			fmt.Fprintln(r.out, "system prompt: none set")
+		} else {
+			fmt.Fprintf(r.out, "system prompt: %s\n", *system)
+		}
+		return nil
+	case "-":
+		return r.conv.SetSystem("")
+	default:
+		return r.conv.SetSystem(arg)
+	}
+}
+
+func (r *REPL) reset(string) error {
+	old := r.conv.SessionId()
+	if err := r.conv.Resume(""); err != nil {
+		return err
+	}
+	r.exchanges = nil
+	fmt.Fprintln(r.out, "new session")
+
+	if old == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	if _, err := r.client.CloseSession(ctx, wrapperspb.String(old)); err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	return nil
+}
+
+func (r *REPL) save(arg string) error {
+	id := r.conv.SessionId()
+	if id == "" {
+		return fmt.Errorf("nothing to save, no question is asked yet")
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	if _, err := r.client.SaveSession(ctx, wrapperspb.String(id)); err != nil {
+		return err
+	}
+	if arg != "" {
+		if err := os.WriteFile(arg, []byte(r.transcript(id)), 0644); err != nil {
+			return fmt.Errorf("write transcript failure, nest error: %v", err)
+		}
+		fmt.Fprintf(r.out, "transcript written to %s\n", arg)
+	}
+	fmt.Fprintf(r.out, "session saved, /load %s to continue it\n", id)
+	return nil
+}
+
+// transcript is the exchanges of the session in markdown.
+func (r *REPL) transcript(id string) string {
+	model, _, system := r.conv.Settings()
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "# Session %s\n\n", id)
+	if model != "" {
+		fmt.Fprintf(&buf, "- model: %s\n", model)
+	}
+	if system != nil && *system != "" {
+		fmt.Fprintf(&buf, "- system prompt: %s\n", *system)
+	}
+	fmt.Fprintf(&buf, "- saved at: %s\n", time.Now().Format(time.RFC3339))
+	for _, e := range r.exchanges {
+		fmt.Fprintf(&buf, "\n## User\n\n%s\n\n## Assistant\n\n%s\n", e.question, strings.TrimSpace(e.answer.String()))
+	}
+	return buf.String()
+}
+
+func (r *REPL) load(arg string) error {
+	if arg == "" {
+		return fmt.Errorf("session id is nil, /sessions lists them")
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	s, err := r.client.OpenSession(ctx, wrapperspb.String(arg))
+	if err != nil {
+		return err
+	}
+	if err := r.conv.Resume(s.Id); err != nil {
+		return err
+	}
+	r.conv.SetModel("")
+	r.exchanges = nil
+	fmt.Fprintf(r.out, "session %s loaded, model: %s, last active at: %s\n", s.Id, s.Model, formatTime(s.LastActiveAt))
+	return nil
+}
+
+func (r *REPL) sessions(string) error {
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	resp, err := r.client.ListSessions(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	if len(resp.Sessions) == 0 {
+		fmt.Fprintln(r.out, "no session")
+		return nil
+	}
+
+	current := r.conv.SessionId()
+	w := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tMODEL\tLAST ACTIVE\tSAVED")
+	for _, s := range resp.Sessions {
+		mark := " "
+		if s.Id == current {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%v\n", mark, s.Id, s.Model, formatTime(s.LastActiveAt), s.Persisted)
+	}
+	return w.Flush()
+}
+
+func (r *REPL) retry(string) error {
+	if len(r.exchanges) == 0 {
+		return fmt.Errorf("no answer to retry")
+	}
+
+	e := r.exchanges[len(r.exchanges)-1]
+	e.answer.Reset()
+	r.turn(e, r.conv.Regenerate)
+	return nil
+}
+
+func (r *REPL) copy(string) error {
+	if len(r.exchanges) == 0 {
+		return fmt.Errorf("no answer to copy")
+	}
+
+	answer := strings.TrimSpace(r.exchanges[len(r.exchanges)-1].answer.String())
+	if err := copyToClipboard(r.out, answer); err != nil {
+		return fmt.Errorf("copy to clipboard failure, nest error: %v", err)
+	}
+	fmt.Fprintf(r.errOut, "copied %d characters\n", len([]rune(answer)))
+	return nil
+}
+
+// listModels returns the models of the server, they are cached for the
+// completion unless refresh.
+func (r *REPL) listModels(refresh bool) ([]*pb.Model, error) {
+	if r.models != nil && !refresh {
+		return r.models, nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	resp, err := r.client.ListModels(ctx, &pb.ListModelsReq{})
+	if err != nil {
+		return nil, err
+	}
+	r.models = resp.Models
+	return r.models, nil
+}
+
+func (r *REPL) completeModel(arg string) []string {
+	models, err := r.listModels(false)
+	if err != nil {
+		return nil
+	}
+
+	var data []string
+	for _, m := range models {
+		if m.Kind != pb.ModelKind_CHAT || m.Status == pb.ModelStatus_UNAVAILABLE {
+			continue
+		}
+		for _, name := range []string{m.Name, m.Provider + "/" + m.Name} {
+			if strings.HasPrefix(name, arg) && !slices.Contains(data, name) {
+				data = append(data, name)
+			}
+		}
+	}
+	return data
+}
+
+func (r *REPL) completeSession(arg string) []string {
+	ctx, cancel := context.WithTimeout(r.ctx, rpcTimeout)
+	defer cancel()
+	resp, err := r.client.ListSessions(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil
+	}
+
+	var data []string
+	for _, s := range resp.Sessions {
+		if strings.HasPrefix(s.Id, arg) {
+			data = append(data, s.Id)
+		}
+	}
+	return data
+}
+
+func (r *REPL) completeFile(arg string) []string {
+	matches, err := filepath.Glob(arg + "*")
+	if err != nil {
+		return nil
+	}
+	for i, match := range matches {
+		if fi, err := os.Stat(match); err == nil && fi.IsDir() {
+			matches[i] = match + string(filepath.Separator)
+		}
+	}
+	return matches
+}
+
+func formatWindow(tokens int32) string {
+	if tokens >= 1024 && tokens%1024 == 0 {
+		return fmt.Sprintf("%dk", tokens/1024)
+	}
+	return strconv.Itoa(int(tokens))
+}
+
+func formatAbilities(m *pb.Model) string {
+	var data []string
+	for _, ability := range []struct {
+		name string
+		ok   bool
+	}{
+		{"vision", m.Vision},
+		{"tools", m.Tools},
+		{"json", m.JsonMode || m.JsonSchema},
+	} {
+		if ability.ok {
+			data = append(data, ability.name)
+		}
+	}
+	return strings.Join(data, ",")
+}
+
+func formatPricing(p *pb.Pricing) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%g/%g %s per 1M", p.Input, p.Output, p.Currency)
+}
+
+func formatModelStatus(s pb.ModelStatus) string {
+	if s == pb.ModelStatus_UNAVAILABLE {
+		return "unavailable"
+	}
+	return ""
+}
+
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).Format(time.DateTime)
+}
@@ -0,0 +1,260 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/chat"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/editor"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/render"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/term"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const prompt = "> "
+
+// REPL reads a question or a slash command per line and prints the streamed
+// answer. Ctrl-C stops the answer being generated, a second one gives up
+// waiting for the server, Ctrl-D or /exit quits.
+type REPL struct {
+	ctx    context.Context
+	conv   *chat.Conversation
+	client pb.OpenAIClient
+	lines  lineReader
+	out    io.Writer
+	errOut io.Writer
+	md     *render.Markdown
+
+	mu         sync.Mutex
+	generating bool
+	stopping   bool
+
+	history   *editor.History
+	exchanges []*exchange
+	models    []*pb.Model
+}
+
+// exchange is a question and its answer, kept for /save and /copy.
+type exchange struct {
+	question string
+	answer   strings.Builder
+}
+
+type Option func(*REPL)
+
+// WithHistoryForREPL keeps the lines typed in h.
+func WithHistoryForREPL(h *editor.History) Option {
+	return func(r *REPL) {
+		r.history = h
+	}
+}
+
+// New makes a REPL on conv, client serves the unary calls of the commands and
+// carries the same metadata as conv. Lines are read with the line editor when
+// both in and out are a terminal, and answers are rendered as markdown when
+// out is.
+func New(conv *chat.Conversation, client pb.OpenAIClient, in io.Reader, out io.Writer, errOut io.Writer, opts ...Option) *REPL {
+	r := &REPL{
+		ctx:    context.Background(),
+		conv:   conv,
+		client: client,
+		out:    out,
+		errOut: errOut,
+		md:     render.NewMarkdown(out, term.IsTerminal(out)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	inFile, inOk := in.(*os.File)
+	outFile, outOk := out.(*os.File)
+	if inOk && outOk && term.IsTTY(inFile) && term.IsTTY(outFile) {
+		r.lines = editor.New(inFile, out, editor.WithHistoryForEditor(r.history), editor.WithCompleteForEditor(r.Complete))
+	} else {
+		r.lines = &plainReader{in: bufio.NewReader(in), out: out}
+	}
+	return r
+}
+
+// Run serves until the input ends or ctx is done, every value received from
+// interrupts is a Ctrl-C.
+func (r *REPL) Run(ctx context.Context, interrupts <-chan os.Signal) error {
+	r.ctx = ctx
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-interrupts:
+				r.interrupt()
+			}
+		}
+	}()
+
+	for {
+		line, err := r.lines.ReadLine(prompt)
+		switch {
+		case errors.Is(err, editor.ErrHistory):
+			// the history goes on in memory, the line is still served
+			fmt.Fprintf(r.errOut, "warning: the input history is kept in memory only, %v\n", err)
+		case err == editor.ErrInterrupted:
+			fmt.Fprintln(r.errOut, "(press Ctrl-D to quit)")
+			continue
+		case err == io.EOF:
+			fmt.Fprintln(r.out)
+			return nil
+		case err != nil:
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		content := strings.TrimSpace(line)
+		switch {
+		case content == "":
+		case strings.HasPrefix(content, "/"):
+			if err := r.command(content); err == errQuit {
+				return nil
+			} else if err != nil {
+				fmt.Fprintf(r.errOut, "error: %s\n", errorMessage(err))
+			}
+		default:
+			r.ask(content)
+		}
+	}
+}
+
+func (r *REPL) ask(content string) {
+	e := &exchange{question: content}
+	r.turn(e, func(handle func(*pb.ChatResp)) (*pb.TurnComplete, error) {
+		return r.conv.Ask(content, handle)
+	})
+}
+
+// turn runs a turn made by ask, e keeps its answer and joins the exchanges
+// unless nothing is answered.
+func (r *REPL) turn(e *exchange, ask func(handle func(*pb.ChatResp)) (*pb.TurnComplete, error)) {
+	r.setGenerating(true)
+	defer r.setGenerating(false)
+
+	done, err := ask(func(resp *pb.ChatResp) {
+		if resp.Index == 0 {
+			e.answer.WriteString(resp.Json)
+			e.answer.WriteString(resp.GetMessage().GetContent())
+		}
+		r.print(resp)
+	})
+	r.complete(done, err)
+
+	if e.answer.Len() != 0 && (len(r.exchanges) == 0 || r.exchanges[len(r.exchanges)-1] != e) {
+		r.exchanges = append(r.exchanges, e)
+	}
+}
+
+// print writes a delta of the answer through the markdown renderer, tool
+// calls go to errOut so that they never mix into a piped answer.
+func (r *REPL) print(resp *pb.ChatResp) {
+	switch {
+	case resp.ToolCall != nil:
+		r.md.Flush()
+		fmt.Fprintf(r.errOut, "\n[tool] %s(%s)\n", resp.ToolCall.Name, resp.ToolCall.Arguments)
+	case resp.ToolResult != nil:
+		if resp.ToolResult.IsError {
+			fmt.Fprintf(r.errOut, "[tool] %s failed: %s\n", resp.ToolResult.Name, resp.ToolResult.Content)
+		}
+	case resp.Json != "":
+		fmt.Fprint(r.out, resp.Json)
+	case resp.Index == 0:
+		r.md.WriteString(resp.GetMessage().GetContent())
+	}
+}
+
+// complete ends the answer of a turn.
+func (r *REPL) complete(done *pb.TurnComplete, err error) {
+	r.md.Flush()
+	fmt.Fprintln(r.out)
+	switch {
+	case status.Code(err) == codes.Canceled:
+		fmt.Fprintln(r.errOut, "[aborted]")
+	case err != nil:
+		fmt.Fprintf(r.errOut, "error: %s\n", errorMessage(err))
+	case done.Stopped:
+		fmt.Fprintln(r.errOut, "[stopped]")
+	}
+	if done == nil {
+		return
+	}
+	for _, c := range done.Citations {
+		fmt.Fprintf(r.errOut, "[%d] %s:%d-%d", c.Index, c.Source, c.StartLine, c.EndLine)
+		if c.Heading != "" {
+			fmt.Fprintf(r.errOut, " (%s)", c.Heading)
+		}
+		fmt.Fprintln(r.errOut)
+	}
+}
+
+// interrupt handles Ctrl-C, the first one stops the answer, the second one
+// aborts the stream in case the server does not answer.
+func (r *REPL) interrupt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case !r.generating:
+		fmt.Fprint(r.errOut, "\n(press Ctrl-D to quit)\n"+prompt)
+	case r.stopping:
+		r.conv.Abort()
+	default:
+		r.stopping = true
+		if err := r.conv.Stop(); err != nil {
+			r.conv.Abort()
+		}
+	}
+}
+
+func (r *REPL) setGenerating(val bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generating, r.stopping = val, false
+}
+
+// errorMessage is the message of a status error without the code prefix.
+func errorMessage(err error) string {
+	if s, ok := status.FromError(err); ok {
+		if s.Code() == codes.Unavailable {
+			return "server is unavailable, " + s.Message()
+		}
+		return s.Message()
+	}
+	return err.Error()
+}
+
+// lineReader reads the input a line at a time.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainReader reads lines from a pipe or a terminal without the line editor.
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *plainReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimSuffix(line, "\n"), err
+}
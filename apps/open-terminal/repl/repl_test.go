@@ -0,0 +1,157 @@
+package repl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/chat"
+	"github.com/eviltomorrow/open-terminal/apps/open-terminal/internal/chattest"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeServer echoes every turn, a regenerated turn is echoed again with a
+// mark, and keeps the sessions it is asked to save.
+type fakeServer struct {
+	*chattest.Server
+
+	mu     sync.Mutex
+	saved  []string
+	closed []string
+}
+
+func regenerate(last *pb.ChatReq) (*chattest.Reply, error) {
+	return &chattest.Reply{Deltas: []string{"again: " + last.Content}, Done: &pb.TurnComplete{FinishReason: "stop"}}, nil
+}
+
+func (s *fakeServer) ListModels(context.Context, *pb.ListModelsReq) (*pb.Models, error) {
+	return &pb.Models{Models: []*pb.Model{
+		{Provider: "deepseek", Name: "deepseek-chat", Kind: pb.ModelKind_CHAT, Default: true},
+		{Provider: "deepseek", Name: "deepseek-reasoner", Kind: pb.ModelKind_CHAT, Status: pb.ModelStatus_UNAVAILABLE},
+		{Provider: "kimi", Name: "moonshot-v1-8k", Kind: pb.ModelKind_CHAT},
+		{Provider: "local", Name: "bge-m3", Kind: pb.ModelKind_EMBEDDING},
+	}}, nil
+}
+
+func (s *fakeServer) SaveSession(_ context.Context, id *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saved = append(s.saved, id.Value)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *fakeServer) CloseSession(_ context.Context, id *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = append(s.closed, id.Value)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *fakeServer) ListSessions(context.Context, *emptypb.Empty) (*pb.Sessions, error) {
+	return &pb.Sessions{Sessions: []*pb.Session{{Id: "Kimi-1", Model: "moonshot-v1-8k"}, {Id: "Kimi-2", Model: "moonshot-v1-8k"}}}, nil
+}
+
+func (s *fakeServer) OpenSession(_ context.Context, id *wrapperspb.StringValue) (*pb.Session, error) {
+	if id.Value != "Kimi-1" && id.Value != "Kimi-2" {
+		return nil, status.Errorf(codes.NotFound, "session not found, sessionId: %s", id.Value)
+	}
+	return &pb.Session{Id: id.Value, Model: "moonshot-v1-8k", Provider: "kimi"}, nil
+}
+
+func newTestREPL(t *testing.T, input string) (*REPL, *fakeServer, *strings.Builder, *strings.Builder) {
+	fake := &fakeServer{Server: &chattest.Server{Regenerate: regenerate}}
+	client := chattest.Dial(t, fake)
+	conv := chat.NewConversation(context.Background(), client, "")
+	t.Cleanup(func() { conv.Close() })
+
+	var out, errOut strings.Builder
+	return New(conv, client, strings.NewReader(input), &out, &errOut), fake, &out, &errOut
+}
+
+func TestCommands(t *testing.T) {
+	_assert := assert.New(t)
+
+	transcript := filepath.Join(t.TempDir(), "chat.md")
+	input := strings.Join([]string{
+		"/temp 0.3",
+		"/model deepseek-reasoner",
+		"/model kimi/moonshot-v1-8k",
+		"/system be brief",
+		"hi",
+		"/retry",
+		"/save " + transcript,
+		"/model moonshot-v1-8k",
+		"/model deepseek-chat",
+		"again",
+		"/load Kimi-9",
+		"/load Kimi-2",
+		"/reset",
+		"/nope",
+		"/exit",
+		"never asked",
+	}, "\n")
+	r, fake, out, errOut := newTestREPL(t, input)
+
+	_assert.Nil(r.Run(context.Background(), nil))
+	_assert.Contains(out.String(), "echo: hi")
+	_assert.Contains(out.String(), "again: hi")
+	_assert.Contains(out.String(), "/load Kimi-1")
+	_assert.Contains(out.String(), "the session is served by provider kimi, deepseek/deepseek-chat starts a new one\nnew session\n")
+	_assert.Contains(errOut.String(), "error: model is not served by provider deepseek now")
+	_assert.Contains(errOut.String(), "error: session not found, sessionId: Kimi-9")
+	_assert.Contains(errOut.String(), "error: unknown command /nope")
+
+	buf, err := os.ReadFile(transcript)
+	_assert.Nil(err)
+	_assert.Contains(string(buf), "# Session Kimi-1")
+	_assert.Contains(string(buf), "## User\n\nhi\n\n## Assistant\n\nagain: hi\n")
+	_assert.NotContains(string(buf), "echo: hi")
+
+	turns := fake.Turns()
+	_assert.Equal(2, len(turns))
+	_assert.Equal("kimi/moonshot-v1-8k", turns[0].Model)
+	// a model of another provider is asked in a new session
+	_assert.Equal("deepseek-chat", turns[1].Model)
+	_assert.Equal("", turns[1].SessionId)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	_assert.Equal([]string{"Kimi-1"}, fake.saved)
+	_assert.Equal([]string{"Kimi-1", "Kimi-2"}, fake.closed)
+
+	controls := fake.Controls()
+	var actions []pb.Control_Action
+	for _, ctl := range controls {
+		actions = append(actions, ctl.Action)
+	}
+	// the settings are sent again on the stream of the new session
+	_assert.Equal([]pb.Control_Action{pb.Control_SET_TEMPERATURE, pb.Control_SET_SYSTEM, pb.Control_REGENERATE, pb.Control_SET_TEMPERATURE, pb.Control_SET_SYSTEM}, actions)
+	_assert.Equal(float32(0.3), controls[0].Temperature)
+	_assert.Equal("be brief", controls[1].System)
+}
+
+func TestComplete(t *testing.T) {
+	_assert := assert.New(t)
+
+	r, _, _, _ := newTestREPL(t, "")
+
+	_assert.Equal([]string{"/system ", "/save ", "/sessions"}, r.Complete("/s"))
+	_assert.Nil(r.Complete("hello"))
+	_assert.Nil(r.Complete("/retry "))
+	_assert.Equal([]string{"/model deepseek-chat", "/model deepseek/deepseek-chat"}, r.Complete("/model deepseek"))
+	_assert.Equal([]string{"/model kimi/moonshot-v1-8k"}, r.Complete("/model ki"))
+	_assert.Equal([]string{"/load Kimi-1", "/load Kimi-2"}, r.Complete("/load Ki"))
+
+	dir := t.TempDir()
+	_assert.Nil(os.Mkdir(filepath.Join(dir, "notes"), 0755))
+	_assert.Equal([]string{"/save " + filepath.Join(dir, "notes") + "/"}, r.Complete("/save "+dir+"/no"))
+}
@@ -0,0 +1,48 @@
+package term
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// State is the mode of a terminal, to restore it after MakeRaw.
+type State struct {
+	termios unix.Termios
+}
+
+// MakeRaw puts the terminal of fd into raw mode: input is read a key at a
+// time without echo, Ctrl-C and Ctrl-Z come as keys instead of signals and
+// output is written as it is, so a newline needs "\r\n".
+func MakeRaw(fd int) (*State, error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	old := &State{termios: *termios}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// Restore puts the terminal of fd back into state.
+func Restore(fd int, state *State) error {
+	return unix.IoctlSetTermios(fd, unix.TCSETS, &state.termios)
+}
+
+// Width is the number of columns of the terminal of fd, 80 when it is not
+// told.
+func Width(fd int) int {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 {
+		return 80
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,26 @@
+package term
+
+import (
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// IsTerminal reports whether w writes to a terminal which takes colours, it
+// is false for pipes, files, TERM=dumb and when NO_COLOR is set.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTTY(f)
+}
+
+// IsTTY reports whether f is a terminal.
+func IsTTY(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
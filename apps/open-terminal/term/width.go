@@ -0,0 +1,37 @@
+package term
+
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// RuneWidth is the number of cells r takes on a terminal, wide CJK and
+// fullwidth characters take two, control and combining characters none.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case r == '\u200b' || r == '\u200d' || r == '\ufeff':
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// StringWidth is the number of cells s takes on a terminal, s holds no
+// escape sequence.
+func StringWidth(s string) int {
+	var n int
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"github.com/eviltomorrow/open-terminal/lib/grpc/client/internal"
+	pb "github.com/eviltomorrow/open-terminal/lib/grpc/pb/open-server"
+)
+
+// NewOpenAIWithTarget dials open-server at target, such as 127.0.0.1:50001,
+// the connection is made lazily by the first call.
+func NewOpenAIWithTarget(target string) (pb.OpenAIClient, func() error, error) {
+	conn, err := internal.DialWithTarget(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pb.NewOpenAIClient(conn), conn.Close, nil
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.5
+// 	protoc-gen-go v1.36.6
 // 	protoc        v5.29.3
 // source: open-ai.proto
 
@@ -9,8 +9,8 @@ package pb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
-	_ "google.golang.org/protobuf/types/known/emptypb"
-	_ "google.golang.org/protobuf/types/known/wrapperspb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -81,6 +81,209 @@ func (Role) EnumDescriptor() ([]byte, []int) {
 	return file_open_ai_proto_rawDescGZIP(), []int{0}
 }
 
+type ResponseFormat int32
+
+const (
+	ResponseFormat_TEXT        ResponseFormat = 0
+	ResponseFormat_JSON_OBJECT ResponseFormat = 1
+)
+
+// Enum value maps for ResponseFormat.
+var (
+	ResponseFormat_name = map[int32]string{
+		0: "TEXT",
+		1: "JSON_OBJECT",
+	}
+	ResponseFormat_value = map[string]int32{
+		"TEXT":        0,
+		"JSON_OBJECT": 1,
+	}
+)
+
+func (x ResponseFormat) Enum() *ResponseFormat {
+	p := new(ResponseFormat)
+	*p = x
+	return p
+}
+
+func (x ResponseFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResponseFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_open_ai_proto_enumTypes[1].Descriptor()
+}
+
+func (ResponseFormat) Type() protoreflect.EnumType {
+	return &file_open_ai_proto_enumTypes[1]
+}
+
+func (x ResponseFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResponseFormat.Descriptor instead.
+func (ResponseFormat) EnumDescriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{1}
+}
+
+type ModelKind int32
+
+const (
+	ModelKind_CHAT      ModelKind = 0
+	ModelKind_EMBEDDING ModelKind = 1
+)
+
+// Enum value maps for ModelKind.
+var (
+	ModelKind_name = map[int32]string{
+		0: "CHAT",
+		1: "EMBEDDING",
+	}
+	ModelKind_value = map[string]int32{
+		"CHAT":      0,
+		"EMBEDDING": 1,
+	}
+)
+
+func (x ModelKind) Enum() *ModelKind {
+	p := new(ModelKind)
+	*p = x
+	return p
+}
+
+func (x ModelKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ModelKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_open_ai_proto_enumTypes[2].Descriptor()
+}
+
+func (ModelKind) Type() protoreflect.EnumType {
+	return &file_open_ai_proto_enumTypes[2]
+}
+
+func (x ModelKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ModelKind.Descriptor instead.
+func (ModelKind) EnumDescriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{2}
+}
+
+type ModelStatus int32
+
+const (
+	// UNKNOWN is a model of a provider which can not list its models.
+	ModelStatus_UNKNOWN   ModelStatus = 0
+	ModelStatus_AVAILABLE ModelStatus = 1
+	// UNAVAILABLE is a configured model the provider does not list, chat
+	// requests for it are refused.
+	ModelStatus_UNAVAILABLE ModelStatus = 2
+)
+
+// Enum value maps for ModelStatus.
+var (
+	ModelStatus_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "AVAILABLE",
+		2: "UNAVAILABLE",
+	}
+	ModelStatus_value = map[string]int32{
+		"UNKNOWN":     0,
+		"AVAILABLE":   1,
+		"UNAVAILABLE": 2,
+	}
+)
+
+func (x ModelStatus) Enum() *ModelStatus {
+	p := new(ModelStatus)
+	*p = x
+	return p
+}
+
+func (x ModelStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ModelStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_open_ai_proto_enumTypes[3].Descriptor()
+}
+
+func (ModelStatus) Type() protoreflect.EnumType {
+	return &file_open_ai_proto_enumTypes[3]
+}
+
+func (x ModelStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ModelStatus.Descriptor instead.
+func (ModelStatus) EnumDescriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{3}
+}
+
+type Control_Action int32
+
+const (
+	// STOP stops the answer being generated, its turn completes as stopped.
+	Control_STOP Control_Action = 0
+	// REGENERATE drops the last answer and asks its turn again, images
+	// attached to the turn are not sent again.
+	Control_REGENERATE Control_Action = 1
+	// SET_TEMPERATURE sets the temperature of the turns that follow and of
+	// regenerated answers, params of a turn still take precedence.
+	Control_SET_TEMPERATURE Control_Action = 2
+	// SET_SYSTEM replaces the system prompt of the session, an empty one
+	// drops it. Set before the first turn, it opens the session.
+	Control_SET_SYSTEM Control_Action = 3
+)
+
+// Enum value maps for Control_Action.
+var (
+	Control_Action_name = map[int32]string{
+		0: "STOP",
+		1: "REGENERATE",
+		2: "SET_TEMPERATURE",
+		3: "SET_SYSTEM",
+	}
+	Control_Action_value = map[string]int32{
+		"STOP":            0,
+		"REGENERATE":      1,
+		"SET_TEMPERATURE": 2,
+		"SET_SYSTEM":      3,
+	}
+)
+
+func (x Control_Action) Enum() *Control_Action {
+	p := new(Control_Action)
+	*p = x
+	return p
+}
+
+func (x Control_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Control_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_open_ai_proto_enumTypes[4].Descriptor()
+}
+
+func (Control_Action) Type() protoreflect.EnumType {
+	return &file_open_ai_proto_enumTypes[4]
+}
+
+func (x Control_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Control_Action.Descriptor instead.
+func (Control_Action) EnumDescriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{9, 0}
+}
+
 type Message struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
@@ -126,9 +329,24 @@ func (x *Message) GetContent() string {
 }
 
 type ChatReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Role          Role                   `protobuf:"varint,1,opt,name=role,proto3,enum=server.Role" json:"role,omitempty"`
-	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Role      Role                   `protobuf:"varint,1,opt,name=role,proto3,enum=server.Role" json:"role,omitempty"`
+	Content   string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	SessionId string                 `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// model is a model name or <provider>/<model>, the default model is used
+	// when it is empty.
+	Model  string            `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	Params *GenerationParams `protobuf:"bytes,5,opt,name=params,proto3" json:"params,omitempty"`
+	// json_schema asks for a JSON answer matching the schema, it is returned
+	// in ChatResp.json once validated.
+	JsonSchema  *JSONSchema   `protobuf:"bytes,6,opt,name=json_schema,json=jsonSchema,proto3" json:"json_schema,omitempty"`
+	Attachments []*Attachment `protobuf:"bytes,7,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	// knowledge_bases are searched for the content, the answer cites the chunks
+	// it uses and they are returned in ChatResp.citations.
+	KnowledgeBases []string `protobuf:"bytes,8,rep,name=knowledge_bases,json=knowledgeBases,proto3" json:"knowledge_bases,omitempty"`
+	// no_cache skips the response cache, the answer is neither read from nor
+	// put into it.
+	NoCache       bool `protobuf:"varint,9,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -177,27 +395,81 @@ func (x *ChatReq) GetContent() string {
 	return ""
 }
 
-type ChatResp struct {
+func (x *ChatReq) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ChatReq) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ChatReq) GetParams() *GenerationParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *ChatReq) GetJsonSchema() *JSONSchema {
+	if x != nil {
+		return x.JsonSchema
+	}
+	return nil
+}
+
+func (x *ChatReq) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *ChatReq) GetKnowledgeBases() []string {
+	if x != nil {
+		return x.KnowledgeBases
+	}
+	return nil
+}
+
+func (x *ChatReq) GetNoCache() bool {
+	if x != nil {
+		return x.NoCache
+	}
+	return false
+}
+
+// Attachment is a file sent along with the content. Images need a vision
+// model and may be sent by url, text files are inlined into the content.
+type Attachment struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       *Message               `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	MimeType      string                 `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Url           string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ChatResp) Reset() {
-	*x = ChatResp{}
+func (x *Attachment) Reset() {
+	*x = Attachment{}
 	mi := &file_open_ai_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChatResp) String() string {
+func (x *Attachment) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChatResp) ProtoMessage() {}
+func (*Attachment) ProtoMessage() {}
 
-func (x *ChatResp) ProtoReflect() protoreflect.Message {
+func (x *Attachment) ProtoReflect() protoreflect.Message {
 	mi := &file_open_ai_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -209,93 +481,1881 @@ func (x *ChatResp) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChatResp.ProtoReflect.Descriptor instead.
-func (*ChatResp) Descriptor() ([]byte, []int) {
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
 	return file_open_ai_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *ChatResp) GetMessage() *Message {
+func (x *Attachment) GetName() string {
 	if x != nil {
-		return x.Message
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attachment) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Attachment) GetData() []byte {
+	if x != nil {
+		return x.Data
 	}
 	return nil
 }
 
-var File_open_ai_proto protoreflect.FileDescriptor
+func (x *Attachment) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
 
-var file_open_ai_proto_rawDesc = string([]byte{
-	0x0a, 0x0d, 0x6f, 0x70, 0x65, 0x6e, 0x2d, 0x61, 0x69, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x06, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72,
-	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x23, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
-	0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x22, 0x45, 0x0a, 0x07, 0x43, 0x68, 0x61,
-	0x74, 0x52, 0x65, 0x71, 0x12, 0x20, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x0c, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x52, 0x6f, 0x6c, 0x65,
-	0x52, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
-	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
-	0x22, 0x35, 0x0a, 0x08, 0x43, 0x68, 0x61, 0x74, 0x52, 0x65, 0x73, 0x70, 0x12, 0x29, 0x0a, 0x07,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x07,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2a, 0x50, 0x0a, 0x04, 0x52, 0x6f, 0x6c, 0x65, 0x12,
-	0x0a, 0x0a, 0x06, 0x53, 0x59, 0x53, 0x54, 0x45, 0x4d, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x55,
-	0x53, 0x45, 0x52, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x41, 0x53, 0x53, 0x49, 0x53, 0x54, 0x41,
-	0x4e, 0x54, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x46, 0x55, 0x4e, 0x43, 0x54, 0x49, 0x4f, 0x4e,
-	0x10, 0x03, 0x12, 0x08, 0x0a, 0x04, 0x54, 0x4f, 0x4f, 0x4c, 0x10, 0x04, 0x12, 0x0b, 0x0a, 0x07,
-	0x44, 0x45, 0x56, 0x45, 0x4c, 0x4f, 0x50, 0x10, 0x05, 0x32, 0x3d, 0x0a, 0x06, 0x4f, 0x70, 0x65,
-	0x6e, 0x41, 0x49, 0x12, 0x33, 0x0a, 0x0a, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x43, 0x68, 0x61,
-	0x74, 0x12, 0x0f, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x43, 0x68, 0x61, 0x74, 0x52,
-	0x65, 0x71, 0x1a, 0x10, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x43, 0x68, 0x61, 0x74,
-	0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x30, 0x01, 0x42, 0x07, 0x5a, 0x05, 0x2e, 0x2f, 0x3b, 0x70,
-	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
-})
+type JSONSchema struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Schema string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	// max_retries is how many times an invalid answer is re-prompted.
+	MaxRetries    *int32 `protobuf:"varint,2,opt,name=max_retries,json=maxRetries,proto3,oneof" json:"max_retries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-var (
-	file_open_ai_proto_rawDescOnce sync.Once
-	file_open_ai_proto_rawDescData []byte
-)
+func (x *JSONSchema) Reset() {
+	*x = JSONSchema{}
+	mi := &file_open_ai_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-func file_open_ai_proto_rawDescGZIP() []byte {
-	file_open_ai_proto_rawDescOnce.Do(func() {
-		file_open_ai_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_open_ai_proto_rawDesc), len(file_open_ai_proto_rawDesc)))
-	})
-	return file_open_ai_proto_rawDescData
+func (x *JSONSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_open_ai_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_open_ai_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
-var file_open_ai_proto_goTypes = []any{
-	(Role)(0),        // 0: server.Role
-	(*Message)(nil),  // 1: server.Message
-	(*ChatReq)(nil),  // 2: server.ChatReq
-	(*ChatResp)(nil), // 3: server.ChatResp
+func (*JSONSchema) ProtoMessage() {}
+
+func (x *JSONSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_open_ai_proto_depIdxs = []int32{
-	0, // 0: server.ChatReq.role:type_name -> server.Role
-	1, // 1: server.ChatResp.message:type_name -> server.Message
-	2, // 2: server.OpenAI.CreateChat:input_type -> server.ChatReq
-	3, // 3: server.OpenAI.CreateChat:output_type -> server.ChatResp
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+
+// Deprecated: Use JSONSchema.ProtoReflect.Descriptor instead.
+func (*JSONSchema) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{3}
 }
 
-func init() { file_open_ai_proto_init() }
-func file_open_ai_proto_init() {
-	if File_open_ai_proto != nil {
-		return
+func (x *JSONSchema) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *JSONSchema) GetMaxRetries() int32 {
+	if x != nil && x.MaxRetries != nil {
+		return *x.MaxRetries
+	}
+	return 0
+}
+
+// GenerationParams tunes one request, unset fields keep the model defaults.
+type GenerationParams struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Temperature      *float32               `protobuf:"fixed32,1,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	MaxTokens        *int32                 `protobuf:"varint,2,opt,name=max_tokens,json=maxTokens,proto3,oneof" json:"max_tokens,omitempty"`
+	TopP             *float32               `protobuf:"fixed32,3,opt,name=top_p,json=topP,proto3,oneof" json:"top_p,omitempty"`
+	Stop             []string               `protobuf:"bytes,4,rep,name=stop,proto3" json:"stop,omitempty"`
+	PresencePenalty  *float32               `protobuf:"fixed32,5,opt,name=presence_penalty,json=presencePenalty,proto3,oneof" json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float32               `protobuf:"fixed32,6,opt,name=frequency_penalty,json=frequencyPenalty,proto3,oneof" json:"frequency_penalty,omitempty"`
+	Seed             *int64                 `protobuf:"varint,7,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	N                *int32                 `protobuf:"varint,8,opt,name=n,proto3,oneof" json:"n,omitempty"`
+	ResponseFormat   ResponseFormat         `protobuf:"varint,9,opt,name=response_format,json=responseFormat,proto3,enum=server.ResponseFormat" json:"response_format,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GenerationParams) Reset() {
+	*x = GenerationParams{}
+	mi := &file_open_ai_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationParams) ProtoMessage() {}
+
+func (x *GenerationParams) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationParams.ProtoReflect.Descriptor instead.
+func (*GenerationParams) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenerationParams) GetTemperature() float32 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetMaxTokens() int32 {
+	if x != nil && x.MaxTokens != nil {
+		return *x.MaxTokens
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetTopP() float32 {
+	if x != nil && x.TopP != nil {
+		return *x.TopP
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetStop() []string {
+	if x != nil {
+		return x.Stop
+	}
+	return nil
+}
+
+func (x *GenerationParams) GetPresencePenalty() float32 {
+	if x != nil && x.PresencePenalty != nil {
+		return *x.PresencePenalty
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetFrequencyPenalty() float32 {
+	if x != nil && x.FrequencyPenalty != nil {
+		return *x.FrequencyPenalty
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetN() int32 {
+	if x != nil && x.N != nil {
+		return *x.N
+	}
+	return 0
+}
+
+func (x *GenerationParams) GetResponseFormat() ResponseFormat {
+	if x != nil {
+		return x.ResponseFormat
+	}
+	return ResponseFormat_TEXT
+}
+
+type ChatResp struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Message    *Message               `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	SessionId  string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ToolCall   *ToolCall              `protobuf:"bytes,3,opt,name=tool_call,json=toolCall,proto3" json:"tool_call,omitempty"`
+	ToolResult *ToolResult            `protobuf:"bytes,4,opt,name=tool_result,json=toolResult,proto3" json:"tool_result,omitempty"`
+	// finish_reason is only set on the last frame of a complete answer.
+	FinishReason string `protobuf:"bytes,5,opt,name=finish_reason,json=finishReason,proto3" json:"finish_reason,omitempty"`
+	// index is the choice the frame belongs to when more than one is asked for.
+	Index int32  `protobuf:"varint,6,opt,name=index,proto3" json:"index,omitempty"`
+	Json  string `protobuf:"bytes,7,opt,name=json,proto3" json:"json,omitempty"`
+	// citations are only set on the last frame, they are the chunks of
+	// knowledge bases the answer cites.
+	Citations []*Citation `protobuf:"bytes,8,rep,name=citations,proto3" json:"citations,omitempty"`
+	// cached is set on the last frame when the answer is replayed from the
+	// response cache.
+	Cached bool `protobuf:"varint,9,opt,name=cached,proto3" json:"cached,omitempty"`
+	// usage is only set on the last frame.
+	Usage         *Usage `protobuf:"bytes,10,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatResp) Reset() {
+	*x = ChatResp{}
+	mi := &file_open_ai_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatResp) ProtoMessage() {}
+
+func (x *ChatResp) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatResp.ProtoReflect.Descriptor instead.
+func (*ChatResp) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ChatResp) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ChatResp) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ChatResp) GetToolCall() *ToolCall {
+	if x != nil {
+		return x.ToolCall
+	}
+	return nil
+}
+
+func (x *ChatResp) GetToolResult() *ToolResult {
+	if x != nil {
+		return x.ToolResult
+	}
+	return nil
+}
+
+func (x *ChatResp) GetFinishReason() string {
+	if x != nil {
+		return x.FinishReason
+	}
+	return ""
+}
+
+func (x *ChatResp) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ChatResp) GetJson() string {
+	if x != nil {
+		return x.Json
+	}
+	return ""
+}
+
+func (x *ChatResp) GetCitations() []*Citation {
+	if x != nil {
+		return x.Citations
+	}
+	return nil
+}
+
+func (x *ChatResp) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *ChatResp) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+// Usage is the tokens spent on an answer, all zero for a cached answer or a
+// provider which does not report them.
+type Usage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PromptTokens     int32                  `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int32                  `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	TotalTokens      int32                  `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_open_ai_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Usage) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+type Citation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	KnowledgeBase string                 `protobuf:"bytes,2,opt,name=knowledge_base,json=knowledgeBase,proto3" json:"knowledge_base,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Heading       string                 `protobuf:"bytes,4,opt,name=heading,proto3" json:"heading,omitempty"`
+	StartLine     int32                  `protobuf:"varint,5,opt,name=start_line,json=startLine,proto3" json:"start_line,omitempty"`
+	EndLine       int32                  `protobuf:"varint,6,opt,name=end_line,json=endLine,proto3" json:"end_line,omitempty"`
+	Score         float32                `protobuf:"fixed32,7,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Citation) Reset() {
+	*x = Citation{}
+	mi := &file_open_ai_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Citation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Citation) ProtoMessage() {}
+
+func (x *Citation) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Citation.ProtoReflect.Descriptor instead.
+func (*Citation) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Citation) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Citation) GetKnowledgeBase() string {
+	if x != nil {
+		return x.KnowledgeBase
+	}
+	return ""
+}
+
+func (x *Citation) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Citation) GetHeading() string {
+	if x != nil {
+		return x.Heading
+	}
+	return ""
+}
+
+func (x *Citation) GetStartLine() int32 {
+	if x != nil {
+		return x.StartLine
+	}
+	return 0
+}
+
+func (x *Citation) GetEndLine() int32 {
+	if x != nil {
+		return x.EndLine
+	}
+	return 0
+}
+
+func (x *Citation) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type ChatFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ChatFrame_Turn
+	//	*ChatFrame_Control
+	Frame         isChatFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatFrame) Reset() {
+	*x = ChatFrame{}
+	mi := &file_open_ai_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatFrame) ProtoMessage() {}
+
+func (x *ChatFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatFrame.ProtoReflect.Descriptor instead.
+func (*ChatFrame) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ChatFrame) GetFrame() isChatFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ChatFrame) GetTurn() *ChatReq {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatFrame_Turn); ok {
+			return x.Turn
+		}
+	}
+	return nil
+}
+
+func (x *ChatFrame) GetControl() *Control {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatFrame_Control); ok {
+			return x.Control
+		}
+	}
+	return nil
+}
+
+type isChatFrame_Frame interface {
+	isChatFrame_Frame()
+}
+
+type ChatFrame_Turn struct {
+	// turn is asked in the session of the stream. The first turn opens a
+	// session unless it names one by session_id, a turn sent while an
+	// answer is generated stops that answer first.
+	Turn *ChatReq `protobuf:"bytes,1,opt,name=turn,proto3,oneof"`
+}
+
+type ChatFrame_Control struct {
+	Control *Control `protobuf:"bytes,2,opt,name=control,proto3,oneof"`
+}
+
+func (*ChatFrame_Turn) isChatFrame_Frame() {}
+
+func (*ChatFrame_Control) isChatFrame_Frame() {}
+
+type Control struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        Control_Action         `protobuf:"varint,1,opt,name=action,proto3,enum=server.Control_Action" json:"action,omitempty"`
+	Temperature   float32                `protobuf:"fixed32,2,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	System        string                 `protobuf:"bytes,3,opt,name=system,proto3" json:"system,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Control) Reset() {
+	*x = Control{}
+	mi := &file_open_ai_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Control) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Control) ProtoMessage() {}
+
+func (x *Control) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Control.ProtoReflect.Descriptor instead.
+func (*Control) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Control) GetAction() Control_Action {
+	if x != nil {
+		return x.Action
+	}
+	return Control_STOP
+}
+
+func (x *Control) GetTemperature() float32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *Control) GetSystem() string {
+	if x != nil {
+		return x.System
+	}
+	return ""
+}
+
+type ChatEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ChatEvent_Delta
+	//	*ChatEvent_TurnComplete
+	Event         isChatEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatEvent) Reset() {
+	*x = ChatEvent{}
+	mi := &file_open_ai_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatEvent) ProtoMessage() {}
+
+func (x *ChatEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatEvent.ProtoReflect.Descriptor instead.
+func (*ChatEvent) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ChatEvent) GetEvent() isChatEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetDelta() *ChatResp {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Delta); ok {
+			return x.Delta
+		}
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetTurnComplete() *TurnComplete {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_TurnComplete); ok {
+			return x.TurnComplete
+		}
+	}
+	return nil
+}
+
+type isChatEvent_Event interface {
+	isChatEvent_Event()
+}
+
+type ChatEvent_Delta struct {
+	Delta *ChatResp `protobuf:"bytes,1,opt,name=delta,proto3,oneof"`
+}
+
+type ChatEvent_TurnComplete struct {
+	TurnComplete *TurnComplete `protobuf:"bytes,2,opt,name=turn_complete,json=turnComplete,proto3,oneof"`
+}
+
+func (*ChatEvent_Delta) isChatEvent_Event() {}
+
+func (*ChatEvent_TurnComplete) isChatEvent_Event() {}
+
+// TurnComplete ends the answer of a turn, a failed turn carries the status
+// code and message of the error and the stream stays open.
+type TurnComplete struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// turn counts the turns and regenerations of the stream from 1.
+	Turn          int32       `protobuf:"varint,2,opt,name=turn,proto3" json:"turn,omitempty"`
+	FinishReason  string      `protobuf:"bytes,3,opt,name=finish_reason,json=finishReason,proto3" json:"finish_reason,omitempty"`
+	Stopped       bool        `protobuf:"varint,4,opt,name=stopped,proto3" json:"stopped,omitempty"`
+	Cached        bool        `protobuf:"varint,5,opt,name=cached,proto3" json:"cached,omitempty"`
+	Citations     []*Citation `protobuf:"bytes,6,rep,name=citations,proto3" json:"citations,omitempty"`
+	Code          int32       `protobuf:"varint,7,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string      `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	Usage         *Usage      `protobuf:"bytes,9,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnComplete) Reset() {
+	*x = TurnComplete{}
+	mi := &file_open_ai_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnComplete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnComplete) ProtoMessage() {}
+
+func (x *TurnComplete) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnComplete.ProtoReflect.Descriptor instead.
+func (*TurnComplete) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TurnComplete) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *TurnComplete) GetTurn() int32 {
+	if x != nil {
+		return x.Turn
+	}
+	return 0
+}
+
+func (x *TurnComplete) GetFinishReason() string {
+	if x != nil {
+		return x.FinishReason
+	}
+	return ""
+}
+
+func (x *TurnComplete) GetStopped() bool {
+	if x != nil {
+		return x.Stopped
+	}
+	return false
+}
+
+func (x *TurnComplete) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *TurnComplete) GetCitations() []*Citation {
+	if x != nil {
+		return x.Citations
+	}
+	return nil
+}
+
+func (x *TurnComplete) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *TurnComplete) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TurnComplete) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type ToolCall struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Arguments     string                 `protobuf:"bytes,3,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCall) Reset() {
+	*x = ToolCall{}
+	mi := &file_open_ai_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCall) ProtoMessage() {}
+
+func (x *ToolCall) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
+func (*ToolCall) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ToolCall) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ToolCall) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolCall) GetArguments() string {
+	if x != nil {
+		return x.Arguments
+	}
+	return ""
+}
+
+type ToolResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	IsError       bool                   `protobuf:"varint,4,opt,name=is_error,json=isError,proto3" json:"is_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolResult) Reset() {
+	*x = ToolResult{}
+	mi := &file_open_ai_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolResult) ProtoMessage() {}
+
+func (x *ToolResult) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolResult.ProtoReflect.Descriptor instead.
+func (*ToolResult) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ToolResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ToolResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolResult) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ToolResult) GetIsError() bool {
+	if x != nil {
+		return x.IsError
+	}
+	return false
+}
+
+type Session struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Model        string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	CreatedAt    int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastActiveAt int64                  `protobuf:"varint,4,opt,name=last_active_at,json=lastActiveAt,proto3" json:"last_active_at,omitempty"`
+	Persisted    bool                   `protobuf:"varint,5,opt,name=persisted,proto3" json:"persisted,omitempty"`
+	// provider serves the session, a turn may only switch to another model of
+	// it.
+	Provider      string `protobuf:"bytes,6,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_open_ai_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Session) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Session) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Session) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Session) GetLastActiveAt() int64 {
+	if x != nil {
+		return x.LastActiveAt
+	}
+	return 0
+}
+
+func (x *Session) GetPersisted() bool {
+	if x != nil {
+		return x.Persisted
+	}
+	return false
+}
+
+func (x *Session) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+type Sessions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*Session             `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sessions) Reset() {
+	*x = Sessions{}
+	mi := &file_open_ai_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sessions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sessions) ProtoMessage() {}
+
+func (x *Sessions) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sessions.ProtoReflect.Descriptor instead.
+func (*Sessions) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Sessions) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type Document struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// source is the path or name of the document, a document ingested again
+	// with the same source replaces the earlier one.
+	Source        string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	MimeType      string `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Content       []byte `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Document) Reset() {
+	*x = Document{}
+	mi := &file_open_ai_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Document) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Document) ProtoMessage() {}
+
+func (x *Document) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Document.ProtoReflect.Descriptor instead.
+func (*Document) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Document) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Document) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Document) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type IngestReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KnowledgeBase string                 `protobuf:"bytes,1,opt,name=knowledge_base,json=knowledgeBase,proto3" json:"knowledge_base,omitempty"`
+	Documents     []*Document            `protobuf:"bytes,2,rep,name=documents,proto3" json:"documents,omitempty"`
+	// chunk_size and chunk_overlap are in tokens, defaults are used when 0.
+	ChunkSize     int32 `protobuf:"varint,3,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	ChunkOverlap  int32 `protobuf:"varint,4,opt,name=chunk_overlap,json=chunkOverlap,proto3" json:"chunk_overlap,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestReq) Reset() {
+	*x = IngestReq{}
+	mi := &file_open_ai_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestReq) ProtoMessage() {}
+
+func (x *IngestReq) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestReq.ProtoReflect.Descriptor instead.
+func (*IngestReq) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *IngestReq) GetKnowledgeBase() string {
+	if x != nil {
+		return x.KnowledgeBase
+	}
+	return ""
+}
+
+func (x *IngestReq) GetDocuments() []*Document {
+	if x != nil {
+		return x.Documents
+	}
+	return nil
+}
+
+func (x *IngestReq) GetChunkSize() int32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *IngestReq) GetChunkOverlap() int32 {
+	if x != nil {
+		return x.ChunkOverlap
+	}
+	return 0
+}
+
+type IngestResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KnowledgeBase string                 `protobuf:"bytes,1,opt,name=knowledge_base,json=knowledgeBase,proto3" json:"knowledge_base,omitempty"`
+	Documents     int32                  `protobuf:"varint,2,opt,name=documents,proto3" json:"documents,omitempty"`
+	Chunks        int32                  `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResp) Reset() {
+	*x = IngestResp{}
+	mi := &file_open_ai_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResp) ProtoMessage() {}
+
+func (x *IngestResp) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResp.ProtoReflect.Descriptor instead.
+func (*IngestResp) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *IngestResp) GetKnowledgeBase() string {
+	if x != nil {
+		return x.KnowledgeBase
+	}
+	return ""
+}
+
+func (x *IngestResp) GetDocuments() int32 {
+	if x != nil {
+		return x.Documents
+	}
+	return 0
+}
+
+func (x *IngestResp) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+type ListModelsReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// refresh lists the models of every provider upstream again instead of
+	// using the cached lists.
+	Refresh       bool `protobuf:"varint,1,opt,name=refresh,proto3" json:"refresh,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsReq) Reset() {
+	*x = ListModelsReq{}
+	mi := &file_open_ai_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsReq) ProtoMessage() {}
+
+func (x *ListModelsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsReq.ProtoReflect.Descriptor instead.
+func (*ListModelsReq) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListModelsReq) GetRefresh() bool {
+	if x != nil {
+		return x.Refresh
+	}
+	return false
+}
+
+// Pricing is per million tokens, it is unset when not configured.
+type Pricing struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         float64                `protobuf:"fixed64,1,opt,name=input,proto3" json:"input,omitempty"`
+	Output        float64                `protobuf:"fixed64,2,opt,name=output,proto3" json:"output,omitempty"`
+	Currency      string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pricing) Reset() {
+	*x = Pricing{}
+	mi := &file_open_ai_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pricing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pricing) ProtoMessage() {}
+
+func (x *Pricing) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pricing.ProtoReflect.Descriptor instead.
+func (*Pricing) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Pricing) GetInput() float64 {
+	if x != nil {
+		return x.Input
+	}
+	return 0
+}
+
+func (x *Pricing) GetOutput() float64 {
+	if x != nil {
+		return x.Output
+	}
+	return 0
+}
+
+func (x *Pricing) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type Model struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Provider           string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Name               string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Kind               ModelKind              `protobuf:"varint,3,opt,name=kind,proto3,enum=server.ModelKind" json:"kind,omitempty"`
+	Default            bool                   `protobuf:"varint,4,opt,name=default,proto3" json:"default,omitempty"`
+	ContextWindow      int32                  `protobuf:"varint,5,opt,name=context_window,json=contextWindow,proto3" json:"context_window,omitempty"`
+	MaxOutputTokens    int32                  `protobuf:"varint,6,opt,name=max_output_tokens,json=maxOutputTokens,proto3" json:"max_output_tokens,omitempty"`
+	Vision             bool                   `protobuf:"varint,7,opt,name=vision,proto3" json:"vision,omitempty"`
+	Tools              bool                   `protobuf:"varint,8,opt,name=tools,proto3" json:"tools,omitempty"`
+	JsonMode           bool                   `protobuf:"varint,9,opt,name=json_mode,json=jsonMode,proto3" json:"json_mode,omitempty"`
+	JsonSchema         bool                   `protobuf:"varint,10,opt,name=json_schema,json=jsonSchema,proto3" json:"json_schema,omitempty"`
+	EmbeddingDimension int32                  `protobuf:"varint,11,opt,name=embedding_dimension,json=embeddingDimension,proto3" json:"embedding_dimension,omitempty"`
+	Pricing            *Pricing               `protobuf:"bytes,12,opt,name=pricing,proto3" json:"pricing,omitempty"`
+	Status             ModelStatus            `protobuf:"varint,13,opt,name=status,proto3,enum=server.ModelStatus" json:"status,omitempty"`
+	OwnedBy            string                 `protobuf:"bytes,14,opt,name=owned_by,json=ownedBy,proto3" json:"owned_by,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Model) Reset() {
+	*x = Model{}
+	mi := &file_open_ai_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Model) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Model) ProtoMessage() {}
+
+func (x *Model) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Model.ProtoReflect.Descriptor instead.
+func (*Model) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Model) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Model) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Model) GetKind() ModelKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ModelKind_CHAT
+}
+
+func (x *Model) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+func (x *Model) GetContextWindow() int32 {
+	if x != nil {
+		return x.ContextWindow
+	}
+	return 0
+}
+
+func (x *Model) GetMaxOutputTokens() int32 {
+	if x != nil {
+		return x.MaxOutputTokens
+	}
+	return 0
+}
+
+func (x *Model) GetVision() bool {
+	if x != nil {
+		return x.Vision
+	}
+	return false
+}
+
+func (x *Model) GetTools() bool {
+	if x != nil {
+		return x.Tools
+	}
+	return false
+}
+
+func (x *Model) GetJsonMode() bool {
+	if x != nil {
+		return x.JsonMode
+	}
+	return false
+}
+
+func (x *Model) GetJsonSchema() bool {
+	if x != nil {
+		return x.JsonSchema
+	}
+	return false
+}
+
+func (x *Model) GetEmbeddingDimension() int32 {
+	if x != nil {
+		return x.EmbeddingDimension
+	}
+	return 0
+}
+
+func (x *Model) GetPricing() *Pricing {
+	if x != nil {
+		return x.Pricing
+	}
+	return nil
+}
+
+func (x *Model) GetStatus() ModelStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ModelStatus_UNKNOWN
+}
+
+func (x *Model) GetOwnedBy() string {
+	if x != nil {
+		return x.OwnedBy
+	}
+	return ""
+}
+
+type Models struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Models        []*Model               `protobuf:"bytes,1,rep,name=models,proto3" json:"models,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Models) Reset() {
+	*x = Models{}
+	mi := &file_open_ai_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Models) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Models) ProtoMessage() {}
+
+func (x *Models) ProtoReflect() protoreflect.Message {
+	mi := &file_open_ai_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Models.ProtoReflect.Descriptor instead.
+func (*Models) Descriptor() ([]byte, []int) {
+	return file_open_ai_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Models) GetModels() []*Model {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+var File_open_ai_proto protoreflect.FileDescriptor
+
+const file_open_ai_proto_rawDesc = "" +
+	"\n" +
+	"\ropen-ai.proto\x12\x06server\x1a\x1egoogle/protobuf/wrappers.proto\x1a\x1bgoogle/protobuf/empty.proto\"#\n" +
+	"\aMessage\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"\xdb\x02\n" +
+	"\aChatReq\x12 \n" +
+	"\x04role\x18\x01 \x01(\x0e2\f.server.RoleR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\x12\x14\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\x120\n" +
+	"\x06params\x18\x05 \x01(\v2\x18.server.GenerationParamsR\x06params\x123\n" +
+	"\vjson_schema\x18\x06 \x01(\v2\x12.server.JSONSchemaR\n" +
+	"jsonSchema\x124\n" +
+	"\vattachments\x18\a \x03(\v2\x12.server.AttachmentR\vattachments\x12'\n" +
+	"\x0fknowledge_bases\x18\b \x03(\tR\x0eknowledgeBases\x12\x19\n" +
+	"\bno_cache\x18\t \x01(\bR\anoCache\"c\n" +
+	"\n" +
+	"Attachment\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\"Z\n" +
+	"\n" +
+	"JSONSchema\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\tR\x06schema\x12$\n" +
+	"\vmax_retries\x18\x02 \x01(\x05H\x00R\n" +
+	"maxRetries\x88\x01\x01B\x0e\n" +
+	"\f_max_retries\"\xbd\x03\n" +
+	"\x10GenerationParams\x12%\n" +
+	"\vtemperature\x18\x01 \x01(\x02H\x00R\vtemperature\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"max_tokens\x18\x02 \x01(\x05H\x01R\tmaxTokens\x88\x01\x01\x12\x18\n" +
+	"\x05top_p\x18\x03 \x01(\x02H\x02R\x04topP\x88\x01\x01\x12\x12\n" +
+	"\x04stop\x18\x04 \x03(\tR\x04stop\x12.\n" +
+	"\x10presence_penalty\x18\x05 \x01(\x02H\x03R\x0fpresencePenalty\x88\x01\x01\x120\n" +
+	"\x11frequency_penalty\x18\x06 \x01(\x02H\x04R\x10frequencyPenalty\x88\x01\x01\x12\x17\n" +
+	"\x04seed\x18\a \x01(\x03H\x05R\x04seed\x88\x01\x01\x12\x11\n" +
+	"\x01n\x18\b \x01(\x05H\x06R\x01n\x88\x01\x01\x12?\n" +
+	"\x0fresponse_format\x18\t \x01(\x0e2\x16.server.ResponseFormatR\x0eresponseFormatB\x0e\n" +
+	"\f_temperatureB\r\n" +
+	"\v_max_tokensB\b\n" +
+	"\x06_top_pB\x13\n" +
+	"\x11_presence_penaltyB\x14\n" +
+	"\x12_frequency_penaltyB\a\n" +
+	"\x05_seedB\x04\n" +
+	"\x02_n\"\xf4\x02\n" +
+	"\bChatResp\x12)\n" +
+	"\amessage\x18\x01 \x01(\v2\x0f.server.MessageR\amessage\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12-\n" +
+	"\ttool_call\x18\x03 \x01(\v2\x10.server.ToolCallR\btoolCall\x123\n" +
+	"\vtool_result\x18\x04 \x01(\v2\x12.server.ToolResultR\n" +
+	"toolResult\x12#\n" +
+	"\rfinish_reason\x18\x05 \x01(\tR\ffinishReason\x12\x14\n" +
+	"\x05index\x18\x06 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04json\x18\a \x01(\tR\x04json\x12.\n" +
+	"\tcitations\x18\b \x03(\v2\x10.server.CitationR\tcitations\x12\x16\n" +
+	"\x06cached\x18\t \x01(\bR\x06cached\x12#\n" +
+	"\x05usage\x18\n" +
+	" \x01(\v2\r.server.UsageR\x05usage\"|\n" +
+	"\x05Usage\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x05R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x05R\vtotalTokens\"\xc9\x01\n" +
+	"\bCitation\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12%\n" +
+	"\x0eknowledge_base\x18\x02 \x01(\tR\rknowledgeBase\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x18\n" +
+	"\aheading\x18\x04 \x01(\tR\aheading\x12\x1d\n" +
+	"\n" +
+	"start_line\x18\x05 \x01(\x05R\tstartLine\x12\x19\n" +
+	"\bend_line\x18\x06 \x01(\x05R\aendLine\x12\x14\n" +
+	"\x05score\x18\a \x01(\x02R\x05score\"h\n" +
+	"\tChatFrame\x12%\n" +
+	"\x04turn\x18\x01 \x01(\v2\x0f.server.ChatReqH\x00R\x04turn\x12+\n" +
+	"\acontrol\x18\x02 \x01(\v2\x0f.server.ControlH\x00R\acontrolB\a\n" +
+	"\x05frame\"\xbc\x01\n" +
+	"\aControl\x12.\n" +
+	"\x06action\x18\x01 \x01(\x0e2\x16.server.Control.ActionR\x06action\x12 \n" +
+	"\vtemperature\x18\x02 \x01(\x02R\vtemperature\x12\x16\n" +
+	"\x06system\x18\x03 \x01(\tR\x06system\"G\n" +
+	"\x06Action\x12\b\n" +
+	"\x04STOP\x10\x00\x12\x0e\n" +
+	"\n" +
+	"REGENERATE\x10\x01\x12\x13\n" +
+	"\x0fSET_TEMPERATURE\x10\x02\x12\x0e\n" +
+	"\n" +
+	"SET_SYSTEM\x10\x03\"{\n" +
+	"\tChatEvent\x12(\n" +
+	"\x05delta\x18\x01 \x01(\v2\x10.server.ChatRespH\x00R\x05delta\x12;\n" +
+	"\rturn_complete\x18\x02 \x01(\v2\x14.server.TurnCompleteH\x00R\fturnCompleteB\a\n" +
+	"\x05event\"\x97\x02\n" +
+	"\fTurnComplete\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04turn\x18\x02 \x01(\x05R\x04turn\x12#\n" +
+	"\rfinish_reason\x18\x03 \x01(\tR\ffinishReason\x12\x18\n" +
+	"\astopped\x18\x04 \x01(\bR\astopped\x12\x16\n" +
+	"\x06cached\x18\x05 \x01(\bR\x06cached\x12.\n" +
+	"\tcitations\x18\x06 \x03(\v2\x10.server.CitationR\tcitations\x12\x12\n" +
+	"\x04code\x18\a \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x12#\n" +
+	"\x05usage\x18\t \x01(\v2\r.server.UsageR\x05usage\"L\n" +
+	"\bToolCall\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\targuments\x18\x03 \x01(\tR\targuments\"e\n" +
+	"\n" +
+	"ToolResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x19\n" +
+	"\bis_error\x18\x04 \x01(\bR\aisError\"\xae\x01\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12$\n" +
+	"\x0elast_active_at\x18\x04 \x01(\x03R\flastActiveAt\x12\x1c\n" +
+	"\tpersisted\x18\x05 \x01(\bR\tpersisted\x12\x1a\n" +
+	"\bprovider\x18\x06 \x01(\tR\bprovider\"7\n" +
+	"\bSessions\x12+\n" +
+	"\bsessions\x18\x01 \x03(\v2\x0f.server.SessionR\bsessions\"Y\n" +
+	"\bDocument\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"\xa6\x01\n" +
+	"\tIngestReq\x12%\n" +
+	"\x0eknowledge_base\x18\x01 \x01(\tR\rknowledgeBase\x12.\n" +
+	"\tdocuments\x18\x02 \x03(\v2\x10.server.DocumentR\tdocuments\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x03 \x01(\x05R\tchunkSize\x12#\n" +
+	"\rchunk_overlap\x18\x04 \x01(\x05R\fchunkOverlap\"i\n" +
+	"\n" +
+	"IngestResp\x12%\n" +
+	"\x0eknowledge_base\x18\x01 \x01(\tR\rknowledgeBase\x12\x1c\n" +
+	"\tdocuments\x18\x02 \x01(\x05R\tdocuments\x12\x16\n" +
+	"\x06chunks\x18\x03 \x01(\x05R\x06chunks\")\n" +
+	"\rListModelsReq\x12\x18\n" +
+	"\arefresh\x18\x01 \x01(\bR\arefresh\"S\n" +
+	"\aPricing\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\x01R\x05input\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\x01R\x06output\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\"\xdb\x03\n" +
+	"\x05Model\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x11.server.ModelKindR\x04kind\x12\x18\n" +
+	"\adefault\x18\x04 \x01(\bR\adefault\x12%\n" +
+	"\x0econtext_window\x18\x05 \x01(\x05R\rcontextWindow\x12*\n" +
+	"\x11max_output_tokens\x18\x06 \x01(\x05R\x0fmaxOutputTokens\x12\x16\n" +
+	"\x06vision\x18\a \x01(\bR\x06vision\x12\x14\n" +
+	"\x05tools\x18\b \x01(\bR\x05tools\x12\x1b\n" +
+	"\tjson_mode\x18\t \x01(\bR\bjsonMode\x12\x1f\n" +
+	"\vjson_schema\x18\n" +
+	" \x01(\bR\n" +
+	"jsonSchema\x12/\n" +
+	"\x13embedding_dimension\x18\v \x01(\x05R\x12embeddingDimension\x12)\n" +
+	"\apricing\x18\f \x01(\v2\x0f.server.PricingR\apricing\x12+\n" +
+	"\x06status\x18\r \x01(\x0e2\x13.server.ModelStatusR\x06status\x12\x19\n" +
+	"\bowned_by\x18\x0e \x01(\tR\aownedBy\"/\n" +
+	"\x06Models\x12%\n" +
+	"\x06models\x18\x01 \x03(\v2\r.server.ModelR\x06models*P\n" +
+	"\x04Role\x12\n" +
+	"\n" +
+	"\x06SYSTEM\x10\x00\x12\b\n" +
+	"\x04USER\x10\x01\x12\r\n" +
+	"\tASSISTANT\x10\x02\x12\f\n" +
+	"\bFUNCTION\x10\x03\x12\b\n" +
+	"\x04TOOL\x10\x04\x12\v\n" +
+	"\aDEVELOP\x10\x05*+\n" +
+	"\x0eResponseFormat\x12\b\n" +
+	"\x04TEXT\x10\x00\x12\x0f\n" +
+	"\vJSON_OBJECT\x10\x01*$\n" +
+	"\tModelKind\x12\b\n" +
+	"\x04CHAT\x10\x00\x12\r\n" +
+	"\tEMBEDDING\x10\x01*:\n" +
+	"\vModelStatus\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\r\n" +
+	"\tAVAILABLE\x10\x01\x12\x0f\n" +
+	"\vUNAVAILABLE\x10\x022\xcd\x04\n" +
+	"\x06OpenAI\x123\n" +
+	"\n" +
+	"CreateChat\x12\x0f.server.ChatReq\x1a\x10.server.ChatResp\"\x000\x01\x122\n" +
+	"\x04Chat\x12\x11.server.ChatFrame\x1a\x11.server.ChatEvent\"\x00(\x010\x01\x126\n" +
+	"\rCreateSession\x12\x0f.server.ChatReq\x1a\x10.server.ChatResp\"\x000\x01\x12-\n" +
+	"\x04Send\x12\x0f.server.ChatReq\x1a\x10.server.ChatResp\"\x000\x01\x12F\n" +
+	"\fCloseSession\x12\x1c.google.protobuf.StringValue\x1a\x16.google.protobuf.Empty\"\x00\x12:\n" +
+	"\fListSessions\x12\x16.google.protobuf.Empty\x1a\x10.server.Sessions\"\x00\x12E\n" +
+	"\vSaveSession\x12\x1c.google.protobuf.StringValue\x1a\x16.google.protobuf.Empty\"\x00\x12>\n" +
+	"\vOpenSession\x12\x1c.google.protobuf.StringValue\x1a\x0f.server.Session\"\x00\x121\n" +
+	"\x06Ingest\x12\x11.server.IngestReq\x1a\x12.server.IngestResp\"\x00\x125\n" +
+	"\n" +
+	"ListModels\x12\x15.server.ListModelsReq\x1a\x0e.server.Models\"\x00B\aZ\x05./;pbb\x06proto3"
+
+var (
+	file_open_ai_proto_rawDescOnce sync.Once
+	file_open_ai_proto_rawDescData []byte
+)
+
+func file_open_ai_proto_rawDescGZIP() []byte {
+	file_open_ai_proto_rawDescOnce.Do(func() {
+		file_open_ai_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_open_ai_proto_rawDesc), len(file_open_ai_proto_rawDesc)))
+	})
+	return file_open_ai_proto_rawDescData
+}
+
+var file_open_ai_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_open_ai_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_open_ai_proto_goTypes = []any{
+	(Role)(0),                      // 0: server.Role
+	(ResponseFormat)(0),            // 1: server.ResponseFormat
+	(ModelKind)(0),                 // 2: server.ModelKind
+	(ModelStatus)(0),               // 3: server.ModelStatus
+	(Control_Action)(0),            // 4: server.Control.Action
+	(*Message)(nil),                // 5: server.Message
+	(*ChatReq)(nil),                // 6: server.ChatReq
+	(*Attachment)(nil),             // 7: server.Attachment
+	(*JSONSchema)(nil),             // 8: server.JSONSchema
+	(*GenerationParams)(nil),       // 9: server.GenerationParams
+	(*ChatResp)(nil),               // 10: server.ChatResp
+	(*Usage)(nil),                  // 11: server.Usage
+	(*Citation)(nil),               // 12: server.Citation
+	(*ChatFrame)(nil),              // 13: server.ChatFrame
+	(*Control)(nil),                // 14: server.Control
+	(*ChatEvent)(nil),              // 15: server.ChatEvent
+	(*TurnComplete)(nil),           // 16: server.TurnComplete
+	(*ToolCall)(nil),               // 17: server.ToolCall
+	(*ToolResult)(nil),             // 18: server.ToolResult
+	(*Session)(nil),                // 19: server.Session
+	(*Sessions)(nil),               // 20: server.Sessions
+	(*Document)(nil),               // 21: server.Document
+	(*IngestReq)(nil),              // 22: server.IngestReq
+	(*IngestResp)(nil),             // 23: server.IngestResp
+	(*ListModelsReq)(nil),          // 24: server.ListModelsReq
+	(*Pricing)(nil),                // 25: server.Pricing
+	(*Model)(nil),                  // 26: server.Model
+	(*Models)(nil),                 // 27: server.Models
+	(*wrapperspb.StringValue)(nil), // 28: google.protobuf.StringValue
+	(*emptypb.Empty)(nil),          // 29: google.protobuf.Empty
+}
+var file_open_ai_proto_depIdxs = []int32{
+	0,  // 0: server.ChatReq.role:type_name -> server.Role
+	9,  // 1: server.ChatReq.params:type_name -> server.GenerationParams
+	8,  // 2: server.ChatReq.json_schema:type_name -> server.JSONSchema
+	7,  // 3: server.ChatReq.attachments:type_name -> server.Attachment
+	1,  // 4: server.GenerationParams.response_format:type_name -> server.ResponseFormat
+	5,  // 5: server.ChatResp.message:type_name -> server.Message
+	17, // 6: server.ChatResp.tool_call:type_name -> server.ToolCall
+	18, // 7: server.ChatResp.tool_result:type_name -> server.ToolResult
+	12, // 8: server.ChatResp.citations:type_name -> server.Citation
+	11, // 9: server.ChatResp.usage:type_name -> server.Usage
+	6,  // 10: server.ChatFrame.turn:type_name -> server.ChatReq
+	14, // 11: server.ChatFrame.control:type_name -> server.Control
+	4,  // 12: server.Control.action:type_name -> server.Control.Action
+	10, // 13: server.ChatEvent.delta:type_name -> server.ChatResp
+	16, // 14: server.ChatEvent.turn_complete:type_name -> server.TurnComplete
+	12, // 15: server.TurnComplete.citations:type_name -> server.Citation
+	11, // 16: server.TurnComplete.usage:type_name -> server.Usage
+	19, // 17: server.Sessions.sessions:type_name -> server.Session
+	21, // 18: server.IngestReq.documents:type_name -> server.Document
+	2,  // 19: server.Model.kind:type_name -> server.ModelKind
+	25, // 20: server.Model.pricing:type_name -> server.Pricing
+	3,  // 21: server.Model.status:type_name -> server.ModelStatus
+	26, // 22: server.Models.models:type_name -> server.Model
+	6,  // 23: server.OpenAI.CreateChat:input_type -> server.ChatReq
+	13, // 24: server.OpenAI.Chat:input_type -> server.ChatFrame
+	6,  // 25: server.OpenAI.CreateSession:input_type -> server.ChatReq
+	6,  // 26: server.OpenAI.Send:input_type -> server.ChatReq
+	28, // 27: server.OpenAI.CloseSession:input_type -> google.protobuf.StringValue
+	29, // 28: server.OpenAI.ListSessions:input_type -> google.protobuf.Empty
+	28, // 29: server.OpenAI.SaveSession:input_type -> google.protobuf.StringValue
+	28, // 30: server.OpenAI.OpenSession:input_type -> google.protobuf.StringValue
+	22, // 31: server.OpenAI.Ingest:input_type -> server.IngestReq
+	24, // 32: server.OpenAI.ListModels:input_type -> server.ListModelsReq
+	10, // 33: server.OpenAI.CreateChat:output_type -> server.ChatResp
+	15, // 34: server.OpenAI.Chat:output_type -> server.ChatEvent
+	10, // 35: server.OpenAI.CreateSession:output_type -> server.ChatResp
+	10, // 36: server.OpenAI.Send:output_type -> server.ChatResp
+	29, // 37: server.OpenAI.CloseSession:output_type -> google.protobuf.Empty
+	20, // 38: server.OpenAI.ListSessions:output_type -> server.Sessions
+	29, // 39: server.OpenAI.SaveSession:output_type -> google.protobuf.Empty
+	19, // 40: server.OpenAI.OpenSession:output_type -> server.Session
+	23, // 41: server.OpenAI.Ingest:output_type -> server.IngestResp
+	27, // 42: server.OpenAI.ListModels:output_type -> server.Models
+	33, // [33:43] is the sub-list for method output_type
+	23, // [23:33] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
+}
+
+func init() { file_open_ai_proto_init() }
+func file_open_ai_proto_init() {
+	if File_open_ai_proto != nil {
+		return
+	}
+	file_open_ai_proto_msgTypes[3].OneofWrappers = []any{}
+	file_open_ai_proto_msgTypes[4].OneofWrappers = []any{}
+	file_open_ai_proto_msgTypes[8].OneofWrappers = []any{
+		(*ChatFrame_Turn)(nil),
+		(*ChatFrame_Control)(nil),
+	}
+	file_open_ai_proto_msgTypes[10].OneofWrappers = []any{
+		(*ChatEvent_Delta)(nil),
+		(*ChatEvent_TurnComplete)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_open_ai_proto_rawDesc), len(file_open_ai_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   3,
+			NumEnums:      5,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -11,6 +11,8 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -19,7 +21,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	OpenAI_CreateChat_FullMethodName = "/server.OpenAI/CreateChat"
+	OpenAI_CreateChat_FullMethodName    = "/server.OpenAI/CreateChat"
+	OpenAI_Chat_FullMethodName          = "/server.OpenAI/Chat"
+	OpenAI_CreateSession_FullMethodName = "/server.OpenAI/CreateSession"
+	OpenAI_Send_FullMethodName          = "/server.OpenAI/Send"
+	OpenAI_CloseSession_FullMethodName  = "/server.OpenAI/CloseSession"
+	OpenAI_ListSessions_FullMethodName  = "/server.OpenAI/ListSessions"
+	OpenAI_SaveSession_FullMethodName   = "/server.OpenAI/SaveSession"
+	OpenAI_OpenSession_FullMethodName   = "/server.OpenAI/OpenSession"
+	OpenAI_Ingest_FullMethodName        = "/server.OpenAI/Ingest"
+	OpenAI_ListModels_FullMethodName    = "/server.OpenAI/ListModels"
 )
 
 // OpenAIClient is the client API for OpenAI service.
@@ -27,6 +38,18 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type OpenAIClient interface {
 	CreateChat(ctx context.Context, in *ChatReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatResp], error)
+	// Chat is a conversation on one long-lived stream, the client sends turns
+	// and control frames, the server streams the answers, each ended by a
+	// turn_complete event.
+	Chat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ChatFrame, ChatEvent], error)
+	CreateSession(ctx context.Context, in *ChatReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatResp], error)
+	Send(ctx context.Context, in *ChatReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatResp], error)
+	CloseSession(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Sessions, error)
+	SaveSession(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	OpenSession(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*Session, error)
+	Ingest(ctx context.Context, in *IngestReq, opts ...grpc.CallOption) (*IngestResp, error)
+	ListModels(ctx context.Context, in *ListModelsReq, opts ...grpc.CallOption) (*Models, error)
 }
 
 type openAIClient struct {
@@ -56,11 +79,134 @@ func (c *openAIClient) CreateChat(ctx context.Context, in *ChatReq, opts ...grpc
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type OpenAI_CreateChatClient = grpc.ServerStreamingClient[ChatResp]
 
+func (c *openAIClient) Chat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ChatFrame, ChatEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OpenAI_ServiceDesc.Streams[1], OpenAI_Chat_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatFrame, ChatEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OpenAI_ChatClient = grpc.BidiStreamingClient[ChatFrame, ChatEvent]
+
+func (c *openAIClient) CreateSession(ctx context.Context, in *ChatReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatResp], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OpenAI_ServiceDesc.Streams[2], OpenAI_CreateSession_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatReq, ChatResp]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OpenAI_CreateSessionClient = grpc.ServerStreamingClient[ChatResp]
+
+func (c *openAIClient) Send(ctx context.Context, in *ChatReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatResp], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OpenAI_ServiceDesc.Streams[3], OpenAI_Send_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatReq, ChatResp]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OpenAI_SendClient = grpc.ServerStreamingClient[ChatResp]
+
+func (c *openAIClient) CloseSession(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, OpenAI_CloseSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openAIClient) ListSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Sessions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Sessions)
+	err := c.cc.Invoke(ctx, OpenAI_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openAIClient) SaveSession(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, OpenAI_SaveSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openAIClient) OpenSession(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, OpenAI_OpenSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openAIClient) Ingest(ctx context.Context, in *IngestReq, opts ...grpc.CallOption) (*IngestResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestResp)
+	err := c.cc.Invoke(ctx, OpenAI_Ingest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *openAIClient) ListModels(ctx context.Context, in *ListModelsReq, opts ...grpc.CallOption) (*Models, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Models)
+	err := c.cc.Invoke(ctx, OpenAI_ListModels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OpenAIServer is the server API for OpenAI service.
 // All implementations must embed UnimplementedOpenAIServer
 // for forward compatibility.
 type OpenAIServer interface {
 	CreateChat(*ChatReq, grpc.ServerStreamingServer[ChatResp]) error
+	// Chat is a conversation on one long-lived stream, the client sends turns
+	// and control frames, the server streams the answers, each ended by a
+	// turn_complete event.
+	Chat(grpc.BidiStreamingServer[ChatFrame, ChatEvent]) error
+	CreateSession(*ChatReq, grpc.ServerStreamingServer[ChatResp]) error
+	Send(*ChatReq, grpc.ServerStreamingServer[ChatResp]) error
+	CloseSession(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error)
+	ListSessions(context.Context, *emptypb.Empty) (*Sessions, error)
+	SaveSession(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error)
+	OpenSession(context.Context, *wrapperspb.StringValue) (*Session, error)
+	Ingest(context.Context, *IngestReq) (*IngestResp, error)
+	ListModels(context.Context, *ListModelsReq) (*Models, error)
 	mustEmbedUnimplementedOpenAIServer()
 }
 
@@ -74,6 +220,33 @@ type UnimplementedOpenAIServer struct{}
 func (UnimplementedOpenAIServer) CreateChat(*ChatReq, grpc.ServerStreamingServer[ChatResp]) error {
 	return status.Errorf(codes.Unimplemented, "method CreateChat not implemented")
 }
+func (UnimplementedOpenAIServer) Chat(grpc.BidiStreamingServer[ChatFrame, ChatEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Chat not implemented")
+}
+func (UnimplementedOpenAIServer) CreateSession(*ChatReq, grpc.ServerStreamingServer[ChatResp]) error {
+	return status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (UnimplementedOpenAIServer) Send(*ChatReq, grpc.ServerStreamingServer[ChatResp]) error {
+	return status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedOpenAIServer) CloseSession(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseSession not implemented")
+}
+func (UnimplementedOpenAIServer) ListSessions(context.Context, *emptypb.Empty) (*Sessions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedOpenAIServer) SaveSession(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveSession not implemented")
+}
+func (UnimplementedOpenAIServer) OpenSession(context.Context, *wrapperspb.StringValue) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenSession not implemented")
+}
+func (UnimplementedOpenAIServer) Ingest(context.Context, *IngestReq) (*IngestResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedOpenAIServer) ListModels(context.Context, *ListModelsReq) (*Models, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModels not implemented")
+}
 func (UnimplementedOpenAIServer) mustEmbedUnimplementedOpenAIServer() {}
 func (UnimplementedOpenAIServer) testEmbeddedByValue()                {}
 
@@ -106,19 +279,197 @@ func _OpenAI_CreateChat_Handler(srv interface{}, stream grpc.ServerStream) error
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type OpenAI_CreateChatServer = grpc.ServerStreamingServer[ChatResp]
 
+func _OpenAI_Chat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OpenAIServer).Chat(&grpc.GenericServerStream[ChatFrame, ChatEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OpenAI_ChatServer = grpc.BidiStreamingServer[ChatFrame, ChatEvent]
+
+func _OpenAI_CreateSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChatReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OpenAIServer).CreateSession(m, &grpc.GenericServerStream[ChatReq, ChatResp]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OpenAI_CreateSessionServer = grpc.ServerStreamingServer[ChatResp]
+
+func _OpenAI_Send_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChatReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OpenAIServer).Send(m, &grpc.GenericServerStream[ChatReq, ChatResp]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OpenAI_SendServer = grpc.ServerStreamingServer[ChatResp]
+
+func _OpenAI_CloseSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenAIServer).CloseSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenAI_CloseSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenAIServer).CloseSession(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenAI_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenAIServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenAI_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenAIServer).ListSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenAI_SaveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenAIServer).SaveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenAI_SaveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenAIServer).SaveSession(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenAI_OpenSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenAIServer).OpenSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenAI_OpenSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenAIServer).OpenSession(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenAI_Ingest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenAIServer).Ingest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenAI_Ingest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenAIServer).Ingest(ctx, req.(*IngestReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OpenAI_ListModels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListModelsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OpenAIServer).ListModels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OpenAI_ListModels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OpenAIServer).ListModels(ctx, req.(*ListModelsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // OpenAI_ServiceDesc is the grpc.ServiceDesc for OpenAI service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var OpenAI_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "server.OpenAI",
 	HandlerType: (*OpenAIServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CloseSession",
+			Handler:    _OpenAI_CloseSession_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _OpenAI_ListSessions_Handler,
+		},
+		{
+			MethodName: "SaveSession",
+			Handler:    _OpenAI_SaveSession_Handler,
+		},
+		{
+			MethodName: "OpenSession",
+			Handler:    _OpenAI_OpenSession_Handler,
+		},
+		{
+			MethodName: "Ingest",
+			Handler:    _OpenAI_Ingest_Handler,
+		},
+		{
+			MethodName: "ListModels",
+			Handler:    _OpenAI_ListModels_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "CreateChat",
 			Handler:       _OpenAI_CreateChat_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Chat",
+			Handler:       _OpenAI_Chat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "CreateSession",
+			Handler:       _OpenAI_CreateSession_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Send",
+			Handler:       _OpenAI_Send_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "open-ai.proto",
 }
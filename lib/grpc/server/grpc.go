@@ -31,6 +31,10 @@ type GRPC struct {
 	RegisteredAPI []func(*grpc.Server)
 }
 
+// maxRecvMsgSize leaves room for requests which carry files, such as chat
+// attachments, the default of grpc is 4MB.
+const maxRecvMsgSize = 64 << 20
+
 func NewGRPC(network *network.Config, log *log.Config, supported ...func(*grpc.Server)) *GRPC {
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -110,6 +114,7 @@ func (g *GRPC) Serve() error {
 			// middleware.StreamServerLogInterceptor,
 		),
 		grpc.Creds(creds),
+		grpc.MaxRecvMsgSize(maxRecvMsgSize),
 		// grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	)
 
@@ -0,0 +1,395 @@
+// Package llmtest serves the OpenAI compatible api on a local httptest server
+// with scripted replies, so that the code talking to a model can be tested
+// without network access.
+package llmtest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sashabaranov/go-openai"
+)
+
+const DefaultDimension = 8
+
+// Reply is the scripted answer of one request. A reply with Status other than
+// 200 fails the request, otherwise Tokens are streamed one by one, followed by
+// ToolCalls.
+type Reply struct {
+	Tokens       []string
+	ToolCalls    []openai.ToolCall
+	FinishReason openai.FinishReason
+
+	Status     int
+	Message    string
+	RetryAfter string
+
+	// Delay is waited before each token, Hang keeps the stream open after the
+	// last token until the client goes away.
+	Delay time.Duration
+	Hang  bool
+}
+
+// Tokens answers with the tokens.
+func Tokens(tokens ...string) *Reply {
+	return &Reply{Tokens: tokens, FinishReason: openai.FinishReasonStop}
+}
+
+// ToolCall answers with a call of the tool.
+func ToolCall(id, name, arguments string) *Reply {
+	return &Reply{
+		ToolCalls: []openai.ToolCall{{
+			ID:       id,
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: name, Arguments: arguments},
+		}},
+		FinishReason: openai.FinishReasonToolCalls,
+	}
+}
+
+// Error fails the request with status and message.
+func Error(status int, message string) *Reply {
+	return &Reply{Status: status, Message: message}
+}
+
+func (r *Reply) WithDelay(delay time.Duration) *Reply {
+	r.Delay = delay
+	return r
+}
+
+func (r *Reply) WithHang() *Reply {
+	r.Hang = true
+	return r
+}
+
+func (r *Reply) WithRetryAfter(val string) *Reply {
+	r.RetryAfter = val
+	return r
+}
+
+func (r *Reply) WithFinishReason(reason openai.FinishReason) *Reply {
+	r.FinishReason = reason
+	return r
+}
+
+// Server is an OpenAI compatible server. Chat requests take the enqueued
+// replies in order and answer "ok" when none is left, embeddings are derived
+// from the input text unless a failure is enqueued. The models are listed once
+// set, before that the endpoint answers 404 like a backend without it.
+type Server struct {
+	*httptest.Server
+
+	Dimension int
+
+	mu         sync.Mutex
+	replies    []*Reply
+	embeddings []*Reply
+	requests   []openai.ChatCompletionRequest
+	inputs     []string
+	models     []string
+	listed     int
+}
+
+func NewServer() *Server {
+	s := &Server{Dimension: DefaultDimension}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", s.handleChat)
+	mux.HandleFunc("POST /v1/embeddings", s.handleEmbeddings)
+	mux.HandleFunc("GET /v1/models", s.handleModels)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseURL is the base url to configure a client with.
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1"
+}
+
+func (s *Server) Enqueue(replies ...*Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replies = append(s.replies, replies...)
+}
+
+// EnqueueEmbeddings scripts the next embeddings requests, only the failure
+// fields and Delay of a reply are used.
+func (s *Server) EnqueueEmbeddings(replies ...*Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.embeddings = append(s.embeddings, replies...)
+}
+
+// SetModels sets the models listed by /v1/models, nil removes the endpoint.
+func (s *Server) SetModels(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.models = ids
+}
+
+// Listed returns how many times the models were listed.
+func (s *Server) Listed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listed
+}
+
+// Requests returns the chat requests received so far.
+func (s *Server) Requests() []openai.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]openai.ChatCompletionRequest(nil), s.requests...)
+}
+
+// Inputs returns the texts of the embeddings requests received so far.
+func (s *Server) Inputs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.inputs...)
+}
+
+func (s *Server) next(queue *[]*Reply) *Reply {
+	if len(*queue) == 0 {
+		return nil
+	}
+	reply := (*queue)[0]
+	*queue = (*queue)[1:]
+	return reply
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req openai.ChatCompletionRequest
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, &Reply{Status: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	reply := s.next(&s.replies)
+	s.mu.Unlock()
+
+	if reply == nil {
+		reply = Tokens("ok")
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		writeError(w, reply)
+		return
+	}
+
+	if !req.Stream {
+		writeCompletion(w, req.Model, reply)
+		return
+	}
+	var usage *openai.Usage
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		usage = usageOf(req, reply)
+	}
+	writeStream(w, r, req.Model, reply, usage)
+}
+
+// usageOf counts a token a word of the messages and a token a token of the
+// reply, so that tests can tell the usage reported.
+func usageOf(req openai.ChatCompletionRequest, reply *Reply) *openai.Usage {
+	var prompt int
+	for _, message := range req.Messages {
+		prompt += len(strings.Fields(message.Content))
+	}
+	return &openai.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: len(reply.Tokens),
+		TotalTokens:      prompt + len(reply.Tokens),
+	}
+}
+
+func writeCompletion(w http.ResponseWriter, model string, reply *Reply) {
+	resp := openai.ChatCompletionResponse{
+		ID:      "chatcmpl-mock",
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role:      openai.ChatMessageRoleAssistant,
+				Content:   strings.Join(reply.Tokens, ""),
+				ToolCalls: reply.ToolCalls,
+			},
+			FinishReason: reply.FinishReason,
+		}},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w).Encode(resp)
+}
+
+// writeStream streams reply, followed by a chunk of usage without choices
+// when usage is asked for.
+func writeStream(w http.ResponseWriter, r *http.Request, model string, reply *Reply, usage *openai.Usage) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(delta openai.ChatCompletionStreamChoiceDelta, reason openai.FinishReason) bool {
+		chunk := openai.ChatCompletionStreamResponse{
+			ID:      "chatcmpl-mock",
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: delta, FinishReason: reason}},
+		}
+		buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(chunk)
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", buf); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	for _, token := range reply.Tokens {
+		if reply.Delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(reply.Delay):
+			}
+		}
+		if !send(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant, Content: token}, "") {
+			return
+		}
+	}
+	for i, call := range reply.ToolCalls {
+		index := i
+		call.Index = &index
+		if !send(openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{call}}, "") {
+			return
+		}
+	}
+	if reply.Hang {
+		<-r.Context().Done()
+		return
+	}
+
+	send(openai.ChatCompletionStreamChoiceDelta{}, reply.FinishReason)
+	if usage != nil {
+		chunk := openai.ChatCompletionStreamResponse{
+			ID:      "chatcmpl-mock",
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []openai.ChatCompletionStreamChoice{},
+			Usage:   usage,
+		}
+		buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", buf)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, &Reply{Status: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.inputs = append(s.inputs, req.Input...)
+	reply := s.next(&s.embeddings)
+	dimension := s.Dimension
+	s.mu.Unlock()
+
+	if reply != nil {
+		if reply.Delay > 0 {
+			time.Sleep(reply.Delay)
+		}
+		if reply.Status != 0 && reply.Status != http.StatusOK {
+			writeError(w, reply)
+			return
+		}
+	}
+
+	resp := openai.EmbeddingResponse{Object: "list", Model: openai.EmbeddingModel(req.Model)}
+	for i, text := range req.Input {
+		resp.Data = append(resp.Data, openai.Embedding{Object: "embedding", Index: i, Embedding: Embed(text, dimension)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.listed++
+	ids := s.models
+	s.mu.Unlock()
+
+	if ids == nil {
+		writeError(w, &Reply{Status: http.StatusNotFound})
+		return
+	}
+
+	resp := openai.ModelsList{Models: make([]openai.Model, 0, len(ids))}
+	for _, id := range ids {
+		resp.Models = append(resp.Models, openai.Model{ID: id, Object: "model", OwnedBy: "llmtest"})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, reply *Reply) {
+	if reply.RetryAfter != "" {
+		w.Header().Set("Retry-After", reply.RetryAfter)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(reply.Status)
+
+	message := reply.Message
+	if message == "" {
+		message = http.StatusText(reply.Status)
+	}
+	fmt.Fprintf(w, `{"error":{"message":%q,"type":"mock_error"}}`, message)
+}
+
+// Embed returns the vector the server answers for text, texts sharing more
+// characters are closer.
+func Embed(text string, dimension int) []float32 {
+	vec := make([]float32, dimension)
+	for _, r := range strings.ToLower(text) {
+		h := fnv.New32a()
+		io.WriteString(h, string(r))
+		vec[h.Sum32()%uint32(dimension)]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		vec[0] = 1
+		return vec
+	}
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / math.Sqrt(norm))
+	}
+	return vec
+}
@@ -0,0 +1,95 @@
+package retrieval
+
+import (
+	"math"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// stopwords carry no meaning for keyword search, the segmenter keeps Chinese
+// particles as single characters.
+var stopwords = map[string]struct{}{
+	"的": {}, "了": {}, "吗": {}, "呢": {}, "吧": {}, "啊": {}, "是": {}, "在": {}, "和": {}, "与": {},
+	"就": {}, "都": {}, "也": {}, "还": {}, "把": {}, "被": {}, "给": {}, "让": {}, "我": {}, "你": {},
+	"他": {}, "她": {}, "它": {}, "这": {}, "那": {}, "有": {}, "个": {}, "要": {}, "会": {}, "请": {},
+	"a": {}, "an": {}, "the": {}, "is": {}, "are": {}, "was": {}, "be": {}, "to": {}, "of": {}, "and": {},
+	"or": {}, "in": {}, "on": {}, "at": {}, "for": {}, "with": {}, "it": {}, "this": {}, "that": {}, "i": {},
+	"you": {}, "do": {}, "does": {}, "how": {}, "what": {}, "can": {}, "my": {}, "me": {}, "please": {},
+}
+
+func terms(segment func(string) []string, text string) []string {
+	words := segment(text)
+	data := words[:0]
+	for _, word := range words {
+		if _, ok := stopwords[word]; !ok {
+			data = append(data, word)
+		}
+	}
+	return data
+}
+
+// bm25 scores a small corpus, it is built from the payloads read from the
+// store and cached along with them by the Retriever. A search only walks the
+// postings of its terms.
+type bm25 struct {
+	postings map[string][]posting
+	lens     []int
+	avgLen   float64
+}
+
+// posting is a doc holding a term, tf times.
+type posting struct {
+	doc int
+	tf  int
+}
+
+func newBM25(docs [][]string) *bm25 {
+	b := &bm25{
+		postings: make(map[string][]posting, 256),
+		lens:     make([]int, 0, len(docs)),
+	}
+
+	var total int
+	for i, doc := range docs {
+		tf := make(map[string]int, len(doc))
+		for _, term := range doc {
+			tf[term]++
+		}
+		for term, f := range tf {
+			b.postings[term] = append(b.postings[term], posting{doc: i, tf: f})
+		}
+		b.lens = append(b.lens, len(doc))
+		total += len(doc)
+	}
+	if len(docs) != 0 {
+		b.avgLen = float64(total) / float64(len(docs))
+	}
+	return b
+}
+
+// score returns the score of every doc for query, in the order of the docs.
+func (b *bm25) score(query []string) []float64 {
+	unique := make(map[string]struct{}, len(query))
+	for _, term := range query {
+		unique[term] = struct{}{}
+	}
+
+	n := float64(len(b.lens))
+	scores := make([]float64, len(b.lens))
+	for term := range unique {
+		postings := b.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range postings {
+			norm := 1 - bm25B + bm25B*float64(b.lens[p.doc])/b.avgLen
+			scores[p.doc] += idf * float64(p.tf) * (bm25K1 + 1) / (float64(p.tf) + bm25K1*norm)
+		}
+	}
+	return scores
+}
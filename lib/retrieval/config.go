@@ -0,0 +1,66 @@
+package retrieval
+
+import (
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type Config struct {
+	// TopK is the max number of hits returned.
+	TopK int `json:"top_k" toml:"top_k" mapstructure:"top_k"`
+	// Candidates is the number of hits taken from each of vector and keyword
+	// search before they are fused.
+	Candidates int `json:"candidates" toml:"candidates" mapstructure:"candidates"`
+	// MinVectorScore drops the vector hits with a lower cosine similarity.
+	MinVectorScore float32 `json:"min_vector_score" toml:"min_vector_score" mapstructure:"min_vector_score"`
+	// MinKeywordScore drops the keyword hits with a lower BM25 score, a hit
+	// always shares at least one word with the query.
+	MinKeywordScore float64 `json:"min_keyword_score" toml:"min_keyword_score" mapstructure:"min_keyword_score"`
+	// RRFK is the k of reciprocal rank fusion, a larger k flattens the weight
+	// of the top ranks.
+	RRFK int `json:"rrf_k" toml:"rrf_k" mapstructure:"rrf_k"`
+	// MaxScan is the max number of stored payloads scored by keyword search,
+	// they are kept in the keyword index of the collection until it is written.
+	MaxScan int `json:"max_scan" toml:"max_scan" mapstructure:"max_scan"`
+	// Dictionary is a file of extra words for Chinese segmentation, one word
+	// per line.
+	Dictionary string `json:"dictionary" toml:"dictionary" mapstructure:"dictionary"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		TopK:           5,
+		Candidates:     20,
+		MinVectorScore: 0.2,
+		RRFK:           60,
+		MaxScan:        10000,
+	}
+}
+
+func (c *Config) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Config) VerifyConfig() error {
+	if c.TopK <= 0 {
+		return fmt.Errorf("retrieval.top_k has wrong value, top_k: %d", c.TopK)
+	}
+	if c.Candidates < c.TopK {
+		return fmt.Errorf("retrieval.candidates is less than top_k, candidates: %d", c.Candidates)
+	}
+	if c.MinVectorScore < -1 || c.MinVectorScore > 1 {
+		return fmt.Errorf("retrieval.min_vector_score has wrong value, min_vector_score: %v", c.MinVectorScore)
+	}
+	if c.MinKeywordScore < 0 {
+		return fmt.Errorf("retrieval.min_keyword_score has wrong value, min_keyword_score: %v", c.MinKeywordScore)
+	}
+	if c.RRFK <= 0 {
+		return fmt.Errorf("retrieval.rrf_k has wrong value, rrf_k: %d", c.RRFK)
+	}
+	if c.MaxScan < 0 {
+		return fmt.Errorf("retrieval.max_scan has wrong value, max_scan: %d", c.MaxScan)
+	}
+	return nil
+}
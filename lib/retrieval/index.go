@@ -0,0 +1,152 @@
+package retrieval
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/eviltomorrow/open-terminal/lib/zlog"
+	"go.uber.org/zap"
+)
+
+// maxIndexes bounds the keyword indexes kept, the one searched least recently
+// is dropped first.
+const maxIndexes = 256
+
+// index is the keyword index of a collection, the payloads scrolled from the
+// store along with their BM25 statistics.
+type index struct {
+	points   []*vectorstore.Point
+	bm25     *bm25
+	lastUsed time.Time
+}
+
+// indexes caches the keyword index of every collection searched without a
+// filter, the index of a collection is dropped when it is written through
+// Retriever.Store.
+type indexes struct {
+	sync.Mutex
+
+	data map[string]*index
+	// gen counts the writes, an index scrolled across a write is not kept.
+	gen uint64
+}
+
+func newIndexes() *indexes {
+	return &indexes{data: make(map[string]*index, 32)}
+}
+
+func (x *indexes) get(collection string) (*index, uint64) {
+	x.Lock()
+	defer x.Unlock()
+
+	idx, ok := x.data[collection]
+	if !ok {
+		return nil, x.gen
+	}
+	idx.lastUsed = time.Now()
+	return idx, x.gen
+}
+
+func (x *indexes) put(collection string, idx *index, gen uint64) {
+	x.Lock()
+	defer x.Unlock()
+
+	if x.gen != gen {
+		return
+	}
+	idx.lastUsed = time.Now()
+	x.data[collection] = idx
+	if len(x.data) <= maxIndexes {
+		return
+	}
+
+	var oldest string
+	for name, e := range x.data {
+		if oldest == "" || e.lastUsed.Before(x.data[oldest].lastUsed) {
+			oldest = name
+		}
+	}
+	delete(x.data, oldest)
+}
+
+func (x *indexes) invalidate(collection string) {
+	x.Lock()
+	defer x.Unlock()
+
+	x.gen++
+	delete(x.data, collection)
+}
+
+// index returns the keyword index of the collection, the cached one when the
+// search has no filter.
+func (r *Retriever) index(ctx context.Context, collection string, filter vectorstore.Filter) (*index, error) {
+	cacheable := len(filter) == 0
+	var gen uint64
+	if cacheable {
+		var idx *index
+		if idx, gen = r.indexes.get(collection); idx != nil {
+			return idx, nil
+		}
+	}
+
+	points, err := r.store.Scroll(ctx, collection, filter, 0, r.config.MaxScan)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == r.config.MaxScan {
+		zlog.Warn("Keyword search scans part of the collection, raise retrieval.max_scan to scan all of it", zap.String("collection", collection), zap.Int("maxScan", r.config.MaxScan))
+	}
+
+	docs := make([][]string, 0, len(points))
+	for _, point := range points {
+		content, _ := point.Payload[ContentField].(string)
+		docs = append(docs, terms(r.segmenter.Segment, content))
+	}
+	idx := &index{points: points, bm25: newBM25(docs)}
+	if cacheable {
+		r.indexes.put(collection, idx, gen)
+	}
+	return idx, nil
+}
+
+// Store returns the store of the retriever with its writes dropping the
+// keyword indexes of the collections written. The collections searched must
+// be written through it, or keyword search goes on with their old contents.
+func (r *Retriever) Store() vectorstore.VectorStore {
+	return &indexedStore{VectorStore: r.store, indexes: r.indexes}
+}
+
+type indexedStore struct {
+	vectorstore.VectorStore
+	indexes *indexes
+}
+
+// the indexes are dropped once the write is done, so that a search in the
+// meantime does not keep the contents before it.
+
+func (s *indexedStore) CreateCollection(ctx context.Context, name string, dimension int) error {
+	defer s.indexes.invalidate(name)
+	return s.VectorStore.CreateCollection(ctx, name, dimension)
+}
+
+func (s *indexedStore) DeleteCollection(ctx context.Context, name string) error {
+	defer s.indexes.invalidate(name)
+	return s.VectorStore.DeleteCollection(ctx, name)
+}
+
+func (s *indexedStore) Upsert(ctx context.Context, collection string, points ...*vectorstore.Point) error {
+	defer s.indexes.invalidate(collection)
+	return s.VectorStore.Upsert(ctx, collection, points...)
+}
+
+func (s *indexedStore) Delete(ctx context.Context, collection string, ids ...uint64) error {
+	defer s.indexes.invalidate(collection)
+	return s.VectorStore.Delete(ctx, collection, ids...)
+}
+
+func (s *indexedStore) DeleteByFilter(ctx context.Context, collection string, filter vectorstore.Filter) error {
+	defer s.indexes.invalidate(collection)
+	return s.VectorStore.DeleteByFilter(ctx, collection, filter)
+}
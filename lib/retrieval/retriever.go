@@ -0,0 +1,176 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eviltomorrow/open-terminal/lib/textutil"
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+)
+
+// ContentField is the payload field holding the text of a point.
+const ContentField = "content"
+
+type Query struct {
+	Collection string
+	Text       string
+	// Vector is the embedding of Text, vector search is skipped when it is
+	// nil.
+	Vector []float32
+	Filter vectorstore.Filter
+	// TopK overrides Config.TopK when it is not 0.
+	TopK int
+}
+
+type Hit struct {
+	Id uint64
+	// Score is the fused score, it only orders hits and is no similarity.
+	Score        float64
+	VectorScore  float32
+	KeywordScore float64
+	Payload      vectorstore.Payload
+}
+
+func (h *Hit) Content() string {
+	content, _ := h.Payload[ContentField].(string)
+	return content
+}
+
+// Retriever searches a collection by vector similarity and by BM25 over the
+// stored contents, and fuses both rankings with reciprocal rank fusion.
+type Retriever struct {
+	store     vectorstore.VectorStore
+	config    *Config
+	segmenter *textutil.Segmenter
+	indexes   *indexes
+}
+
+// NewRetriever creates a Retriever over store, c is DefaultConfig when nil.
+func NewRetriever(store vectorstore.VectorStore, c *Config) (*Retriever, error) {
+	if c == nil {
+		c = DefaultConfig()
+	}
+	if err := c.VerifyConfig(); err != nil {
+		return nil, err
+	}
+
+	var words []string
+	if c.Dictionary != "" {
+		data, err := textutil.LoadWords(c.Dictionary)
+		if err != nil {
+			return nil, fmt.Errorf("load retrieval dictionary failure, nest error: %v", err)
+		}
+		words = data
+	}
+	return &Retriever{store: store, config: c, segmenter: textutil.NewSegmenter(words...), indexes: newIndexes()}, nil
+}
+
+func (r *Retriever) Search(ctx context.Context, query *Query) ([]*Hit, error) {
+	hits := make(map[uint64]*Hit, 2*r.config.Candidates)
+
+	if query.Vector != nil {
+		points, err := r.store.Query(ctx, query.Collection, &vectorstore.Query{
+			Vector:   query.Vector,
+			Limit:    r.config.Candidates,
+			MinScore: r.config.MinVectorScore,
+			Filter:   query.Filter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("vector search failure, nest error: %v", err)
+		}
+		for rank, point := range points {
+			hit := &Hit{Id: point.Id, VectorScore: point.Score, Payload: point.Payload}
+			hit.Score += r.rrf(rank)
+			hits[point.Id] = hit
+		}
+	}
+
+	keywords, err := r.keywordSearch(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("keyword search failure, nest error: %v", err)
+	}
+	for rank, kw := range keywords {
+		hit, ok := hits[kw.Id]
+		if !ok {
+			hit = &Hit{Id: kw.Id, Payload: kw.Payload}
+			hits[kw.Id] = hit
+		}
+		hit.KeywordScore = kw.KeywordScore
+		hit.Score += r.rrf(rank)
+	}
+
+	data := make([]*Hit, 0, len(hits))
+	for _, hit := range hits {
+		data = append(data, hit)
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Score == data[j].Score {
+			return data[i].Id < data[j].Id
+		}
+		return data[i].Score > data[j].Score
+	})
+	return r.cut(data, query.TopK), nil
+}
+
+// TopK returns the number of hits returned when a query does not set it.
+func (r *Retriever) TopK() int {
+	return r.config.TopK
+}
+
+func (r *Retriever) rrf(rank int) float64 {
+	return 1 / float64(r.config.RRFK+rank+1)
+}
+
+// keywordSearch scores the contents stored in the collection against the
+// query and returns the best candidates.
+func (r *Retriever) keywordSearch(ctx context.Context, query *Query) ([]*Hit, error) {
+	words := terms(r.segmenter.Segment, query.Text)
+	if len(words) == 0 || r.config.MaxScan == 0 {
+		return nil, nil
+	}
+
+	idx, err := r.index(ctx, query.Collection, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+	points, scores := idx.points, idx.bm25.score(words)
+
+	data := make([]*Hit, 0, len(points))
+	for i, point := range points {
+		if scores[i] <= 0 || scores[i] < r.config.MinKeywordScore {
+			continue
+		}
+		data = append(data, &Hit{Id: point.Id, KeywordScore: scores[i], Payload: point.Payload})
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].KeywordScore > data[j].KeywordScore
+	})
+	if len(data) > r.config.Candidates {
+		data = data[:r.config.Candidates]
+	}
+	return data, nil
+}
+
+// cut drops the hits whose content repeats a better hit and keeps the top k.
+func (r *Retriever) cut(hits []*Hit, topK int) []*Hit {
+	if topK <= 0 {
+		topK = r.config.TopK
+	}
+
+	seen := make(map[string]struct{}, len(hits))
+	data := make([]*Hit, 0, min(topK, len(hits)))
+	for _, hit := range hits {
+		if len(data) == topK {
+			break
+		}
+		key := strings.Join(strings.Fields(strings.ToLower(hit.Content())), " ")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		data = append(data, hit)
+	}
+	return data
+}
@@ -0,0 +1,76 @@
+package retrieval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eviltomorrow/open-terminal/lib/vectorstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBM25(t *testing.T) {
+	_assert := assert.New(t)
+
+	b := newBM25([][]string{
+		{"重启", "服务"},
+		{"备份", "数据库", "数据库"},
+		{"服务", "日志", "日志", "日志"},
+	})
+	scores := b.score([]string{"重启", "服务", "服务"})
+	_assert.Greater(scores[0], scores[2])
+	_assert.Equal(0.0, scores[1])
+}
+
+func TestSearch(t *testing.T) {
+	_assert := assert.New(t)
+	ctx := context.Background()
+
+	store := vectorstore.NewMemory()
+	_assert.Nil(store.CreateCollection(ctx, "test", 2))
+	_assert.Nil(store.Upsert(ctx, "test",
+		&vectorstore.Point{Id: 1, Vector: []float32{1, 0}, Payload: vectorstore.Payload{"content": "如何备份数据库"}},
+		&vectorstore.Point{Id: 2, Vector: []float32{0, 1}, Payload: vectorstore.Payload{"content": "用 systemctl 重启服务"}},
+		&vectorstore.Point{Id: 3, Vector: []float32{1, 0.1}, Payload: vectorstore.Payload{"content": "如何备份数据库 "}},
+		&vectorstore.Point{Id: 4, Vector: []float32{-1, 0}, Payload: vectorstore.Payload{"content": "今天天气很好"}},
+	))
+
+	r, err := NewRetriever(store, nil)
+	_assert.Nil(err)
+
+	// the vector points at backups, the words at the restart
+	hits, err := r.Search(ctx, &Query{Collection: "test", Text: "服务怎么重启", Vector: []float32{1, 0}})
+	_assert.Nil(err)
+	_assert.Equal(2, len(hits))
+	_assert.Equal(uint64(1), hits[0].Id)
+	_assert.Equal(uint64(2), hits[1].Id)
+	_assert.Greater(hits[1].KeywordScore, 0.0)
+	_assert.Equal(float32(0), hits[1].VectorScore)
+
+	// a hit of both searches ranks first
+	hits, err = r.Search(ctx, &Query{Collection: "test", Text: "重启服务", Vector: []float32{0, 1}, TopK: 1})
+	_assert.Nil(err)
+	_assert.Equal(1, len(hits))
+	_assert.Equal("用 systemctl 重启服务", hits[0].Content())
+
+	hits, err = r.Search(ctx, &Query{Collection: "test", Text: "天气"})
+	_assert.Nil(err)
+	_assert.Equal(1, len(hits))
+	_assert.Equal(uint64(4), hits[0].Id)
+
+	// the keyword index is kept until the collection is written through the
+	// store of the retriever
+	_assert.Equal(1, len(r.indexes.data))
+	_assert.Nil(r.Store().Upsert(ctx, "test", &vectorstore.Point{Id: 5, Vector: []float32{0, -1}, Payload: vectorstore.Payload{"content": "明天天气转晴"}}))
+	_assert.Equal(0, len(r.indexes.data))
+	hits, err = r.Search(ctx, &Query{Collection: "test", Text: "天气"})
+	_assert.Nil(err)
+	_assert.Equal(2, len(hits))
+
+	// a search with a filter is never cached
+	_, err = r.Search(ctx, &Query{Collection: "test", Text: "天气", Filter: vectorstore.Filter{"content": "今天天气很好"}})
+	_assert.Nil(err)
+	_assert.Equal(1, len(r.indexes.data))
+
+	_, err = NewRetriever(store, &Config{TopK: 5, Candidates: 1, RRFK: 60})
+	_assert.NotNil(err)
+}
@@ -3,6 +3,7 @@ package snowflake
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 )
@@ -41,3 +42,12 @@ func GenerateID() string {
 		return result
 	}
 }
+
+// ParseTime returns when id was made by GenerateID.
+func ParseTime(id string) (time.Time, error) {
+	n, err := snowflake.ParseString(id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse id failure, nest error: %v", err)
+	}
+	return time.UnixMilli(n.Time()), nil
+}
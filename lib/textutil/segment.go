@@ -0,0 +1,204 @@
+package textutil
+
+import (
+	"bufio"
+	_ "embed"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed dict.txt
+var dictionary string
+
+var defaultSegmenter = sync.OnceValue(func() *Segmenter {
+	return NewSegmenter()
+})
+
+// Segment splits text into words with the built-in dictionary, see
+// Segmenter.Segment.
+func Segment(text string) []string {
+	return defaultSegmenter().Segment(text)
+}
+
+// Segmenter splits Chinese text into words by bidirectional maximum matching
+// against a dictionary. It is read only once created and safe for concurrent
+// use.
+type Segmenter struct {
+	words  map[string]struct{}
+	maxLen int
+}
+
+// NewSegmenter creates a Segmenter with the built-in dictionary and words.
+func NewSegmenter(words ...string) *Segmenter {
+	s := &Segmenter{words: make(map[string]struct{}, 1024)}
+	s.add(parseWords(dictionary)...)
+	s.add(words...)
+	return s
+}
+
+// LoadWords reads a dictionary file of one word per line, lines starting with
+// # are comments.
+func LoadWords(path string) ([]string, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseWords(string(buf)), nil
+}
+
+func parseWords(text string) []string {
+	var data []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		data = append(data, strings.Fields(line)[0])
+	}
+	return data
+}
+
+func (s *Segmenter) add(words ...string) {
+	for _, word := range words {
+		n := utf8.RuneCountInString(word)
+		if n < 2 {
+			continue
+		}
+		s.words[strings.ToLower(word)] = struct{}{}
+		s.maxLen = max(s.maxLen, n)
+	}
+}
+
+// Segment splits text into lower-cased words. Latin words and numbers are split
+// on everything which is not a letter or digit, a run of CJK characters is cut
+// into dictionary words, and the characters left between them are kept as
+// pairs of neighbouring characters, or alone when there is only one.
+func (s *Segmenter) Segment(text string) []string {
+	var (
+		terms = make([]string, 0, len(text)/2)
+		word  strings.Builder
+		cjk   = make([]rune, 0, 16)
+	)
+
+	flushWord := func() {
+		if word.Len() != 0 {
+			terms = append(terms, word.String())
+			word.Reset()
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) != 0 {
+			terms = append(terms, s.cut(cjk)...)
+			cjk = cjk[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case IsCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return terms
+}
+
+// cut prefers the matching of fewer words, then the one with fewer single
+// characters, and the backward one on a tie since it is right more often for
+// Chinese.
+func (s *Segmenter) cut(run []rune) []string {
+	forward, backward := s.forward(run), s.backward(run)
+
+	words := backward
+	switch {
+	case len(forward) < len(backward):
+		words = forward
+	case len(forward) == len(backward) && singles(forward) < singles(backward):
+		words = forward
+	}
+	return pairSingles(words)
+}
+
+func (s *Segmenter) forward(run []rune) []string {
+	data := make([]string, 0, len(run))
+	for i := 0; i < len(run); {
+		n := min(s.maxLen, len(run)-i)
+		for ; n > 1; n-- {
+			if _, ok := s.words[string(run[i:i+n])]; ok {
+				break
+			}
+		}
+		data = append(data, string(run[i:i+n]))
+		i += n
+	}
+	return data
+}
+
+func (s *Segmenter) backward(run []rune) []string {
+	data := make([]string, 0, len(run))
+	for j := len(run); j > 0; {
+		n := min(s.maxLen, j)
+		for ; n > 1; n-- {
+			if _, ok := s.words[string(run[j-n:j])]; ok {
+				break
+			}
+		}
+		data = append(data, string(run[j-n:j]))
+		j -= n
+	}
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return data
+}
+
+func singles(words []string) int {
+	var n int
+	for _, word := range words {
+		if utf8.RuneCountInString(word) == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// pairSingles replaces each run of single characters by the pairs of
+// neighbouring characters in it, an unknown word of two or more characters is
+// then still found by its pairs.
+func pairSingles(words []string) []string {
+	data := make([]string, 0, len(words))
+	run := make([]string, 0, 8)
+	flush := func() {
+		if len(run) == 1 {
+			data = append(data, run[0])
+		}
+		for i := 0; i+1 < len(run); i++ {
+			data = append(data, run[i]+run[i+1])
+		}
+		run = run[:0]
+	}
+
+	for _, word := range words {
+		if utf8.RuneCountInString(word) == 1 {
+			run = append(run, word)
+			continue
+		}
+		flush()
+		data = append(data, word)
+	}
+	flush()
+	return data
+}
@@ -0,0 +1,61 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// IsCJK reports whether r is a Chinese, Japanese or Korean character, those
+// languages are written without spaces between words.
+func IsCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// Tokenize splits text into lower-cased terms. Latin words and numbers are split
+// on everything which is not a letter or digit, a run of CJK characters yields
+// every single character and every pair of neighbouring characters, which works
+// as a dictionary-free segmentation for retrieval.
+func Tokenize(text string) []string {
+	var (
+		terms = make([]string, 0, len(text)/2)
+		word  strings.Builder
+		cjk   = make([]rune, 0, 16)
+	)
+
+	flushWord := func() {
+		if word.Len() != 0 {
+			terms = append(terms, word.String())
+			word.Reset()
+		}
+	}
+	flushCJK := func() {
+		for i, r := range cjk {
+			terms = append(terms, string(r))
+			if i+1 < len(cjk) {
+				terms = append(terms, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case IsCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return terms
+}
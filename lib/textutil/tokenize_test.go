@@ -0,0 +1,27 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	_assert := assert.New(t)
+
+	_assert.Equal([]string{"restart", "the", "service", "v2"}, Tokenize("Restart the service, v2!"))
+	_assert.Equal([]string{"重", "重启", "启", "启服", "服", "服务", "务"}, Tokenize("重启服务"))
+	_assert.Equal([]string{"如", "如何", "何", "nginx", "重", "重启", "启"}, Tokenize("如何nginx重启？"))
+	_assert.Equal([]string{}, Tokenize(" ,。 "))
+}
+
+func TestSegment(t *testing.T) {
+	_assert := assert.New(t)
+
+	_assert.Equal([]string{"如何", "重启", "服务"}, Segment("如何重启服务？"))
+	_assert.Equal([]string{"nginx", "报错", "502", "怎么", "排查"}, Segment("Nginx报错502怎么排查"))
+	_assert.Equal([]string{"服务器", "磁盘空间", "不足"}, Segment("服务器磁盘空间不足"))
+	_assert.Equal([]string{"我的", "内存泄漏"}, Segment("我的内存泄漏"))
+	_assert.Equal([]string{"查鲁", "鲁棒", "棒性"}, Segment("查鲁棒性"))
+	_assert.Equal([]string{"查", "鲁棒性"}, NewSegmenter("鲁棒性").Segment("查鲁棒性"))
+}
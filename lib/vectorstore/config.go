@@ -0,0 +1,30 @@
+package vectorstore
+
+import (
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	KindMemory = "memory"
+	KindQdrant = "qdrant"
+)
+
+type Config struct {
+	Kind string `json:"kind" toml:"kind" mapstructure:"kind"`
+}
+
+func (c *Config) String() string {
+	buf, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(c)
+	return string(buf)
+}
+
+func (c *Config) VerifyConfig() error {
+	switch c.Kind {
+	case KindMemory, KindQdrant:
+	default:
+		return fmt.Errorf("vector_store.kind has wrong value, kind: %s", c.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Memory is a VectorStore which keeps everything in process and scores every
+// point with exact cosine similarity, for tests and single node installs.
+type Memory struct {
+	sync.RWMutex
+
+	collections map[string]*memoryCollection
+}
+
+type memoryCollection struct {
+	dimension int
+	points    map[uint64]*Point
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		collections: make(map[string]*memoryCollection, 16),
+	}
+}
+
+func (m *Memory) CreateCollection(ctx context.Context, name string, dimension int) error {
+	if dimension <= 0 {
+		return fmt.Errorf("invalid dimension: %d", dimension)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.collections[name]; ok {
+		return fmt.Errorf("collection already exists, name: %s", name)
+	}
+	m.collections[name] = &memoryCollection{
+		dimension: dimension,
+		points:    make(map[uint64]*Point, 64),
+	}
+	return nil
+}
+
+func (m *Memory) DeleteCollection(ctx context.Context, name string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.collections[name]; !ok {
+		return fmt.Errorf("collection not found, name: %s", name)
+	}
+	delete(m.collections, name)
+	return nil
+}
+
+func (m *Memory) CollectionExists(ctx context.Context, name string) (bool, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	_, ok := m.collections[name]
+	return ok, nil
+}
+
+func (m *Memory) ListCollections(ctx context.Context) ([]string, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	data := make([]string, 0, len(m.collections))
+	for name := range m.collections {
+		data = append(data, name)
+	}
+	sort.Strings(data)
+	return data, nil
+}
+
+func (m *Memory) Upsert(ctx context.Context, collection string, points ...*Point) error {
+	m.Lock()
+	defer m.Unlock()
+
+	c, ok := m.collections[collection]
+	if !ok {
+		return fmt.Errorf("collection not found, name: %s", collection)
+	}
+
+	copied := make([]*Point, 0, len(points))
+	for _, p := range points {
+		if len(p.Vector) != c.dimension {
+			return fmt.Errorf("vector dimension mismatch, expect: %d, actual: %d", c.dimension, len(p.Vector))
+		}
+		payload, err := copyPayload(p.Payload)
+		if err != nil {
+			return err
+		}
+		vector := make([]float32, len(p.Vector))
+		copy(vector, p.Vector)
+
+		copied = append(copied, &Point{Id: p.Id, Vector: vector, Payload: payload})
+	}
+	for _, p := range copied {
+		c.points[p.Id] = p
+	}
+	return nil
+}
+
+func (m *Memory) Query(ctx context.Context, collection string, query *Query) ([]*ScoredPoint, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	c, ok := m.collections[collection]
+	if !ok {
+		return nil, fmt.Errorf("collection not found, name: %s", collection)
+	}
+	if len(query.Vector) != c.dimension {
+		return nil, fmt.Errorf("vector dimension mismatch, expect: %d, actual: %d", c.dimension, len(query.Vector))
+	}
+
+	filter, err := copyPayload(Payload(query.Filter))
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*ScoredPoint, 0, len(c.points))
+	for _, p := range c.points {
+		if !matchPayload(p.Payload, filter) {
+			continue
+		}
+		score := cosine(query.Vector, p.Vector)
+		if query.MinScore != 0 && score < query.MinScore {
+			continue
+		}
+		payload, _ := copyPayload(p.Payload)
+		data = append(data, &ScoredPoint{Id: p.Id, Score: score, Payload: payload})
+	}
+
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Score == data[j].Score {
+			return data[i].Id < data[j].Id
+		}
+		return data[i].Score > data[j].Score
+	})
+	if limit := limitOf(query); len(data) > limit {
+		data = data[:limit]
+	}
+	return data, nil
+}
+
+func (m *Memory) Scroll(ctx context.Context, collection string, filter Filter, offset uint64, limit int) ([]*Point, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	c, ok := m.collections[collection]
+	if !ok {
+		return nil, fmt.Errorf("collection not found, name: %s", collection)
+	}
+	match, err := copyPayload(Payload(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*Point, 0, min(limit, len(c.points)))
+	for _, p := range c.points {
+		if p.Id < offset || !matchPayload(p.Payload, match) {
+			continue
+		}
+		payload, _ := copyPayload(p.Payload)
+		data = append(data, &Point{Id: p.Id, Payload: payload})
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Id < data[j].Id
+	})
+	if len(data) > limit {
+		data = data[:limit]
+	}
+	return data, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, collection string, ids ...uint64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	c, ok := m.collections[collection]
+	if !ok {
+		return fmt.Errorf("collection not found, name: %s", collection)
+	}
+	for _, id := range ids {
+		delete(c.points, id)
+	}
+	return nil
+}
+
+func (m *Memory) DeleteByFilter(ctx context.Context, collection string, filter Filter) error {
+	if len(filter) == 0 {
+		return fmt.Errorf("filter is nil")
+	}
+	match, err := copyPayload(Payload(filter))
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	c, ok := m.collections[collection]
+	if !ok {
+		return fmt.Errorf("collection not found, name: %s", collection)
+	}
+	for id, p := range c.points {
+		if matchPayload(p.Payload, match) {
+			delete(c.points, id)
+		}
+	}
+	return nil
+}
+
+func copyPayload(payload Payload) (Payload, error) {
+	data := make(Payload, len(payload))
+	for k, v := range payload {
+		value, err := normalize(v)
+		if err != nil {
+			return nil, fmt.Errorf("payload[%s]: %v", k, err)
+		}
+		data[k] = value
+	}
+	return data, nil
+}
+
+func matchPayload(payload Payload, filter Payload) bool {
+	for k, v := range filter {
+		if payload[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func cosine(a, b []float32) float32 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
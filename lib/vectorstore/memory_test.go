@@ -0,0 +1,78 @@
+package vectorstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQuery(t *testing.T) {
+	_assert := assert.New(t)
+	ctx := context.Background()
+
+	m := NewMemory()
+	_assert.Nil(m.CreateCollection(ctx, "test", 2))
+	_assert.NotNil(m.CreateCollection(ctx, "test", 2))
+
+	_assert.Nil(m.Upsert(ctx, "test",
+		&Point{Id: 1, Vector: []float32{1, 0}, Payload: Payload{"content": "a", "turn": 1}},
+		&Point{Id: 2, Vector: []float32{1, 1}, Payload: Payload{"content": "b", "turn": 2}},
+		&Point{Id: 3, Vector: []float32{0, 1}, Payload: Payload{"content": "c", "turn": 2}},
+	))
+	_assert.NotNil(m.Upsert(ctx, "test", &Point{Id: 4, Vector: []float32{1}}))
+
+	points, err := m.Query(ctx, "test", &Query{Vector: []float32{1, 0}})
+	_assert.Nil(err)
+	_assert.Equal(3, len(points))
+	_assert.Equal(uint64(1), points[0].Id)
+	_assert.Equal(uint64(2), points[1].Id)
+	_assert.Equal(int64(1), points[0].Payload["turn"])
+
+	points, err = m.Query(ctx, "test", &Query{Vector: []float32{1, 0}, Limit: 1})
+	_assert.Nil(err)
+	_assert.Equal(1, len(points))
+
+	points, err = m.Query(ctx, "test", &Query{Vector: []float32{1, 0}, MinScore: 0.5})
+	_assert.Nil(err)
+	_assert.Equal(2, len(points))
+
+	points, err = m.Query(ctx, "test", &Query{Vector: []float32{1, 0}, Filter: Filter{"turn": 2}})
+	_assert.Nil(err)
+	_assert.Equal(2, len(points))
+	_assert.Equal("b", points[0].Payload["content"])
+
+	_assert.Nil(m.Delete(ctx, "test", 2))
+	points, err = m.Query(ctx, "test", &Query{Vector: []float32{1, 0}, Filter: Filter{"turn": 2}})
+	_assert.Nil(err)
+	_assert.Equal(1, len(points))
+
+	scrolled, err := m.Scroll(ctx, "test", nil, 0, 10)
+	_assert.Nil(err)
+	_assert.Equal(2, len(scrolled))
+	_assert.Equal(uint64(1), scrolled[0].Id)
+	_assert.Nil(scrolled[0].Vector)
+	_assert.Equal("a", scrolled[0].Payload["content"])
+	scrolled, err = m.Scroll(ctx, "test", nil, scrolled[0].Id+1, 10)
+	_assert.Nil(err)
+	_assert.Equal(1, len(scrolled))
+	_assert.Equal(uint64(3), scrolled[0].Id)
+
+	_assert.NotNil(m.DeleteByFilter(ctx, "test", nil))
+	_assert.Nil(m.DeleteByFilter(ctx, "test", Filter{"turn": 1}))
+	points, err = m.Query(ctx, "test", &Query{Vector: []float32{1, 0}})
+	_assert.Nil(err)
+	_assert.Equal(1, len(points))
+	_assert.Equal(uint64(3), points[0].Id)
+
+	names, err := m.ListCollections(ctx)
+	_assert.Nil(err)
+	_assert.Equal([]string{"test"}, names)
+
+	_assert.Nil(m.DeleteCollection(ctx, "test"))
+	ok, err := m.CollectionExists(ctx, "test")
+	_assert.Nil(err)
+	_assert.False(ok)
+	_, err = m.Query(ctx, "test", &Query{Vector: []float32{1, 0}})
+	_assert.NotNil(err)
+}
@@ -0,0 +1,195 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// Qdrant is a VectorStore backed by a qdrant server.
+type Qdrant struct {
+	client *qdrant.Client
+}
+
+func NewQdrant(client *qdrant.Client) *Qdrant {
+	return &Qdrant{client: client}
+}
+
+func (q *Qdrant) CreateCollection(ctx context.Context, name string, dimension int) error {
+	if dimension <= 0 {
+		return fmt.Errorf("invalid dimension: %d", dimension)
+	}
+
+	return q.client.CreateCollection(ctx, &qdrant.CreateCollection{
+		CollectionName: name,
+		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+			Size:     uint64(dimension),
+			Distance: qdrant.Distance_Cosine,
+		}),
+	})
+}
+
+func (q *Qdrant) DeleteCollection(ctx context.Context, name string) error {
+	return q.client.DeleteCollection(ctx, name)
+}
+
+func (q *Qdrant) CollectionExists(ctx context.Context, name string) (bool, error) {
+	return q.client.CollectionExists(ctx, name)
+}
+
+func (q *Qdrant) ListCollections(ctx context.Context) ([]string, error) {
+	return q.client.ListCollections(ctx)
+}
+
+func (q *Qdrant) Upsert(ctx context.Context, collection string, points ...*Point) error {
+	data := make([]*qdrant.PointStruct, 0, len(points))
+	for _, p := range points {
+		payload, err := copyPayload(p.Payload)
+		if err != nil {
+			return err
+		}
+		data = append(data, &qdrant.PointStruct{
+			Id:      qdrant.NewIDNum(p.Id),
+			Vectors: qdrant.NewVectors(p.Vector...),
+			Payload: qdrant.NewValueMap(payload),
+		})
+	}
+
+	_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: collection,
+		Points:         data,
+	})
+	return err
+}
+
+func (q *Qdrant) Query(ctx context.Context, collection string, query *Query) ([]*ScoredPoint, error) {
+	filter, err := buildFilter(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &qdrant.QueryPoints{
+		CollectionName: collection,
+		Query:          qdrant.NewQuery(query.Vector...),
+		Filter:         filter,
+		Limit:          qdrant.PtrOf(uint64(limitOf(query))),
+		WithPayload:    qdrant.NewWithPayload(true),
+	}
+	if query.MinScore != 0 {
+		req.ScoreThreshold = qdrant.PtrOf(query.MinScore)
+	}
+
+	points, err := q.client.Query(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*ScoredPoint, 0, len(points))
+	for _, point := range points {
+		data = append(data, &ScoredPoint{
+			Id:      point.GetId().GetNum(),
+			Score:   point.GetScore(),
+			Payload: parsePayload(point.GetPayload()),
+		})
+	}
+	return data, nil
+}
+
+func (q *Qdrant) Scroll(ctx context.Context, collection string, filter Filter, offset uint64, limit int) ([]*Point, error) {
+	f, err := buildFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := q.client.Scroll(ctx, &qdrant.ScrollPoints{
+		CollectionName: collection,
+		Filter:         f,
+		Offset:         qdrant.NewIDNum(offset),
+		Limit:          qdrant.PtrOf(uint32(limit)),
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*Point, 0, len(points))
+	for _, point := range points {
+		data = append(data, &Point{
+			Id:      point.GetId().GetNum(),
+			Payload: parsePayload(point.GetPayload()),
+		})
+	}
+	return data, nil
+}
+
+func (q *Qdrant) Delete(ctx context.Context, collection string, ids ...uint64) error {
+	pointIds := make([]*qdrant.PointId, 0, len(ids))
+	for _, id := range ids {
+		pointIds = append(pointIds, qdrant.NewIDNum(id))
+	}
+
+	_, err := q.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: collection,
+		Points:         qdrant.NewPointsSelector(pointIds...),
+	})
+	return err
+}
+
+func (q *Qdrant) DeleteByFilter(ctx context.Context, collection string, filter Filter) error {
+	if len(filter) == 0 {
+		return fmt.Errorf("filter is nil")
+	}
+	f, err := buildFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: collection,
+		Points:         qdrant.NewPointsSelectorFilter(f),
+	})
+	return err
+}
+
+func buildFilter(filter Filter) (*qdrant.Filter, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+
+	conditions := make([]*qdrant.Condition, 0, len(filter))
+	for k, v := range filter {
+		value, err := normalize(v)
+		if err != nil {
+			return nil, fmt.Errorf("filter[%s]: %v", k, err)
+		}
+		switch value := value.(type) {
+		case string:
+			conditions = append(conditions, qdrant.NewMatchKeyword(k, value))
+		case int64:
+			conditions = append(conditions, qdrant.NewMatchInt(k, value))
+		case bool:
+			conditions = append(conditions, qdrant.NewMatchBool(k, value))
+		default:
+			return nil, fmt.Errorf("filter[%s]: unsupported match value type: %T", k, v)
+		}
+	}
+	return &qdrant.Filter{Must: conditions}, nil
+}
+
+func parsePayload(payload map[string]*qdrant.Value) Payload {
+	data := make(Payload, len(payload))
+	for k, v := range payload {
+		switch kind := v.GetKind().(type) {
+		case *qdrant.Value_StringValue:
+			data[k] = kind.StringValue
+		case *qdrant.Value_IntegerValue:
+			data[k] = kind.IntegerValue
+		case *qdrant.Value_DoubleValue:
+			data[k] = kind.DoubleValue
+		case *qdrant.Value_BoolValue:
+			data[k] = kind.BoolValue
+		}
+	}
+	return data
+}
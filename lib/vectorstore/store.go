@@ -0,0 +1,81 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+)
+
+const DefaultQueryLimit = 10
+
+// Payload is the data stored along with a vector, values must be string, int64,
+// float64 or bool so that every store keeps them the same way.
+type Payload map[string]any
+
+// Filter matches the points whose payload has every key with the equal value,
+// values must be string, int64 or bool.
+type Filter map[string]any
+
+type Point struct {
+	Id      uint64
+	Vector  []float32
+	Payload Payload
+}
+
+type ScoredPoint struct {
+	Id      uint64
+	Score   float32
+	Payload Payload
+}
+
+type Query struct {
+	Vector []float32
+	// Limit is the max number of points, DefaultQueryLimit when it is 0.
+	Limit int
+	// MinScore drops the points scored lower, no cut-off when it is 0.
+	MinScore float32
+	Filter   Filter
+}
+
+// VectorStore keeps points in named collections and searches them by cosine
+// similarity.
+type VectorStore interface {
+	CreateCollection(ctx context.Context, name string, dimension int) error
+	DeleteCollection(ctx context.Context, name string) error
+	CollectionExists(ctx context.Context, name string) (bool, error)
+	ListCollections(ctx context.Context) ([]string, error)
+
+	Upsert(ctx context.Context, collection string, points ...*Point) error
+	Query(ctx context.Context, collection string, query *Query) ([]*ScoredPoint, error)
+	// Scroll returns up to limit points matched by filter from the id offset
+	// on without their vectors, in the order of their ids. The next page
+	// starts after the id of the last point.
+	Scroll(ctx context.Context, collection string, filter Filter, offset uint64, limit int) ([]*Point, error)
+	Delete(ctx context.Context, collection string, ids ...uint64) error
+	// DeleteByFilter removes the points matched by filter, which must not be
+	// empty.
+	DeleteByFilter(ctx context.Context, collection string, filter Filter) error
+}
+
+func normalize(v any) (any, error) {
+	switch value := v.(type) {
+	case string, int64, float64, bool:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case uint32:
+		return int64(value), nil
+	case float32:
+		return float64(value), nil
+	default:
+		return nil, fmt.Errorf("unsupported payload value type: %T", v)
+	}
+}
+
+func limitOf(query *Query) int {
+	if query.Limit <= 0 {
+		return DefaultQueryLimit
+	}
+	return query.Limit
+}